@@ -20,13 +20,15 @@ type CreateWidgetRequest struct {
 
 // GetWidgetRequest is a value object for retrieving widgets.
 type GetWidgetRequest struct {
-	Subject  cazi.Subject
-	WidgetID string
+	Subject        cazi.Subject
+	WidgetID       string
+	AtLeastAsFresh cazi.ConsistencyToken // optional, e.g. from a previous WidgetResponse
 }
 
 // ListWidgetsRequest is a value object for listing widgets.
 type ListWidgetsRequest struct {
-	Subject cazi.Subject
+	Subject        cazi.Subject
+	AtLeastAsFresh cazi.ConsistencyToken // optional, e.g. from a previous WidgetResponse
 }
 
 // WidgetResponse is a value object representing a widget in the application layer.
@@ -35,6 +37,10 @@ type WidgetResponse struct {
 	Name        string
 	Description string
 	OwnerID     string
+
+	// ConsistencyToken is set when the authorizer stores relationships.
+	// Pass it as AtLeastAsFresh to observe the widget's authorization data.
+	ConsistencyToken cazi.ConsistencyToken
 }
 
 // WidgetService provides operations for managing widgets.
@@ -90,12 +96,37 @@ func (s *WidgetService) CreateWidget(ctx context.Context, req CreateWidgetReques
 		return nil, fmt.Errorf("failed to save widget: %w", err)
 	}
 
+	// Tell the authorizer about the owner, if it stores relationships
+	var writtenAt cazi.ConsistencyToken
+	if writer, ok := s.authz.(cazi.RelationshipWriter); ok {
+		writeResp, err := writer.WriteRelationships(ctx, cazi.WriteRelationshipsRequest{
+			Updates: []cazi.RelationshipUpdate{{
+				Operation: cazi.OperationTouch,
+				Relationship: cazi.Relationship{
+					Object:   cazi.ResourceReference{Type: "widget", ID: string(widget.ID())},
+					Relation: "owner",
+					Subject:  cazi.ResourceReference{Type: "user", ID: ownerID},
+				},
+			}},
+		})
+		if err != nil {
+			// Without an owner, nobody could be authorized to manage the widget, so undo the save.
+			// If that fails too, the widget is left without an owner and must be cleaned up separately.
+			if deleteErr := s.repo.Delete(ctx, widget.ID()); deleteErr != nil {
+				return nil, fmt.Errorf("failed to write widget ownership: %w (and failed to delete widget: %v)", err, deleteErr)
+			}
+			return nil, fmt.Errorf("failed to write widget ownership: %w", err)
+		}
+		writtenAt = writeResp.WrittenAt
+	}
+
 	// Return response
 	return &WidgetResponse{
-		ID:          string(widget.ID()),
-		Name:        widget.Name(),
-		Description: widget.Description(),
-		OwnerID:     widget.OwnerID(),
+		ID:               string(widget.ID()),
+		Name:             widget.Name(),
+		Description:      widget.Description(),
+		OwnerID:          widget.OwnerID(),
+		ConsistencyToken: writtenAt,
 	}, nil
 }
 
@@ -108,6 +139,7 @@ func (s *WidgetService) GetWidget(ctx context.Context, req GetWidgetRequest) (*W
 		Object: cazi.Object{
 			Assertion: cazi.ResourceReference{Type: "widget", ID: req.WidgetID},
 		},
+		AtLeastAsFresh: req.AtLeastAsFresh,
 	}
 
 	authzResp, err := s.authz.Check(ctx, authzReq)
@@ -139,9 +171,10 @@ func (s *WidgetService) GetWidget(ctx context.Context, req GetWidgetRequest) (*W
 func (s *WidgetService) ListWidgets(ctx context.Context, req ListWidgetsRequest) ([]*WidgetResponse, error) {
 	// Get authorization filter for listing widgets
 	authzResp, err := s.authz.ListObjects(ctx, cazi.ListObjectsRequest{
-		Subject:    req.Subject,
-		Verb:       "read",
-		ObjectType: "widget",
+		Subject:        req.Subject,
+		Verb:           "read",
+		ObjectType:     "widget",
+		AtLeastAsFresh: req.AtLeastAsFresh,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("authorization check failed: %w", err)
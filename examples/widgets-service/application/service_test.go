@@ -0,0 +1,102 @@
+package application_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"widgets-service/application"
+	"widgets-service/domain"
+	"widgets-service/infrastructure"
+
+	"github.com/alechenninger/cazi/implementations/memory"
+	"github.com/alechenninger/cazi/pkg/cazi"
+)
+
+var alice = cazi.Subject{Assertion: cazi.ResourceReference{Type: "user", ID: "alice"}}
+
+// newStore returns a memory store that allows alice to create widget-1.
+//
+// The memory store has no policy: a relationship only grants the verb named by its relation.
+// So alice needs a "create" relationship to create the widget, and owning it grants nothing else.
+func newStore(t *testing.T) *memory.Store {
+	t.Helper()
+	store := memory.New()
+	_, err := store.WriteRelationships(context.Background(), cazi.WriteRelationshipsRequest{
+		Updates: []cazi.RelationshipUpdate{{
+			Operation: cazi.OperationTouch,
+			Relationship: cazi.Relationship{
+				Object:   cazi.ResourceReference{Type: "widget", ID: "widget-1"},
+				Relation: "create",
+				Subject:  cazi.ResourceReference{Type: "user", ID: "alice"},
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return store
+}
+
+func TestCreateWidgetWritesOwnership(t *testing.T) {
+	store := newStore(t)
+	service := application.NewWidgetService(infrastructure.NewInMemoryWidgetRepository(), store)
+	ctx := context.Background()
+
+	widget, err := service.CreateWidget(ctx, application.CreateWidgetRequest{
+		Subject:  alice,
+		WidgetID: "widget-1",
+		Name:     "Test Widget",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(widget.ConsistencyToken) == 0 {
+		t.Fatal("expected a consistency token from the relationship write")
+	}
+
+	check := func(verb string) cazi.DecisionKind {
+		t.Helper()
+		resp, err := store.Check(ctx, cazi.CheckRequest{
+			Subject:        alice,
+			Verb:           verb,
+			Object:         cazi.Object{Assertion: cazi.ResourceReference{Type: "widget", ID: "widget-1"}},
+			AtLeastAsFresh: widget.ConsistencyToken,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return resp.Decision
+	}
+
+	if d := check("owner"); d != cazi.DecisionAllow {
+		t.Errorf("expected alice to own widget-1, got %v", d)
+	}
+	if d := check("read"); d != cazi.DecisionDeny {
+		t.Errorf("expected ownership not to grant read without a policy, got %v", d)
+	}
+}
+
+func TestCreateWidgetOwnershipWriteFails(t *testing.T) {
+	failing := cazi.Intercept(cazi.Hooks{
+		WriteRelationships: func(ctx context.Context, req cazi.WriteRelationshipsRequest, next cazi.WriteRelationshipsFunc) (cazi.WriteRelationshipsResponse, error) {
+			return cazi.WriteRelationshipsResponse{}, cazi.ErrUnavailable
+		},
+	})(newStore(t))
+	repo := infrastructure.NewInMemoryWidgetRepository()
+	service := application.NewWidgetService(repo, failing)
+	ctx := context.Background()
+
+	_, err := service.CreateWidget(ctx, application.CreateWidgetRequest{
+		Subject:  alice,
+		WidgetID: "widget-1",
+		Name:     "Test Widget",
+	})
+	if !errors.Is(err, cazi.ErrUnavailable) {
+		t.Fatalf("expected cazi.ErrUnavailable, got %v", err)
+	}
+
+	if _, err := repo.FindByID(ctx, "widget-1", cazi.Expression{}); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected the widget to be deleted, got %v", err)
+	}
+}
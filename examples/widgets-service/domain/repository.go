@@ -34,4 +34,7 @@ type WidgetRepository interface {
 	// Only widgets that satisfy the expression are returned.
 	// The repository implementation decides which expression languages it supports.
 	FindAll(ctx context.Context, authzExpression cazi.Expression) ([]*Widget, error)
+
+	// Delete removes a widget from the repository. Deleting a widget that doesn't exist is not an error.
+	Delete(ctx context.Context, id WidgetID) error
 }
@@ -0,0 +1,28 @@
+package infrastructure
+
+import (
+	"widgets-service/domain"
+
+	cazicel "github.com/alechenninger/cazi/pkg/expr/cel"
+)
+
+// widgetSchema declares what authorization expressions may reference: the widget being filtered.
+// Expressions referencing anything else fail to compile.
+var widgetSchema = cazicel.Schema{
+	Resources: []cazicel.Resource{{
+		Name:   "widget",
+		Fields: []string{"id", "name", "description", "owner_id"},
+	}},
+}
+
+// widgetVars returns the variables authorization expressions are evaluated against for a widget.
+func widgetVars(data domain.WidgetData) map[string]any {
+	return map[string]any{
+		"widget": map[string]any{
+			"id":          data.ID,
+			"name":        data.Name,
+			"description": data.Description,
+			"owner_id":    data.OwnerID,
+		},
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/alechenninger/cazi/pkg/cazi"
 	"github.com/alechenninger/cazi/pkg/claims"
+	"github.com/alechenninger/cazi/pkg/consistency"
 )
 
 // LocalAuthz is a local implementation of the CAZI interface that returns
@@ -24,20 +25,25 @@ func (a *LocalAuthz) Check(ctx context.Context, req cazi.CheckRequest) (cazi.Che
 	// Extract subject user ID
 	subjectRes, ok := req.Subject.Assertion.(cazi.ResourceReference)
 	if !ok {
-		return cazi.CheckResponse{Decision: cazi.DecisionDeny}, fmt.Errorf("subject must be a ResourceReference")
+		return cazi.CheckResponse{Decision: cazi.DecisionDeny}, fmt.Errorf("%w: subject must be a ResourceReference", cazi.ErrUnsupportedAssertion)
 	}
 	if subjectRes.Type != "user" {
-		return cazi.CheckResponse{Decision: cazi.DecisionDeny}, fmt.Errorf("subject must be of type 'user'")
+		return cazi.CheckResponse{Decision: cazi.DecisionDeny}, fmt.Errorf("%w: subject must be of type 'user'", cazi.ErrUnsupportedResourceType)
 	}
 	userID := subjectRes.ID
 
 	// Extract object widget ID
 	objectRes, ok := req.Object.Assertion.(cazi.ResourceReference)
 	if !ok {
-		return cazi.CheckResponse{Decision: cazi.DecisionDeny}, fmt.Errorf("object must be a ResourceReference")
+		return cazi.CheckResponse{Decision: cazi.DecisionDeny}, fmt.Errorf("%w: object must be a ResourceReference", cazi.ErrUnsupportedAssertion)
 	}
 	if objectRes.Type != "widget" {
-		return cazi.CheckResponse{Decision: cazi.DecisionDeny}, fmt.Errorf("object must be of type 'widget'")
+		return cazi.CheckResponse{Decision: cazi.DecisionDeny}, fmt.Errorf("%w: object must be of type 'widget'", cazi.ErrUnsupportedResourceType)
+	}
+
+	snapshot, err := snapshotFor(req.Consistency, req.AtLeastAsFresh)
+	if err != nil {
+		return cazi.CheckResponse{Decision: cazi.DecisionDeny}, err
 	}
 
 	// Hardcoded policy
@@ -48,12 +54,17 @@ func (a *LocalAuthz) Check(ctx context.Context, req cazi.CheckRequest) (cazi.Che
 		reqCtx := make(cazi.Claims)
 		claims.Sub.Set(reqCtx, userID)
 
-		return cazi.CheckResponse{
+		resp := cazi.CheckResponse{
 			Decision: cazi.DecisionAllow,
 			Context: cazi.AuthorizationContext{
 				RequesterContext: reqCtx,
 			},
-		}, nil
+			ConsistencyToken: snapshot,
+		}
+		if req.Explain {
+			resp.Explanation = explain(req.Verb, userID, objectRes, resp, "rule: anyone can create widgets")
+		}
+		return resp, nil
 
 	case "read":
 		// Users can read widgets they own - return CEL expression
@@ -63,7 +74,7 @@ func (a *LocalAuthz) Check(ctx context.Context, req cazi.CheckRequest) (cazi.Che
 		reqCtx := make(cazi.Claims)
 		claims.Sub.Set(reqCtx, userID)
 
-		return cazi.CheckResponse{
+		resp := cazi.CheckResponse{
 			Decision: cazi.DecisionConditional,
 			Condition: cazi.Expression{
 				Language:   "cel",
@@ -72,10 +83,29 @@ func (a *LocalAuthz) Check(ctx context.Context, req cazi.CheckRequest) (cazi.Che
 			Context: cazi.AuthorizationContext{
 				RequesterContext: reqCtx,
 			},
-		}, nil
+			ConsistencyToken: snapshot,
+		}
+		if req.Explain {
+			resp.Explanation = explain(req.Verb, userID, objectRes, resp,
+				"rule: users can read widgets they own (ownership is evaluated by the caller)")
+		}
+		return resp, nil
 
 	default:
-		return cazi.CheckResponse{Decision: cazi.DecisionDeny}, fmt.Errorf("unknown verb: %s", req.Verb)
+		return cazi.CheckResponse{Decision: cazi.DecisionDeny}, fmt.Errorf("%w: %s", cazi.ErrUnsupportedVerb, req.Verb)
+	}
+}
+
+// explain describes a decision made by a single policy rule.
+func explain(verb, userID string, object cazi.ResourceReference, resp cazi.CheckResponse, rule string) *cazi.Explanation {
+	return &cazi.Explanation{
+		Description: fmt.Sprintf("user:%s %s %s:%s", userID, verb, object.Type, object.ID),
+		Decision:    resp.Decision,
+		Steps: []cazi.Explanation{{
+			Description: rule,
+			Decision:    resp.Decision,
+			Condition:   resp.Condition,
+		}},
 	}
 }
 
@@ -86,16 +116,21 @@ func (a *LocalAuthz) ListObjects(ctx context.Context, req cazi.ListObjectsReques
 	// Extract subject user ID
 	subjectRes, ok := req.Subject.Assertion.(cazi.ResourceReference)
 	if !ok {
-		return cazi.ListObjectsResponse{Decision: cazi.DecisionDeny}, fmt.Errorf("subject must be a ResourceReference")
+		return cazi.ListObjectsResponse{Decision: cazi.DecisionDeny}, fmt.Errorf("%w: subject must be a ResourceReference", cazi.ErrUnsupportedAssertion)
 	}
 	if subjectRes.Type != "user" {
-		return cazi.ListObjectsResponse{Decision: cazi.DecisionDeny}, fmt.Errorf("subject must be of type 'user'")
+		return cazi.ListObjectsResponse{Decision: cazi.DecisionDeny}, fmt.Errorf("%w: subject must be of type 'user'", cazi.ErrUnsupportedResourceType)
 	}
 	userID := subjectRes.ID
 
 	// Check authorization policy for listing this object type
 	if req.ObjectType != "widget" {
-		return cazi.ListObjectsResponse{Decision: cazi.DecisionDeny}, fmt.Errorf("unsupported object type: %s", req.ObjectType)
+		return cazi.ListObjectsResponse{Decision: cazi.DecisionDeny}, fmt.Errorf("%w: %s", cazi.ErrUnsupportedResourceType, req.ObjectType)
+	}
+
+	snapshot, err := snapshotFor(req.Consistency, req.AtLeastAsFresh)
+	if err != nil {
+		return cazi.ListObjectsResponse{Decision: cazi.DecisionDeny}, err
 	}
 
 	// Hardcoded policy: users can list widgets they own
@@ -113,5 +148,56 @@ func (a *LocalAuthz) ListObjects(ctx context.Context, req cazi.ListObjectsReques
 		Context: cazi.AuthorizationContext{
 			RequesterContext: reqCtx,
 		},
+		ConsistencyToken: snapshot,
 	}, nil
 }
+
+// policyToken identifies the only state of the policy.
+var policyToken = consistency.Encode(consistency.Token{Backend: "widgets-service:local", Revision: 1})
+
+// snapshotFor returns the consistency token to report for a request's consistency requirement.
+// The policy never changes, so every mode is satisfied and every snapshot gives the same answer:
+// a request for a particular snapshot is answered at that snapshot,
+// and other requests are answered at the policy's only state, [policyToken].
+func snapshotFor(c cazi.Consistency, atLeastAsFresh cazi.ConsistencyToken) (cazi.ConsistencyToken, error) {
+	c, err := c.Resolve(atLeastAsFresh)
+	if err != nil {
+		return nil, err
+	}
+	if len(c.Token) == 0 {
+		return policyToken, nil
+	}
+	return c.Token, nil
+}
+
+// ListSubjects implements cazi.SubjectLister.
+// Only a widget's owner can read it, so the subjects are returned as a conditional expression
+// relating the user to the widget's owner. The caller evaluates it against the widget's data.
+func (a *LocalAuthz) ListSubjects(ctx context.Context, req cazi.ListSubjectsRequest) (cazi.ListSubjectsResponse, error) {
+	// Extract object widget ID
+	objectRes, ok := req.Object.Assertion.(cazi.ResourceReference)
+	if !ok {
+		return cazi.ListSubjectsResponse{Decision: cazi.DecisionDeny}, fmt.Errorf("%w: object must be a ResourceReference", cazi.ErrUnsupportedAssertion)
+	}
+	if objectRes.Type != "widget" {
+		return cazi.ListSubjectsResponse{Decision: cazi.DecisionDeny}, fmt.Errorf("%w: object must be of type 'widget'", cazi.ErrUnsupportedResourceType)
+	}
+	if req.SubjectType != "user" {
+		return cazi.ListSubjectsResponse{Decision: cazi.DecisionDeny}, fmt.Errorf("%w: %s", cazi.ErrUnsupportedResourceType, req.SubjectType)
+	}
+
+	// Hardcoded policy: only the owner can read a widget
+	switch req.Verb {
+	case "read":
+		return cazi.ListSubjectsResponse{
+			Decision: cazi.DecisionConditional,
+			Condition: cazi.Expression{
+				Language:   "cel",
+				Expression: "user.id == widget.owner_id",
+			},
+		}, nil
+
+	default:
+		return cazi.ListSubjectsResponse{Decision: cazi.DecisionDeny}, fmt.Errorf("%w: %s", cazi.ErrUnsupportedVerb, req.Verb)
+	}
+}
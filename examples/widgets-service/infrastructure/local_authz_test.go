@@ -0,0 +1,161 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"github.com/alechenninger/cazi/pkg/claims"
+	"github.com/alechenninger/cazi/pkg/consistency"
+	"github.com/alechenninger/cazi/pkg/principal"
+)
+
+func TestLocalAuthzListSubjects(t *testing.T) {
+	authz := NewLocalAuthz()
+	ctx := context.Background()
+
+	t.Run("Readers are the owner", func(t *testing.T) {
+		resp, err := cazi.ListSubjects(ctx, authz, cazi.ListSubjectsRequest{
+			Object:      cazi.Object{Assertion: cazi.ResourceReference{Type: "widget", ID: "widget-1"}},
+			Verb:        "read",
+			SubjectType: "user",
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if resp.Decision != cazi.DecisionConditional {
+			t.Fatalf("expected conditional decision, got %v", resp.Decision)
+		}
+		if resp.Condition.Expression != "user.id == widget.owner_id" {
+			t.Errorf("unexpected condition: %s", resp.Condition.Expression)
+		}
+	})
+
+	t.Run("Unknown verb", func(t *testing.T) {
+		resp, err := authz.ListSubjects(ctx, cazi.ListSubjectsRequest{
+			Object:      cazi.Object{Assertion: cazi.ResourceReference{Type: "widget", ID: "widget-1"}},
+			Verb:        "delete",
+			SubjectType: "user",
+		})
+		if !errors.Is(err, cazi.ErrUnsupportedVerb) {
+			t.Fatalf("expected cazi.ErrUnsupportedVerb, got %v", err)
+		}
+		if resp.Decision != cazi.DecisionDeny {
+			t.Errorf("expected deny decision, got %v", resp.Decision)
+		}
+	})
+
+	t.Run("Unsupported subject type", func(t *testing.T) {
+		_, err := authz.ListSubjects(ctx, cazi.ListSubjectsRequest{
+			Object:      cazi.Object{Assertion: cazi.ResourceReference{Type: "widget", ID: "widget-1"}},
+			Verb:        "read",
+			SubjectType: "group",
+		})
+		if !errors.Is(err, cazi.ErrUnsupportedResourceType) {
+			t.Fatalf("expected cazi.ErrUnsupportedResourceType, got %v", err)
+		}
+	})
+}
+
+func TestLocalAuthzConsistency(t *testing.T) {
+	authz := NewLocalAuthz()
+	ctx := context.Background()
+	token := cazi.ConsistencyToken("rev-1")
+
+	check := func(c cazi.Consistency) (cazi.CheckResponse, error) {
+		return authz.Check(ctx, cazi.CheckRequest{
+			Subject:     cazi.Subject{Assertion: cazi.ResourceReference{Type: "user", ID: "alice"}},
+			Verb:        "read",
+			Object:      cazi.Object{Assertion: cazi.ResourceReference{Type: "widget", ID: "widget-1"}},
+			Consistency: c,
+		})
+	}
+
+	t.Run("Requested snapshot is reported", func(t *testing.T) {
+		for _, c := range []cazi.Consistency{cazi.AtExactSnapshot(token), cazi.AtLeastAsFresh(token)} {
+			resp, err := check(c)
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", c.Mode, err)
+			}
+			if string(resp.ConsistencyToken) != string(token) {
+				t.Errorf("%s: expected token %q, got %q", c.Mode, token, resp.ConsistencyToken)
+			}
+		}
+	})
+
+	t.Run("Other modes report the policy snapshot", func(t *testing.T) {
+		var tokens []cazi.ConsistencyToken
+		for _, c := range []cazi.Consistency{cazi.MinimizeLatency(), cazi.FullyConsistent()} {
+			resp, err := check(c)
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", c.Mode, err)
+			}
+			if _, err := consistency.Decode(resp.ConsistencyToken); err != nil {
+				t.Fatalf("%s: expected a consistency token, got %q: %v", c.Mode, resp.ConsistencyToken, err)
+			}
+			tokens = append(tokens, resp.ConsistencyToken)
+		}
+		if string(tokens[0]) != string(tokens[1]) {
+			t.Errorf("expected the same snapshot, got %q and %q", tokens[0], tokens[1])
+		}
+	})
+
+	t.Run("Missing token", func(t *testing.T) {
+		resp, err := check(cazi.Consistency{Mode: cazi.ConsistencyAtExactSnapshot})
+		if !errors.Is(err, cazi.ErrInvalidArgument) {
+			t.Fatalf("expected cazi.ErrInvalidArgument, got %v", err)
+		}
+		if resp.Decision != cazi.DecisionDeny {
+			t.Errorf("expected deny decision, got %v", resp.Decision)
+		}
+	})
+}
+
+func TestLocalAuthzExplain(t *testing.T) {
+	authz := NewLocalAuthz()
+	ctx := context.Background()
+
+	resp, err := authz.Check(ctx, cazi.CheckRequest{
+		Subject: cazi.Subject{Assertion: cazi.ResourceReference{Type: "user", ID: "alice"}},
+		Verb:    "read",
+		Object:  cazi.Object{Assertion: cazi.ResourceReference{Type: "widget", ID: "widget-1"}},
+		Explain: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Explanation == nil {
+		t.Fatal("expected explanation")
+	}
+
+	want := `[conditional] user:alice read widget:widget-1
+└── [conditional] rule: users can read widgets they own (ownership is evaluated by the caller) (cel: widget.owner_id == 'alice')
+`
+	if got := resp.Explanation.String(); got != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestLocalAuthzPrincipals(t *testing.T) {
+	authz := principal.Middleware(principal.Chain(
+		principal.References("user"),
+		principal.FromClaims(principal.Mapping{Type: "user"}),
+	))(NewLocalAuthz())
+
+	resp, err := authz.Check(context.Background(), cazi.CheckRequest{
+		Subject: cazi.Subject{Assertion: cazi.Claims{"sub": "alice", "email": "alice@example.com"}},
+		Verb:    "create",
+		Object:  cazi.Object{Assertion: cazi.ResourceReference{Type: "widget", ID: "widget-1"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Decision != cazi.DecisionAllow {
+		t.Errorf("expected allow decision, got %v", resp.Decision)
+	}
+	if sub, _ := claims.Sub.Get(resp.Context.RequesterContext); sub != "alice" {
+		t.Errorf("expected sub 'alice', got '%s'", sub)
+	}
+}
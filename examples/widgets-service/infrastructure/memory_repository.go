@@ -6,8 +6,8 @@ import (
 	"sync"
 
 	"github.com/alechenninger/cazi/pkg/cazi"
-	"github.com/google/cel-go/cel"
-	"github.com/google/cel-go/common/types"
+	"github.com/alechenninger/cazi/pkg/expr"
+	cazicel "github.com/alechenninger/cazi/pkg/expr/cel"
 
 	"widgets-service/domain"
 )
@@ -16,23 +16,22 @@ import (
 type InMemoryWidgetRepository struct {
 	mu      sync.RWMutex
 	widgets map[domain.WidgetID]domain.WidgetData
-	celEnv  *cel.Env // CEL environment for evaluating expressions
+	// languages are the expression languages the repository can evaluate
+	languages expr.Registry
 }
 
 // NewInMemoryWidgetRepository creates a new in-memory repository.
 func NewInMemoryWidgetRepository() *InMemoryWidgetRepository {
-	// Create CEL environment with widget object type
-	env, err := cel.NewEnv(
-		cel.Variable("widget", cel.MapType(cel.StringType, cel.DynType)),
-	)
+	celLanguage, err := cazicel.New(cazicel.Options{Schema: widgetSchema})
 	if err != nil {
-		panic(fmt.Sprintf("failed to create CEL environment: %v", err))
+		panic(fmt.Sprintf("failed to create CEL language: %v", err))
 	}
 
-	return &InMemoryWidgetRepository{
+	r := &InMemoryWidgetRepository{
 		widgets: make(map[domain.WidgetID]domain.WidgetData),
-		celEnv:  env,
 	}
+	r.languages.Register(cazicel.Name, celLanguage)
+	return r
 }
 
 // Save stores a widget in memory.
@@ -45,6 +44,15 @@ func (r *InMemoryWidgetRepository) Save(ctx context.Context, widget *domain.Widg
 	return nil
 }
 
+// Delete removes a widget from memory.
+func (r *InMemoryWidgetRepository) Delete(ctx context.Context, id domain.WidgetID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.widgets, id)
+	return nil
+}
+
 // FindByID retrieves a widget by ID from memory, optionally applying an authorization expression.
 // The expression is evaluated as a filter - if it doesn't match, returns ErrNotFound (security feature).
 func (r *InMemoryWidgetRepository) FindByID(ctx context.Context, id domain.WidgetID, authzExpression cazi.Expression) (*domain.Widget, error) {
@@ -58,7 +66,7 @@ func (r *InMemoryWidgetRepository) FindByID(ctx context.Context, id domain.Widge
 
 	// Apply authorization expression as part of the query filter
 	if authzExpression.Language != "" {
-		matches, err := r.evaluateExpression(data, authzExpression)
+		matches, err := r.evaluateExpression(ctx, data, authzExpression)
 		if err != nil {
 			return nil, fmt.Errorf("failed to evaluate authorization expression: %w", err)
 		}
@@ -83,7 +91,7 @@ func (r *InMemoryWidgetRepository) FindAll(ctx context.Context, authzExpression
 	for _, data := range r.widgets {
 		// Apply authorization expression as part of the query filter
 		if authzExpression.Language != "" {
-			matches, err := r.evaluateExpression(data, authzExpression)
+			matches, err := r.evaluateExpression(ctx, data, authzExpression)
 			if err != nil {
 				return nil, fmt.Errorf("failed to evaluate authorization expression: %w", err)
 			}
@@ -101,51 +109,11 @@ func (r *InMemoryWidgetRepository) FindAll(ctx context.Context, authzExpression
 
 // evaluateExpression evaluates an authorization expression against widget data.
 // The repository decides which expression languages it supports.
-func (r *InMemoryWidgetRepository) evaluateExpression(data domain.WidgetData, expr cazi.Expression) (bool, error) {
-	switch expr.Language {
-	case "cel":
-		return r.evaluateCEL(data, expr.Expression)
-	default:
-		return false, fmt.Errorf("unsupported expression language: %s (repository only supports: cel)", expr.Language)
-	}
-}
-
-// evaluateCEL evaluates a CEL expression against widget data.
 // In a real database, this would be translated to a WHERE clause.
-func (r *InMemoryWidgetRepository) evaluateCEL(data domain.WidgetData, expression string) (bool, error) {
-	// Compile the CEL expression
-	ast, issues := r.celEnv.Compile(expression)
-	if issues != nil && issues.Err() != nil {
-		return false, fmt.Errorf("CEL compilation error: %w", issues.Err())
-	}
-
-	// Create a program from the AST
-	prg, err := r.celEnv.Program(ast)
+func (r *InMemoryWidgetRepository) evaluateExpression(ctx context.Context, data domain.WidgetData, e cazi.Expression) (bool, error) {
+	program, err := r.languages.Compile(e)
 	if err != nil {
-		return false, fmt.Errorf("CEL program creation error: %w", err)
-	}
-
-	// Prepare input data for CEL evaluation with widget object
-	input := map[string]any{
-		"widget": map[string]any{
-			"owner_id":    data.OwnerID,
-			"id":          data.ID,
-			"name":        data.Name,
-			"description": data.Description,
-		},
+		return false, err
 	}
-
-	// Evaluate the expression
-	result, _, err := prg.Eval(input)
-	if err != nil {
-		return false, fmt.Errorf("CEL evaluation error: %w", err)
-	}
-
-	// Convert result to boolean
-	boolResult, ok := result.(types.Bool)
-	if !ok {
-		return false, fmt.Errorf("CEL expression must return a boolean, got: %v", result.Type())
-	}
-
-	return bool(boolResult), nil
+	return expr.Match(ctx, program, widgetVars(data))
 }
@@ -0,0 +1,48 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"widgets-service/domain"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"github.com/alechenninger/cazi/pkg/expr"
+)
+
+func TestInMemoryRepository_FindAll_WithAuthzExpression(t *testing.T) {
+	repo := NewInMemoryWidgetRepository()
+	ctx := context.Background()
+
+	for _, w := range []*domain.Widget{
+		domain.NewWidget("widget-1", "Alice's Widget", "", "user-alice"),
+		domain.NewWidget("widget-2", "Bob's Widget", "", "user-bob"),
+	} {
+		if err := repo.Save(ctx, w); err != nil {
+			t.Fatalf("failed to save widget: %v", err)
+		}
+	}
+
+	widgets, err := repo.FindAll(ctx, cazi.Expression{Language: "cel", Expression: "widget.owner_id == 'user-alice'"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(widgets) != 1 || widgets[0].ID() != "widget-1" {
+		t.Errorf("expected only widget-1, got %v", widgets)
+	}
+
+	t.Run("Unknown variable", func(t *testing.T) {
+		_, err := repo.FindByID(ctx, "widget-1", cazi.Expression{Language: "cel", Expression: "widget.colour == 'red'"})
+		if !errors.Is(err, expr.ErrUnknownVariable) {
+			t.Errorf("expected expr.ErrUnknownVariable, got %v", err)
+		}
+	})
+
+	t.Run("Unsupported language", func(t *testing.T) {
+		_, err := repo.FindByID(ctx, "widget-1", cazi.Expression{Language: "rego", Expression: "input.widget.owner_id == \"user-alice\""})
+		if !errors.Is(err, cazi.ErrUnsupportedLanguage) {
+			t.Errorf("expected cazi.ErrUnsupportedLanguage, got %v", err)
+		}
+	})
+}
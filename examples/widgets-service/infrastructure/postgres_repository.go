@@ -10,6 +10,7 @@ import (
 	"widgets-service/domain"
 
 	"github.com/alechenninger/cazi/pkg/cazi"
+	cazicel "github.com/alechenninger/cazi/pkg/expr/cel"
 	"github.com/google/cel-go/cel"
 	cel2sql "github.com/spandigital/cel2sql/v3"
 	"github.com/spandigital/cel2sql/v3/pg"
@@ -21,7 +22,7 @@ var schemaSQL string
 // PostgresWidgetRepository implements the WidgetRepository interface using PostgreSQL.
 type PostgresWidgetRepository struct {
 	db           *sql.DB
-	celEnv       *cel.Env
+	celLanguage  *cazicel.Language
 	typeProvider pg.TypeProvider
 }
 
@@ -47,18 +48,18 @@ func NewPostgresWidgetRepository(db *sql.DB) (*PostgresWidgetRepository, error)
 	}
 	typeProvider := pg.NewTypeProvider(schemas)
 
-	// Create CEL environment with the widget type
-	celEnv, err := cel.NewEnv(
-		cel.CustomTypeProvider(typeProvider),
-		cel.Variable("widget", cel.MapType(cel.StringType, cel.DynType)),
-	)
+	// Create the CEL language with the widget type
+	celLanguage, err := cazicel.New(cazicel.Options{
+		Schema:     widgetSchema,
+		EnvOptions: []cel.EnvOption{cel.CustomTypeProvider(typeProvider)},
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create CEL environment: %w", err)
+		return nil, fmt.Errorf("failed to create CEL language: %w", err)
 	}
 
 	return &PostgresWidgetRepository{
 		db:           db,
-		celEnv:       celEnv,
+		celLanguage:  celLanguage,
 		typeProvider: typeProvider,
 	}, nil
 }
@@ -85,6 +86,14 @@ func (r *PostgresWidgetRepository) Save(ctx context.Context, widget *domain.Widg
 	return nil
 }
 
+// Delete removes a widget from the database.
+func (r *PostgresWidgetRepository) Delete(ctx context.Context, id domain.WidgetID) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM widgets WHERE id = $1", string(id)); err != nil {
+		return fmt.Errorf("failed to delete widget: %w", err)
+	}
+	return nil
+}
+
 // FindByID retrieves a widget by ID, applying an optional authorization expression.
 // If an expression is provided (Language != ""), it's evaluated as part of the query filter.
 // Only returns the widget if it both exists and satisfies the authorization expression.
@@ -168,18 +177,18 @@ func (r *PostgresWidgetRepository) FindAll(ctx context.Context, authzExpression
 // The paramOffset is the number of existing parameters in the query, used to renumber placeholders.
 // Returns the SQL clause and any parameters for parameterized queries.
 func (r *PostgresWidgetRepository) expressionToSQL(expr cazi.Expression, paramOffset int) (string, []interface{}, error) {
-	if expr.Language != "cel" {
-		return "", nil, fmt.Errorf("unsupported expression language: %s (only 'cel' is supported)", expr.Language)
+	if expr.Language != cazicel.Name {
+		return "", nil, fmt.Errorf("%w: %s (only 'cel' is supported)", cazi.ErrUnsupportedLanguage, expr.Language)
 	}
 
-	// Compile the CEL expression
-	ast, issues := r.celEnv.Compile(expr.Expression)
-	if issues != nil && issues.Err() != nil {
-		return "", nil, fmt.Errorf("failed to compile CEL expression: %w", issues.Err())
+	// Compile the CEL expression, or reuse the program compiled from the same text
+	program, err := r.celLanguage.Program(expr.Expression)
+	if err != nil {
+		return "", nil, err
 	}
 
 	// Convert CEL AST to SQL using cel2sql
-	result, err := cel2sql.ConvertParameterized(ast)
+	result, err := cel2sql.ConvertParameterized(program.AST())
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to convert CEL to SQL: %w", err)
 	}
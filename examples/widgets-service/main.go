@@ -4,16 +4,40 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 
 	"widgets-service/application"
 	"widgets-service/infrastructure"
 	"widgets-service/presentation"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"github.com/alechenninger/cazi/pkg/cazigrpc"
+	"github.com/alechenninger/cazi/pkg/principal"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
 func main() {
 	// Infrastructure layer
 	repo := infrastructure.NewInMemoryWidgetRepository()
-	authz := infrastructure.NewLocalAuthz()
+	var authz cazi.Interface = infrastructure.NewLocalAuthz()
+
+	// Use a remote CAZI server instead, if configured
+	if addr := os.Getenv("CAZI_GRPC_ADDR"); addr != "" {
+		conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			log.Fatalf("Failed to connect to CAZI server: %v", err)
+		}
+		defer conn.Close()
+		authz = cazigrpc.NewClient(conn)
+		log.Printf("Using remote CAZI server at %s", addr)
+	}
+
+	// Accept claims about users as well as user references
+	authz = principal.Middleware(principal.Chain(
+		principal.References("user"),
+		principal.FromClaims(principal.Mapping{Type: "user"}),
+	))(authz)
 
 	// Application layer
 	widgetService := application.NewWidgetService(repo, authz)
@@ -0,0 +1,1547 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: cazi/v1/cazi.proto
+
+package caziv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// RelationshipOperation is the kind of change made by a RelationshipUpdate.
+type RelationshipOperation int32
+
+const (
+	RelationshipOperation_RELATIONSHIP_OPERATION_UNSPECIFIED RelationshipOperation = 0
+	RelationshipOperation_RELATIONSHIP_OPERATION_TOUCH       RelationshipOperation = 1 // the relationship exists
+	RelationshipOperation_RELATIONSHIP_OPERATION_CREATE      RelationshipOperation = 2 // the relationship was created, and did not exist before
+	RelationshipOperation_RELATIONSHIP_OPERATION_DELETE      RelationshipOperation = 3 // the relationship does not exist
+)
+
+// Enum value maps for RelationshipOperation.
+var (
+	RelationshipOperation_name = map[int32]string{
+		0: "RELATIONSHIP_OPERATION_UNSPECIFIED",
+		1: "RELATIONSHIP_OPERATION_TOUCH",
+		2: "RELATIONSHIP_OPERATION_CREATE",
+		3: "RELATIONSHIP_OPERATION_DELETE",
+	}
+	RelationshipOperation_value = map[string]int32{
+		"RELATIONSHIP_OPERATION_UNSPECIFIED": 0,
+		"RELATIONSHIP_OPERATION_TOUCH":       1,
+		"RELATIONSHIP_OPERATION_CREATE":      2,
+		"RELATIONSHIP_OPERATION_DELETE":      3,
+	}
+)
+
+func (x RelationshipOperation) Enum() *RelationshipOperation {
+	p := new(RelationshipOperation)
+	*p = x
+	return p
+}
+
+func (x RelationshipOperation) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RelationshipOperation) Descriptor() protoreflect.EnumDescriptor {
+	return file_cazi_v1_cazi_proto_enumTypes[0].Descriptor()
+}
+
+func (RelationshipOperation) Type() protoreflect.EnumType {
+	return &file_cazi_v1_cazi_proto_enumTypes[0]
+}
+
+func (x RelationshipOperation) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RelationshipOperation.Descriptor instead.
+func (RelationshipOperation) EnumDescriptor() ([]byte, []int) {
+	return file_cazi_v1_cazi_proto_rawDescGZIP(), []int{0}
+}
+
+// DecisionKind is the tri-state outcome for Check.
+// Numeric values match the Go cazi.DecisionKind constants.
+type DecisionKind int32
+
+const (
+	DecisionKind_DECISION_KIND_UNSPECIFIED DecisionKind = 0
+	DecisionKind_DECISION_KIND_ALLOW       DecisionKind = 1
+	DecisionKind_DECISION_KIND_DENY        DecisionKind = 2
+	DecisionKind_DECISION_KIND_CONDITIONAL DecisionKind = 3
+)
+
+// Enum value maps for DecisionKind.
+var (
+	DecisionKind_name = map[int32]string{
+		0: "DECISION_KIND_UNSPECIFIED",
+		1: "DECISION_KIND_ALLOW",
+		2: "DECISION_KIND_DENY",
+		3: "DECISION_KIND_CONDITIONAL",
+	}
+	DecisionKind_value = map[string]int32{
+		"DECISION_KIND_UNSPECIFIED": 0,
+		"DECISION_KIND_ALLOW":       1,
+		"DECISION_KIND_DENY":        2,
+		"DECISION_KIND_CONDITIONAL": 3,
+	}
+)
+
+func (x DecisionKind) Enum() *DecisionKind {
+	p := new(DecisionKind)
+	*p = x
+	return p
+}
+
+func (x DecisionKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DecisionKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_cazi_v1_cazi_proto_enumTypes[1].Descriptor()
+}
+
+func (DecisionKind) Type() protoreflect.EnumType {
+	return &file_cazi_v1_cazi_proto_enumTypes[1]
+}
+
+func (x DecisionKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DecisionKind.Descriptor instead.
+func (DecisionKind) EnumDescriptor() ([]byte, []int) {
+	return file_cazi_v1_cazi_proto_rawDescGZIP(), []int{1}
+}
+
+// ConsistencyMode selects the snapshot of authorization data a request is evaluated at.
+// Numeric values match the Go cazi.ConsistencyMode constants.
+type ConsistencyMode int32
+
+const (
+	ConsistencyMode_CONSISTENCY_MODE_UNSPECIFIED       ConsistencyMode = 0 // at least as fresh as at_least_as_fresh if set, otherwise minimize latency
+	ConsistencyMode_CONSISTENCY_MODE_MINIMIZE_LATENCY  ConsistencyMode = 1 // any snapshot, possibly stale
+	ConsistencyMode_CONSISTENCY_MODE_AT_LEAST_AS_FRESH ConsistencyMode = 2 // a snapshot at least as fresh as the token
+	ConsistencyMode_CONSISTENCY_MODE_AT_EXACT_SNAPSHOT ConsistencyMode = 3 // exactly the snapshot identified by the token
+	ConsistencyMode_CONSISTENCY_MODE_FULLY_CONSISTENT  ConsistencyMode = 4 // the most recent snapshot
+)
+
+// Enum value maps for ConsistencyMode.
+var (
+	ConsistencyMode_name = map[int32]string{
+		0: "CONSISTENCY_MODE_UNSPECIFIED",
+		1: "CONSISTENCY_MODE_MINIMIZE_LATENCY",
+		2: "CONSISTENCY_MODE_AT_LEAST_AS_FRESH",
+		3: "CONSISTENCY_MODE_AT_EXACT_SNAPSHOT",
+		4: "CONSISTENCY_MODE_FULLY_CONSISTENT",
+	}
+	ConsistencyMode_value = map[string]int32{
+		"CONSISTENCY_MODE_UNSPECIFIED":       0,
+		"CONSISTENCY_MODE_MINIMIZE_LATENCY":  1,
+		"CONSISTENCY_MODE_AT_LEAST_AS_FRESH": 2,
+		"CONSISTENCY_MODE_AT_EXACT_SNAPSHOT": 3,
+		"CONSISTENCY_MODE_FULLY_CONSISTENT":  4,
+	}
+)
+
+func (x ConsistencyMode) Enum() *ConsistencyMode {
+	p := new(ConsistencyMode)
+	*p = x
+	return p
+}
+
+func (x ConsistencyMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ConsistencyMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_cazi_v1_cazi_proto_enumTypes[2].Descriptor()
+}
+
+func (ConsistencyMode) Type() protoreflect.EnumType {
+	return &file_cazi_v1_cazi_proto_enumTypes[2]
+}
+
+func (x ConsistencyMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ConsistencyMode.Descriptor instead.
+func (ConsistencyMode) EnumDescriptor() ([]byte, []int) {
+	return file_cazi_v1_cazi_proto_rawDescGZIP(), []int{2}
+}
+
+// CheckRequest captures the inputs to an authorization check.
+type CheckRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Subject        *Subject               `protobuf:"bytes,1,opt,name=subject,proto3" json:"subject,omitempty"`                                         // subject assertion with optional relation
+	Verb           string                 `protobuf:"bytes,2,opt,name=verb,proto3" json:"verb,omitempty"`                                               // verb/relation
+	Object         *Object                `protobuf:"bytes,3,opt,name=object,proto3" json:"object,omitempty"`                                           // object assertion
+	AtLeastAsFresh *ConsistencyToken      `protobuf:"bytes,4,opt,name=at_least_as_fresh,json=atLeastAsFresh,proto3" json:"at_least_as_fresh,omitempty"` // optional opaque token for causal consistency
+	Consistency    *Consistency           `protobuf:"bytes,5,opt,name=consistency,proto3" json:"consistency,omitempty"`                                 // optional; takes precedence over at_least_as_fresh if its mode is set
+	Explain        bool                   `protobuf:"varint,6,opt,name=explain,proto3" json:"explain,omitempty"`                                        // optional; asks for an explanation of the decision
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CheckRequest) Reset() {
+	*x = CheckRequest{}
+	mi := &file_cazi_v1_cazi_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckRequest) ProtoMessage() {}
+
+func (x *CheckRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cazi_v1_cazi_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckRequest.ProtoReflect.Descriptor instead.
+func (*CheckRequest) Descriptor() ([]byte, []int) {
+	return file_cazi_v1_cazi_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CheckRequest) GetSubject() *Subject {
+	if x != nil {
+		return x.Subject
+	}
+	return nil
+}
+
+func (x *CheckRequest) GetVerb() string {
+	if x != nil {
+		return x.Verb
+	}
+	return ""
+}
+
+func (x *CheckRequest) GetObject() *Object {
+	if x != nil {
+		return x.Object
+	}
+	return nil
+}
+
+func (x *CheckRequest) GetAtLeastAsFresh() *ConsistencyToken {
+	if x != nil {
+		return x.AtLeastAsFresh
+	}
+	return nil
+}
+
+func (x *CheckRequest) GetConsistency() *Consistency {
+	if x != nil {
+		return x.Consistency
+	}
+	return nil
+}
+
+func (x *CheckRequest) GetExplain() bool {
+	if x != nil {
+		return x.Explain
+	}
+	return false
+}
+
+// CheckResponse is the outcome of a Check invocation.
+type CheckResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Decision         DecisionKind           `protobuf:"varint,1,opt,name=decision,proto3,enum=cazi.v1.DecisionKind" json:"decision,omitempty"`              // allow/deny/conditional
+	Condition        *Expression            `protobuf:"bytes,2,opt,name=condition,proto3" json:"condition,omitempty"`                                       // present when DECISION_KIND_CONDITIONAL
+	Context          *AuthorizationContext  `protobuf:"bytes,3,opt,name=context,proto3" json:"context,omitempty"`                                           // additional context about the authorization decision
+	ConsistencyToken *ConsistencyToken      `protobuf:"bytes,4,opt,name=consistency_token,json=consistencyToken,proto3" json:"consistency_token,omitempty"` // freshness of this authorization decision
+	Explanation      *Explanation           `protobuf:"bytes,5,opt,name=explanation,proto3" json:"explanation,omitempty"`                                   // how the decision was reached, if requested and supported
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *CheckResponse) Reset() {
+	*x = CheckResponse{}
+	mi := &file_cazi_v1_cazi_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckResponse) ProtoMessage() {}
+
+func (x *CheckResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_cazi_v1_cazi_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckResponse.ProtoReflect.Descriptor instead.
+func (*CheckResponse) Descriptor() ([]byte, []int) {
+	return file_cazi_v1_cazi_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CheckResponse) GetDecision() DecisionKind {
+	if x != nil {
+		return x.Decision
+	}
+	return DecisionKind_DECISION_KIND_UNSPECIFIED
+}
+
+func (x *CheckResponse) GetCondition() *Expression {
+	if x != nil {
+		return x.Condition
+	}
+	return nil
+}
+
+func (x *CheckResponse) GetContext() *AuthorizationContext {
+	if x != nil {
+		return x.Context
+	}
+	return nil
+}
+
+func (x *CheckResponse) GetConsistencyToken() *ConsistencyToken {
+	if x != nil {
+		return x.ConsistencyToken
+	}
+	return nil
+}
+
+func (x *CheckResponse) GetExplanation() *Explanation {
+	if x != nil {
+		return x.Explanation
+	}
+	return nil
+}
+
+// Explanation is a structured trace of how an implementation reached a decision.
+// The root step describes the check itself; each step's steps are the evaluations it depended on.
+type Explanation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Description   string                 `protobuf:"bytes,1,opt,name=description,proto3" json:"description,omitempty"`                      // what was evaluated
+	Decision      DecisionKind           `protobuf:"varint,2,opt,name=decision,proto3,enum=cazi.v1.DecisionKind" json:"decision,omitempty"` // allow if it matched, deny if not, conditional if it produced a condition
+	Relationship  *Relationship          `protobuf:"bytes,3,opt,name=relationship,proto3" json:"relationship,omitempty"`                    // the relationship looked up, if any
+	Condition     *Expression            `protobuf:"bytes,4,opt,name=condition,proto3" json:"condition,omitempty"`                          // the condition produced, if any
+	Steps         []*Explanation         `protobuf:"bytes,5,rep,name=steps,proto3" json:"steps,omitempty"`                                  // nested evaluations
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Explanation) Reset() {
+	*x = Explanation{}
+	mi := &file_cazi_v1_cazi_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Explanation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Explanation) ProtoMessage() {}
+
+func (x *Explanation) ProtoReflect() protoreflect.Message {
+	mi := &file_cazi_v1_cazi_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Explanation.ProtoReflect.Descriptor instead.
+func (*Explanation) Descriptor() ([]byte, []int) {
+	return file_cazi_v1_cazi_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Explanation) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Explanation) GetDecision() DecisionKind {
+	if x != nil {
+		return x.Decision
+	}
+	return DecisionKind_DECISION_KIND_UNSPECIFIED
+}
+
+func (x *Explanation) GetRelationship() *Relationship {
+	if x != nil {
+		return x.Relationship
+	}
+	return nil
+}
+
+func (x *Explanation) GetCondition() *Expression {
+	if x != nil {
+		return x.Condition
+	}
+	return nil
+}
+
+func (x *Explanation) GetSteps() []*Explanation {
+	if x != nil {
+		return x.Steps
+	}
+	return nil
+}
+
+// ListObjectsRequest captures the inputs to an object listing.
+type ListObjectsRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Subject        *Subject               `protobuf:"bytes,1,opt,name=subject,proto3" json:"subject,omitempty"`                                         // subject assertion with optional relation
+	Verb           string                 `protobuf:"bytes,2,opt,name=verb,proto3" json:"verb,omitempty"`                                               // verb/relation
+	ObjectType     string                 `protobuf:"bytes,3,opt,name=object_type,json=objectType,proto3" json:"object_type,omitempty"`                 // type of objects to list
+	Filter         *Expression            `protobuf:"bytes,4,opt,name=filter,proto3" json:"filter,omitempty"`                                           // optional filter expression
+	AtLeastAsFresh *ConsistencyToken      `protobuf:"bytes,5,opt,name=at_least_as_fresh,json=atLeastAsFresh,proto3" json:"at_least_as_fresh,omitempty"` // optional opaque token for causal consistency
+	Consistency    *Consistency           `protobuf:"bytes,6,opt,name=consistency,proto3" json:"consistency,omitempty"`                                 // optional; takes precedence over at_least_as_fresh if its mode is set
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ListObjectsRequest) Reset() {
+	*x = ListObjectsRequest{}
+	mi := &file_cazi_v1_cazi_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListObjectsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListObjectsRequest) ProtoMessage() {}
+
+func (x *ListObjectsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cazi_v1_cazi_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListObjectsRequest.ProtoReflect.Descriptor instead.
+func (*ListObjectsRequest) Descriptor() ([]byte, []int) {
+	return file_cazi_v1_cazi_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListObjectsRequest) GetSubject() *Subject {
+	if x != nil {
+		return x.Subject
+	}
+	return nil
+}
+
+func (x *ListObjectsRequest) GetVerb() string {
+	if x != nil {
+		return x.Verb
+	}
+	return ""
+}
+
+func (x *ListObjectsRequest) GetObjectType() string {
+	if x != nil {
+		return x.ObjectType
+	}
+	return ""
+}
+
+func (x *ListObjectsRequest) GetFilter() *Expression {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *ListObjectsRequest) GetAtLeastAsFresh() *ConsistencyToken {
+	if x != nil {
+		return x.AtLeastAsFresh
+	}
+	return nil
+}
+
+func (x *ListObjectsRequest) GetConsistency() *Consistency {
+	if x != nil {
+		return x.Consistency
+	}
+	return nil
+}
+
+// ListObjectsResponse captures the outputs of an object listing.
+// Rather than returning a list of IDs, it returns a filter expression
+// that the caller can apply to their query.
+type ListObjectsResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Decision         DecisionKind           `protobuf:"varint,1,opt,name=decision,proto3,enum=cazi.v1.DecisionKind" json:"decision,omitempty"`              // allow/deny/conditional
+	Condition        *Expression            `protobuf:"bytes,2,opt,name=condition,proto3" json:"condition,omitempty"`                                       // filter expression to apply
+	Context          *AuthorizationContext  `protobuf:"bytes,3,opt,name=context,proto3" json:"context,omitempty"`                                           // additional context about the authorization decision
+	ConsistencyToken *ConsistencyToken      `protobuf:"bytes,4,opt,name=consistency_token,json=consistencyToken,proto3" json:"consistency_token,omitempty"` // freshness of this authorization decision
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *ListObjectsResponse) Reset() {
+	*x = ListObjectsResponse{}
+	mi := &file_cazi_v1_cazi_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListObjectsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListObjectsResponse) ProtoMessage() {}
+
+func (x *ListObjectsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_cazi_v1_cazi_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListObjectsResponse.ProtoReflect.Descriptor instead.
+func (*ListObjectsResponse) Descriptor() ([]byte, []int) {
+	return file_cazi_v1_cazi_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListObjectsResponse) GetDecision() DecisionKind {
+	if x != nil {
+		return x.Decision
+	}
+	return DecisionKind_DECISION_KIND_UNSPECIFIED
+}
+
+func (x *ListObjectsResponse) GetCondition() *Expression {
+	if x != nil {
+		return x.Condition
+	}
+	return nil
+}
+
+func (x *ListObjectsResponse) GetContext() *AuthorizationContext {
+	if x != nil {
+		return x.Context
+	}
+	return nil
+}
+
+func (x *ListObjectsResponse) GetConsistencyToken() *ConsistencyToken {
+	if x != nil {
+		return x.ConsistencyToken
+	}
+	return nil
+}
+
+// WatchRequest captures the inputs to a watch.
+type WatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ObjectTypes   []string               `protobuf:"bytes,1,rep,name=object_types,json=objectTypes,proto3" json:"object_types,omitempty"` // optional; only changes to objects of these types
+	StartAfter    *ConsistencyToken      `protobuf:"bytes,2,opt,name=start_after,json=startAfter,proto3" json:"start_after,omitempty"`    // optional; if unset, only changes made after the watch starts
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	mi := &file_cazi_v1_cazi_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cazi_v1_cazi_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_cazi_v1_cazi_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *WatchRequest) GetObjectTypes() []string {
+	if x != nil {
+		return x.ObjectTypes
+	}
+	return nil
+}
+
+func (x *WatchRequest) GetStartAfter() *ConsistencyToken {
+	if x != nil {
+		return x.StartAfter
+	}
+	return nil
+}
+
+// WatchResponse is a set of relationship updates that became visible together.
+type WatchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Updates       []*RelationshipUpdate  `protobuf:"bytes,1,rep,name=updates,proto3" json:"updates,omitempty"`                      // RELATIONSHIP_OPERATION_TOUCH or RELATIONSHIP_OPERATION_DELETE
+	ChangedAt     *ConsistencyToken      `protobuf:"bytes,2,opt,name=changed_at,json=changedAt,proto3" json:"changed_at,omitempty"` // resume after this change by passing it as start_after
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchResponse) Reset() {
+	*x = WatchResponse{}
+	mi := &file_cazi_v1_cazi_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchResponse) ProtoMessage() {}
+
+func (x *WatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_cazi_v1_cazi_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchResponse.ProtoReflect.Descriptor instead.
+func (*WatchResponse) Descriptor() ([]byte, []int) {
+	return file_cazi_v1_cazi_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *WatchResponse) GetUpdates() []*RelationshipUpdate {
+	if x != nil {
+		return x.Updates
+	}
+	return nil
+}
+
+func (x *WatchResponse) GetChangedAt() *ConsistencyToken {
+	if x != nil {
+		return x.ChangedAt
+	}
+	return nil
+}
+
+// Relationship states that a subject has a relation to an object.
+type Relationship struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Object          *ResourceReference     `protobuf:"bytes,1,opt,name=object,proto3" json:"object,omitempty"`
+	Relation        string                 `protobuf:"bytes,2,opt,name=relation,proto3" json:"relation,omitempty"`
+	Subject         *ResourceReference     `protobuf:"bytes,3,opt,name=subject,proto3" json:"subject,omitempty"`
+	SubjectRelation string                 `protobuf:"bytes,4,opt,name=subject_relation,json=subjectRelation,proto3" json:"subject_relation,omitempty"` // optional relation of a subject set (e.g., "member")
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Relationship) Reset() {
+	*x = Relationship{}
+	mi := &file_cazi_v1_cazi_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Relationship) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Relationship) ProtoMessage() {}
+
+func (x *Relationship) ProtoReflect() protoreflect.Message {
+	mi := &file_cazi_v1_cazi_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Relationship.ProtoReflect.Descriptor instead.
+func (*Relationship) Descriptor() ([]byte, []int) {
+	return file_cazi_v1_cazi_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Relationship) GetObject() *ResourceReference {
+	if x != nil {
+		return x.Object
+	}
+	return nil
+}
+
+func (x *Relationship) GetRelation() string {
+	if x != nil {
+		return x.Relation
+	}
+	return ""
+}
+
+func (x *Relationship) GetSubject() *ResourceReference {
+	if x != nil {
+		return x.Subject
+	}
+	return nil
+}
+
+func (x *Relationship) GetSubjectRelation() string {
+	if x != nil {
+		return x.SubjectRelation
+	}
+	return ""
+}
+
+// RelationshipUpdate is a single change to a relationship.
+type RelationshipUpdate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Operation     RelationshipOperation  `protobuf:"varint,1,opt,name=operation,proto3,enum=cazi.v1.RelationshipOperation" json:"operation,omitempty"`
+	Relationship  *Relationship          `protobuf:"bytes,2,opt,name=relationship,proto3" json:"relationship,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RelationshipUpdate) Reset() {
+	*x = RelationshipUpdate{}
+	mi := &file_cazi_v1_cazi_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RelationshipUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RelationshipUpdate) ProtoMessage() {}
+
+func (x *RelationshipUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_cazi_v1_cazi_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RelationshipUpdate.ProtoReflect.Descriptor instead.
+func (*RelationshipUpdate) Descriptor() ([]byte, []int) {
+	return file_cazi_v1_cazi_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *RelationshipUpdate) GetOperation() RelationshipOperation {
+	if x != nil {
+		return x.Operation
+	}
+	return RelationshipOperation_RELATIONSHIP_OPERATION_UNSPECIFIED
+}
+
+func (x *RelationshipUpdate) GetRelationship() *Relationship {
+	if x != nil {
+		return x.Relationship
+	}
+	return nil
+}
+
+// Subject represents the actor performing the action.
+type Subject struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Assertion     *Assertion             `protobuf:"bytes,1,opt,name=assertion,proto3" json:"assertion,omitempty"` // assertions about the subject
+	Relation      string                 `protobuf:"bytes,2,opt,name=relation,proto3" json:"relation,omitempty"`   // optional relation (e.g., "member")
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Subject) Reset() {
+	*x = Subject{}
+	mi := &file_cazi_v1_cazi_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Subject) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Subject) ProtoMessage() {}
+
+func (x *Subject) ProtoReflect() protoreflect.Message {
+	mi := &file_cazi_v1_cazi_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Subject.ProtoReflect.Descriptor instead.
+func (*Subject) Descriptor() ([]byte, []int) {
+	return file_cazi_v1_cazi_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Subject) GetAssertion() *Assertion {
+	if x != nil {
+		return x.Assertion
+	}
+	return nil
+}
+
+func (x *Subject) GetRelation() string {
+	if x != nil {
+		return x.Relation
+	}
+	return ""
+}
+
+// Object represents the target of the action.
+type Object struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Assertion     *Assertion             `protobuf:"bytes,1,opt,name=assertion,proto3" json:"assertion,omitempty"` // assertions about the object
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Object) Reset() {
+	*x = Object{}
+	mi := &file_cazi_v1_cazi_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Object) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Object) ProtoMessage() {}
+
+func (x *Object) ProtoReflect() protoreflect.Message {
+	mi := &file_cazi_v1_cazi_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Object.ProtoReflect.Descriptor instead.
+func (*Object) Descriptor() ([]byte, []int) {
+	return file_cazi_v1_cazi_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Object) GetAssertion() *Assertion {
+	if x != nil {
+		return x.Assertion
+	}
+	return nil
+}
+
+// Assertion is a one-of representing assertions about a subject or object.
+type Assertion struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Assertion:
+	//
+	//	*Assertion_Claims
+	//	*Assertion_OpaqueToken
+	//	*Assertion_ResourceReference
+	Assertion     isAssertion_Assertion `protobuf_oneof:"assertion"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Assertion) Reset() {
+	*x = Assertion{}
+	mi := &file_cazi_v1_cazi_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Assertion) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Assertion) ProtoMessage() {}
+
+func (x *Assertion) ProtoReflect() protoreflect.Message {
+	mi := &file_cazi_v1_cazi_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Assertion.ProtoReflect.Descriptor instead.
+func (*Assertion) Descriptor() ([]byte, []int) {
+	return file_cazi_v1_cazi_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Assertion) GetAssertion() isAssertion_Assertion {
+	if x != nil {
+		return x.Assertion
+	}
+	return nil
+}
+
+func (x *Assertion) GetClaims() *structpb.Struct {
+	if x != nil {
+		if x, ok := x.Assertion.(*Assertion_Claims); ok {
+			return x.Claims
+		}
+	}
+	return nil
+}
+
+func (x *Assertion) GetOpaqueToken() *OpaqueToken {
+	if x != nil {
+		if x, ok := x.Assertion.(*Assertion_OpaqueToken); ok {
+			return x.OpaqueToken
+		}
+	}
+	return nil
+}
+
+func (x *Assertion) GetResourceReference() *ResourceReference {
+	if x != nil {
+		if x, ok := x.Assertion.(*Assertion_ResourceReference); ok {
+			return x.ResourceReference
+		}
+	}
+	return nil
+}
+
+type isAssertion_Assertion interface {
+	isAssertion_Assertion()
+}
+
+type Assertion_Claims struct {
+	// Claims is a JSON-compatible set of key-value pairs.
+	Claims *structpb.Struct `protobuf:"bytes,1,opt,name=claims,proto3,oneof"`
+}
+
+type Assertion_OpaqueToken struct {
+	OpaqueToken *OpaqueToken `protobuf:"bytes,2,opt,name=opaque_token,json=opaqueToken,proto3,oneof"`
+}
+
+type Assertion_ResourceReference struct {
+	ResourceReference *ResourceReference `protobuf:"bytes,3,opt,name=resource_reference,json=resourceReference,proto3,oneof"`
+}
+
+func (*Assertion_Claims) isAssertion_Assertion() {}
+
+func (*Assertion_OpaqueToken) isAssertion_Assertion() {}
+
+func (*Assertion_ResourceReference) isAssertion_Assertion() {}
+
+// OpaqueToken carries an opaque payload with a declared type, optionally signed (e.g., JWT).
+type OpaqueToken struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"` // media/type or scheme identifier (e.g., "jwt")
+	Raw           []byte                 `protobuf:"bytes,2,opt,name=raw,proto3" json:"raw,omitempty"`   // raw token bytes
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OpaqueToken) Reset() {
+	*x = OpaqueToken{}
+	mi := &file_cazi_v1_cazi_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OpaqueToken) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OpaqueToken) ProtoMessage() {}
+
+func (x *OpaqueToken) ProtoReflect() protoreflect.Message {
+	mi := &file_cazi_v1_cazi_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OpaqueToken.ProtoReflect.Descriptor instead.
+func (*OpaqueToken) Descriptor() ([]byte, []int) {
+	return file_cazi_v1_cazi_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *OpaqueToken) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *OpaqueToken) GetRaw() []byte {
+	if x != nil {
+		return x.Raw
+	}
+	return nil
+}
+
+// ResourceReference identifies a resource by type and id.
+type ResourceReference struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Id            string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResourceReference) Reset() {
+	*x = ResourceReference{}
+	mi := &file_cazi_v1_cazi_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResourceReference) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResourceReference) ProtoMessage() {}
+
+func (x *ResourceReference) ProtoReflect() protoreflect.Message {
+	mi := &file_cazi_v1_cazi_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResourceReference.ProtoReflect.Descriptor instead.
+func (*ResourceReference) Descriptor() ([]byte, []int) {
+	return file_cazi_v1_cazi_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ResourceReference) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *ResourceReference) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// Expression represents a condition the caller can evaluate.
+// The language is intentionally unspecified (e.g., "cel", "rego").
+type Expression struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Language      string                 `protobuf:"bytes,1,opt,name=language,proto3" json:"language,omitempty"`
+	Expression    string                 `protobuf:"bytes,2,opt,name=expression,proto3" json:"expression,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Expression) Reset() {
+	*x = Expression{}
+	mi := &file_cazi_v1_cazi_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Expression) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Expression) ProtoMessage() {}
+
+func (x *Expression) ProtoReflect() protoreflect.Message {
+	mi := &file_cazi_v1_cazi_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Expression.ProtoReflect.Descriptor instead.
+func (*Expression) Descriptor() ([]byte, []int) {
+	return file_cazi_v1_cazi_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Expression) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *Expression) GetExpression() string {
+	if x != nil {
+		return x.Expression
+	}
+	return ""
+}
+
+// AuthorizationContext provides optional additional information about the authorization decision.
+// Inspired by the Transaction Token specification (draft-ietf-oauth-transaction-tokens).
+type AuthorizationContext struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	RequesterContext   *structpb.Struct       `protobuf:"bytes,1,opt,name=requester_context,json=requesterContext,proto3" json:"requester_context,omitempty"`       // claims about the requester (subject)
+	TransactionContext *structpb.Struct       `protobuf:"bytes,2,opt,name=transaction_context,json=transactionContext,proto3" json:"transaction_context,omitempty"` // claims about the requested operation
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *AuthorizationContext) Reset() {
+	*x = AuthorizationContext{}
+	mi := &file_cazi_v1_cazi_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuthorizationContext) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuthorizationContext) ProtoMessage() {}
+
+func (x *AuthorizationContext) ProtoReflect() protoreflect.Message {
+	mi := &file_cazi_v1_cazi_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuthorizationContext.ProtoReflect.Descriptor instead.
+func (*AuthorizationContext) Descriptor() ([]byte, []int) {
+	return file_cazi_v1_cazi_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *AuthorizationContext) GetRequesterContext() *structpb.Struct {
+	if x != nil {
+		return x.RequesterContext
+	}
+	return nil
+}
+
+func (x *AuthorizationContext) GetTransactionContext() *structpb.Struct {
+	if x != nil {
+		return x.TransactionContext
+	}
+	return nil
+}
+
+// Consistency is the consistency requirement of a request.
+// Implementations report the snapshot they used in the response's consistency_token.
+type Consistency struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Mode          ConsistencyMode        `protobuf:"varint,1,opt,name=mode,proto3,enum=cazi.v1.ConsistencyMode" json:"mode,omitempty"`
+	Token         *ConsistencyToken      `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"` // required for AT_LEAST_AS_FRESH and AT_EXACT_SNAPSHOT
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Consistency) Reset() {
+	*x = Consistency{}
+	mi := &file_cazi_v1_cazi_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Consistency) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Consistency) ProtoMessage() {}
+
+func (x *Consistency) ProtoReflect() protoreflect.Message {
+	mi := &file_cazi_v1_cazi_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Consistency.ProtoReflect.Descriptor instead.
+func (*Consistency) Descriptor() ([]byte, []int) {
+	return file_cazi_v1_cazi_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *Consistency) GetMode() ConsistencyMode {
+	if x != nil {
+		return x.Mode
+	}
+	return ConsistencyMode_CONSISTENCY_MODE_UNSPECIFIED
+}
+
+func (x *Consistency) GetToken() *ConsistencyToken {
+	if x != nil {
+		return x.Token
+	}
+	return nil
+}
+
+// ConsistencyToken is an opaque token representing the freshness of authorization data.
+// Clients must round-trip the bytes untouched.
+type ConsistencyToken struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         []byte                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConsistencyToken) Reset() {
+	*x = ConsistencyToken{}
+	mi := &file_cazi_v1_cazi_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConsistencyToken) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConsistencyToken) ProtoMessage() {}
+
+func (x *ConsistencyToken) ProtoReflect() protoreflect.Message {
+	mi := &file_cazi_v1_cazi_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConsistencyToken.ProtoReflect.Descriptor instead.
+func (*ConsistencyToken) Descriptor() ([]byte, []int) {
+	return file_cazi_v1_cazi_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ConsistencyToken) GetToken() []byte {
+	if x != nil {
+		return x.Token
+	}
+	return nil
+}
+
+var File_cazi_v1_cazi_proto protoreflect.FileDescriptor
+
+const file_cazi_v1_cazi_proto_rawDesc = "" +
+	"\n" +
+	"\x12cazi/v1/cazi.proto\x12\acazi.v1\x1a\x1cgoogle/protobuf/struct.proto\"\x8f\x02\n" +
+	"\fCheckRequest\x12*\n" +
+	"\asubject\x18\x01 \x01(\v2\x10.cazi.v1.SubjectR\asubject\x12\x12\n" +
+	"\x04verb\x18\x02 \x01(\tR\x04verb\x12'\n" +
+	"\x06object\x18\x03 \x01(\v2\x0f.cazi.v1.ObjectR\x06object\x12D\n" +
+	"\x11at_least_as_fresh\x18\x04 \x01(\v2\x19.cazi.v1.ConsistencyTokenR\x0eatLeastAsFresh\x126\n" +
+	"\vconsistency\x18\x05 \x01(\v2\x14.cazi.v1.ConsistencyR\vconsistency\x12\x18\n" +
+	"\aexplain\x18\x06 \x01(\bR\aexplain\"\xae\x02\n" +
+	"\rCheckResponse\x121\n" +
+	"\bdecision\x18\x01 \x01(\x0e2\x15.cazi.v1.DecisionKindR\bdecision\x121\n" +
+	"\tcondition\x18\x02 \x01(\v2\x13.cazi.v1.ExpressionR\tcondition\x127\n" +
+	"\acontext\x18\x03 \x01(\v2\x1d.cazi.v1.AuthorizationContextR\acontext\x12F\n" +
+	"\x11consistency_token\x18\x04 \x01(\v2\x19.cazi.v1.ConsistencyTokenR\x10consistencyToken\x126\n" +
+	"\vexplanation\x18\x05 \x01(\v2\x14.cazi.v1.ExplanationR\vexplanation\"\xfc\x01\n" +
+	"\vExplanation\x12 \n" +
+	"\vdescription\x18\x01 \x01(\tR\vdescription\x121\n" +
+	"\bdecision\x18\x02 \x01(\x0e2\x15.cazi.v1.DecisionKindR\bdecision\x129\n" +
+	"\frelationship\x18\x03 \x01(\v2\x15.cazi.v1.RelationshipR\frelationship\x121\n" +
+	"\tcondition\x18\x04 \x01(\v2\x13.cazi.v1.ExpressionR\tcondition\x12*\n" +
+	"\x05steps\x18\x05 \x03(\v2\x14.cazi.v1.ExplanationR\x05steps\"\xa0\x02\n" +
+	"\x12ListObjectsRequest\x12*\n" +
+	"\asubject\x18\x01 \x01(\v2\x10.cazi.v1.SubjectR\asubject\x12\x12\n" +
+	"\x04verb\x18\x02 \x01(\tR\x04verb\x12\x1f\n" +
+	"\vobject_type\x18\x03 \x01(\tR\n" +
+	"objectType\x12+\n" +
+	"\x06filter\x18\x04 \x01(\v2\x13.cazi.v1.ExpressionR\x06filter\x12D\n" +
+	"\x11at_least_as_fresh\x18\x05 \x01(\v2\x19.cazi.v1.ConsistencyTokenR\x0eatLeastAsFresh\x126\n" +
+	"\vconsistency\x18\x06 \x01(\v2\x14.cazi.v1.ConsistencyR\vconsistency\"\xfc\x01\n" +
+	"\x13ListObjectsResponse\x121\n" +
+	"\bdecision\x18\x01 \x01(\x0e2\x15.cazi.v1.DecisionKindR\bdecision\x121\n" +
+	"\tcondition\x18\x02 \x01(\v2\x13.cazi.v1.ExpressionR\tcondition\x127\n" +
+	"\acontext\x18\x03 \x01(\v2\x1d.cazi.v1.AuthorizationContextR\acontext\x12F\n" +
+	"\x11consistency_token\x18\x04 \x01(\v2\x19.cazi.v1.ConsistencyTokenR\x10consistencyToken\"m\n" +
+	"\fWatchRequest\x12!\n" +
+	"\fobject_types\x18\x01 \x03(\tR\vobjectTypes\x12:\n" +
+	"\vstart_after\x18\x02 \x01(\v2\x19.cazi.v1.ConsistencyTokenR\n" +
+	"startAfter\"\x80\x01\n" +
+	"\rWatchResponse\x125\n" +
+	"\aupdates\x18\x01 \x03(\v2\x1b.cazi.v1.RelationshipUpdateR\aupdates\x128\n" +
+	"\n" +
+	"changed_at\x18\x02 \x01(\v2\x19.cazi.v1.ConsistencyTokenR\tchangedAt\"\xbf\x01\n" +
+	"\fRelationship\x122\n" +
+	"\x06object\x18\x01 \x01(\v2\x1a.cazi.v1.ResourceReferenceR\x06object\x12\x1a\n" +
+	"\brelation\x18\x02 \x01(\tR\brelation\x124\n" +
+	"\asubject\x18\x03 \x01(\v2\x1a.cazi.v1.ResourceReferenceR\asubject\x12)\n" +
+	"\x10subject_relation\x18\x04 \x01(\tR\x0fsubjectRelation\"\x8d\x01\n" +
+	"\x12RelationshipUpdate\x12<\n" +
+	"\toperation\x18\x01 \x01(\x0e2\x1e.cazi.v1.RelationshipOperationR\toperation\x129\n" +
+	"\frelationship\x18\x02 \x01(\v2\x15.cazi.v1.RelationshipR\frelationship\"W\n" +
+	"\aSubject\x120\n" +
+	"\tassertion\x18\x01 \x01(\v2\x12.cazi.v1.AssertionR\tassertion\x12\x1a\n" +
+	"\brelation\x18\x02 \x01(\tR\brelation\":\n" +
+	"\x06Object\x120\n" +
+	"\tassertion\x18\x01 \x01(\v2\x12.cazi.v1.AssertionR\tassertion\"\xd3\x01\n" +
+	"\tAssertion\x121\n" +
+	"\x06claims\x18\x01 \x01(\v2\x17.google.protobuf.StructH\x00R\x06claims\x129\n" +
+	"\fopaque_token\x18\x02 \x01(\v2\x14.cazi.v1.OpaqueTokenH\x00R\vopaqueToken\x12K\n" +
+	"\x12resource_reference\x18\x03 \x01(\v2\x1a.cazi.v1.ResourceReferenceH\x00R\x11resourceReferenceB\v\n" +
+	"\tassertion\"3\n" +
+	"\vOpaqueToken\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x10\n" +
+	"\x03raw\x18\x02 \x01(\fR\x03raw\"7\n" +
+	"\x11ResourceReference\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\tR\x02id\"H\n" +
+	"\n" +
+	"Expression\x12\x1a\n" +
+	"\blanguage\x18\x01 \x01(\tR\blanguage\x12\x1e\n" +
+	"\n" +
+	"expression\x18\x02 \x01(\tR\n" +
+	"expression\"\xa6\x01\n" +
+	"\x14AuthorizationContext\x12D\n" +
+	"\x11requester_context\x18\x01 \x01(\v2\x17.google.protobuf.StructR\x10requesterContext\x12H\n" +
+	"\x13transaction_context\x18\x02 \x01(\v2\x17.google.protobuf.StructR\x12transactionContext\"l\n" +
+	"\vConsistency\x12,\n" +
+	"\x04mode\x18\x01 \x01(\x0e2\x18.cazi.v1.ConsistencyModeR\x04mode\x12/\n" +
+	"\x05token\x18\x02 \x01(\v2\x19.cazi.v1.ConsistencyTokenR\x05token\"(\n" +
+	"\x10ConsistencyToken\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\fR\x05token*\xa7\x01\n" +
+	"\x15RelationshipOperation\x12&\n" +
+	"\"RELATIONSHIP_OPERATION_UNSPECIFIED\x10\x00\x12 \n" +
+	"\x1cRELATIONSHIP_OPERATION_TOUCH\x10\x01\x12!\n" +
+	"\x1dRELATIONSHIP_OPERATION_CREATE\x10\x02\x12!\n" +
+	"\x1dRELATIONSHIP_OPERATION_DELETE\x10\x03*}\n" +
+	"\fDecisionKind\x12\x1d\n" +
+	"\x19DECISION_KIND_UNSPECIFIED\x10\x00\x12\x17\n" +
+	"\x13DECISION_KIND_ALLOW\x10\x01\x12\x16\n" +
+	"\x12DECISION_KIND_DENY\x10\x02\x12\x1d\n" +
+	"\x19DECISION_KIND_CONDITIONAL\x10\x03*\xd1\x01\n" +
+	"\x0fConsistencyMode\x12 \n" +
+	"\x1cCONSISTENCY_MODE_UNSPECIFIED\x10\x00\x12%\n" +
+	"!CONSISTENCY_MODE_MINIMIZE_LATENCY\x10\x01\x12&\n" +
+	"\"CONSISTENCY_MODE_AT_LEAST_AS_FRESH\x10\x02\x12&\n" +
+	"\"CONSISTENCY_MODE_AT_EXACT_SNAPSHOT\x10\x03\x12%\n" +
+	"!CONSISTENCY_MODE_FULLY_CONSISTENT\x10\x042\xda\x01\n" +
+	"\x1cCommonAuthorizationInterface\x126\n" +
+	"\x05Check\x12\x15.cazi.v1.CheckRequest\x1a\x16.cazi.v1.CheckResponse\x12H\n" +
+	"\vListObjects\x12\x1b.cazi.v1.ListObjectsRequest\x1a\x1c.cazi.v1.ListObjectsResponse\x128\n" +
+	"\x05Watch\x12\x15.cazi.v1.WatchRequest\x1a\x16.cazi.v1.WatchResponse0\x01BY\n" +
+	" com.github.alechenninger.cazi.v1P\x01Z3github.com/alechenninger/cazi/gen/go/cazi/v1;caziv1b\x06proto3"
+
+var (
+	file_cazi_v1_cazi_proto_rawDescOnce sync.Once
+	file_cazi_v1_cazi_proto_rawDescData []byte
+)
+
+func file_cazi_v1_cazi_proto_rawDescGZIP() []byte {
+	file_cazi_v1_cazi_proto_rawDescOnce.Do(func() {
+		file_cazi_v1_cazi_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_cazi_v1_cazi_proto_rawDesc), len(file_cazi_v1_cazi_proto_rawDesc)))
+	})
+	return file_cazi_v1_cazi_proto_rawDescData
+}
+
+var file_cazi_v1_cazi_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_cazi_v1_cazi_proto_msgTypes = make([]protoimpl.MessageInfo, 18)
+var file_cazi_v1_cazi_proto_goTypes = []any{
+	(RelationshipOperation)(0),   // 0: cazi.v1.RelationshipOperation
+	(DecisionKind)(0),            // 1: cazi.v1.DecisionKind
+	(ConsistencyMode)(0),         // 2: cazi.v1.ConsistencyMode
+	(*CheckRequest)(nil),         // 3: cazi.v1.CheckRequest
+	(*CheckResponse)(nil),        // 4: cazi.v1.CheckResponse
+	(*Explanation)(nil),          // 5: cazi.v1.Explanation
+	(*ListObjectsRequest)(nil),   // 6: cazi.v1.ListObjectsRequest
+	(*ListObjectsResponse)(nil),  // 7: cazi.v1.ListObjectsResponse
+	(*WatchRequest)(nil),         // 8: cazi.v1.WatchRequest
+	(*WatchResponse)(nil),        // 9: cazi.v1.WatchResponse
+	(*Relationship)(nil),         // 10: cazi.v1.Relationship
+	(*RelationshipUpdate)(nil),   // 11: cazi.v1.RelationshipUpdate
+	(*Subject)(nil),              // 12: cazi.v1.Subject
+	(*Object)(nil),               // 13: cazi.v1.Object
+	(*Assertion)(nil),            // 14: cazi.v1.Assertion
+	(*OpaqueToken)(nil),          // 15: cazi.v1.OpaqueToken
+	(*ResourceReference)(nil),    // 16: cazi.v1.ResourceReference
+	(*Expression)(nil),           // 17: cazi.v1.Expression
+	(*AuthorizationContext)(nil), // 18: cazi.v1.AuthorizationContext
+	(*Consistency)(nil),          // 19: cazi.v1.Consistency
+	(*ConsistencyToken)(nil),     // 20: cazi.v1.ConsistencyToken
+	(*structpb.Struct)(nil),      // 21: google.protobuf.Struct
+}
+var file_cazi_v1_cazi_proto_depIdxs = []int32{
+	12, // 0: cazi.v1.CheckRequest.subject:type_name -> cazi.v1.Subject
+	13, // 1: cazi.v1.CheckRequest.object:type_name -> cazi.v1.Object
+	20, // 2: cazi.v1.CheckRequest.at_least_as_fresh:type_name -> cazi.v1.ConsistencyToken
+	19, // 3: cazi.v1.CheckRequest.consistency:type_name -> cazi.v1.Consistency
+	1,  // 4: cazi.v1.CheckResponse.decision:type_name -> cazi.v1.DecisionKind
+	17, // 5: cazi.v1.CheckResponse.condition:type_name -> cazi.v1.Expression
+	18, // 6: cazi.v1.CheckResponse.context:type_name -> cazi.v1.AuthorizationContext
+	20, // 7: cazi.v1.CheckResponse.consistency_token:type_name -> cazi.v1.ConsistencyToken
+	5,  // 8: cazi.v1.CheckResponse.explanation:type_name -> cazi.v1.Explanation
+	1,  // 9: cazi.v1.Explanation.decision:type_name -> cazi.v1.DecisionKind
+	10, // 10: cazi.v1.Explanation.relationship:type_name -> cazi.v1.Relationship
+	17, // 11: cazi.v1.Explanation.condition:type_name -> cazi.v1.Expression
+	5,  // 12: cazi.v1.Explanation.steps:type_name -> cazi.v1.Explanation
+	12, // 13: cazi.v1.ListObjectsRequest.subject:type_name -> cazi.v1.Subject
+	17, // 14: cazi.v1.ListObjectsRequest.filter:type_name -> cazi.v1.Expression
+	20, // 15: cazi.v1.ListObjectsRequest.at_least_as_fresh:type_name -> cazi.v1.ConsistencyToken
+	19, // 16: cazi.v1.ListObjectsRequest.consistency:type_name -> cazi.v1.Consistency
+	1,  // 17: cazi.v1.ListObjectsResponse.decision:type_name -> cazi.v1.DecisionKind
+	17, // 18: cazi.v1.ListObjectsResponse.condition:type_name -> cazi.v1.Expression
+	18, // 19: cazi.v1.ListObjectsResponse.context:type_name -> cazi.v1.AuthorizationContext
+	20, // 20: cazi.v1.ListObjectsResponse.consistency_token:type_name -> cazi.v1.ConsistencyToken
+	20, // 21: cazi.v1.WatchRequest.start_after:type_name -> cazi.v1.ConsistencyToken
+	11, // 22: cazi.v1.WatchResponse.updates:type_name -> cazi.v1.RelationshipUpdate
+	20, // 23: cazi.v1.WatchResponse.changed_at:type_name -> cazi.v1.ConsistencyToken
+	16, // 24: cazi.v1.Relationship.object:type_name -> cazi.v1.ResourceReference
+	16, // 25: cazi.v1.Relationship.subject:type_name -> cazi.v1.ResourceReference
+	0,  // 26: cazi.v1.RelationshipUpdate.operation:type_name -> cazi.v1.RelationshipOperation
+	10, // 27: cazi.v1.RelationshipUpdate.relationship:type_name -> cazi.v1.Relationship
+	14, // 28: cazi.v1.Subject.assertion:type_name -> cazi.v1.Assertion
+	14, // 29: cazi.v1.Object.assertion:type_name -> cazi.v1.Assertion
+	21, // 30: cazi.v1.Assertion.claims:type_name -> google.protobuf.Struct
+	15, // 31: cazi.v1.Assertion.opaque_token:type_name -> cazi.v1.OpaqueToken
+	16, // 32: cazi.v1.Assertion.resource_reference:type_name -> cazi.v1.ResourceReference
+	21, // 33: cazi.v1.AuthorizationContext.requester_context:type_name -> google.protobuf.Struct
+	21, // 34: cazi.v1.AuthorizationContext.transaction_context:type_name -> google.protobuf.Struct
+	2,  // 35: cazi.v1.Consistency.mode:type_name -> cazi.v1.ConsistencyMode
+	20, // 36: cazi.v1.Consistency.token:type_name -> cazi.v1.ConsistencyToken
+	3,  // 37: cazi.v1.CommonAuthorizationInterface.Check:input_type -> cazi.v1.CheckRequest
+	6,  // 38: cazi.v1.CommonAuthorizationInterface.ListObjects:input_type -> cazi.v1.ListObjectsRequest
+	8,  // 39: cazi.v1.CommonAuthorizationInterface.Watch:input_type -> cazi.v1.WatchRequest
+	4,  // 40: cazi.v1.CommonAuthorizationInterface.Check:output_type -> cazi.v1.CheckResponse
+	7,  // 41: cazi.v1.CommonAuthorizationInterface.ListObjects:output_type -> cazi.v1.ListObjectsResponse
+	9,  // 42: cazi.v1.CommonAuthorizationInterface.Watch:output_type -> cazi.v1.WatchResponse
+	40, // [40:43] is the sub-list for method output_type
+	37, // [37:40] is the sub-list for method input_type
+	37, // [37:37] is the sub-list for extension type_name
+	37, // [37:37] is the sub-list for extension extendee
+	0,  // [0:37] is the sub-list for field type_name
+}
+
+func init() { file_cazi_v1_cazi_proto_init() }
+func file_cazi_v1_cazi_proto_init() {
+	if File_cazi_v1_cazi_proto != nil {
+		return
+	}
+	file_cazi_v1_cazi_proto_msgTypes[11].OneofWrappers = []any{
+		(*Assertion_Claims)(nil),
+		(*Assertion_OpaqueToken)(nil),
+		(*Assertion_ResourceReference)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_cazi_v1_cazi_proto_rawDesc), len(file_cazi_v1_cazi_proto_rawDesc)),
+			NumEnums:      3,
+			NumMessages:   18,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_cazi_v1_cazi_proto_goTypes,
+		DependencyIndexes: file_cazi_v1_cazi_proto_depIdxs,
+		EnumInfos:         file_cazi_v1_cazi_proto_enumTypes,
+		MessageInfos:      file_cazi_v1_cazi_proto_msgTypes,
+	}.Build()
+	File_cazi_v1_cazi_proto = out.File
+	file_cazi_v1_cazi_proto_goTypes = nil
+	file_cazi_v1_cazi_proto_depIdxs = nil
+}
@@ -0,0 +1,218 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: cazi/v1/cazi.proto
+
+package caziv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	CommonAuthorizationInterface_Check_FullMethodName       = "/cazi.v1.CommonAuthorizationInterface/Check"
+	CommonAuthorizationInterface_ListObjects_FullMethodName = "/cazi.v1.CommonAuthorizationInterface/ListObjects"
+	CommonAuthorizationInterface_Watch_FullMethodName       = "/cazi.v1.CommonAuthorizationInterface/Watch"
+)
+
+// CommonAuthorizationInterfaceClient is the client API for CommonAuthorizationInterface service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// CommonAuthorizationInterface defines the core authorization service.
+// It mirrors the Go cazi.Interface.
+type CommonAuthorizationInterfaceClient interface {
+	// Check answers whether a subject can perform a verb (relation) on an object.
+	// The decision is allow, deny, or conditional on an expression the caller evaluates.
+	Check(ctx context.Context, in *CheckRequest, opts ...grpc.CallOption) (*CheckResponse, error)
+	// ListObjects returns a filter expression for querying authorized objects of a given type.
+	ListObjects(ctx context.Context, in *ListObjectsRequest, opts ...grpc.CallOption) (*ListObjectsResponse, error)
+	// Watch streams changes to relationships, in the order they became visible.
+	// Servers whose implementation does not support watching return UNIMPLEMENTED.
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchResponse], error)
+}
+
+type commonAuthorizationInterfaceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCommonAuthorizationInterfaceClient(cc grpc.ClientConnInterface) CommonAuthorizationInterfaceClient {
+	return &commonAuthorizationInterfaceClient{cc}
+}
+
+func (c *commonAuthorizationInterfaceClient) Check(ctx context.Context, in *CheckRequest, opts ...grpc.CallOption) (*CheckResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CheckResponse)
+	err := c.cc.Invoke(ctx, CommonAuthorizationInterface_Check_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commonAuthorizationInterfaceClient) ListObjects(ctx context.Context, in *ListObjectsRequest, opts ...grpc.CallOption) (*ListObjectsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListObjectsResponse)
+	err := c.cc.Invoke(ctx, CommonAuthorizationInterface_ListObjects_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commonAuthorizationInterfaceClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CommonAuthorizationInterface_ServiceDesc.Streams[0], CommonAuthorizationInterface_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRequest, WatchResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CommonAuthorizationInterface_WatchClient = grpc.ServerStreamingClient[WatchResponse]
+
+// CommonAuthorizationInterfaceServer is the server API for CommonAuthorizationInterface service.
+// All implementations must embed UnimplementedCommonAuthorizationInterfaceServer
+// for forward compatibility.
+//
+// CommonAuthorizationInterface defines the core authorization service.
+// It mirrors the Go cazi.Interface.
+type CommonAuthorizationInterfaceServer interface {
+	// Check answers whether a subject can perform a verb (relation) on an object.
+	// The decision is allow, deny, or conditional on an expression the caller evaluates.
+	Check(context.Context, *CheckRequest) (*CheckResponse, error)
+	// ListObjects returns a filter expression for querying authorized objects of a given type.
+	ListObjects(context.Context, *ListObjectsRequest) (*ListObjectsResponse, error)
+	// Watch streams changes to relationships, in the order they became visible.
+	// Servers whose implementation does not support watching return UNIMPLEMENTED.
+	Watch(*WatchRequest, grpc.ServerStreamingServer[WatchResponse]) error
+	mustEmbedUnimplementedCommonAuthorizationInterfaceServer()
+}
+
+// UnimplementedCommonAuthorizationInterfaceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCommonAuthorizationInterfaceServer struct{}
+
+func (UnimplementedCommonAuthorizationInterfaceServer) Check(context.Context, *CheckRequest) (*CheckResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Check not implemented")
+}
+func (UnimplementedCommonAuthorizationInterfaceServer) ListObjects(context.Context, *ListObjectsRequest) (*ListObjectsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListObjects not implemented")
+}
+func (UnimplementedCommonAuthorizationInterfaceServer) Watch(*WatchRequest, grpc.ServerStreamingServer[WatchResponse]) error {
+	return status.Error(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedCommonAuthorizationInterfaceServer) mustEmbedUnimplementedCommonAuthorizationInterfaceServer() {
+}
+func (UnimplementedCommonAuthorizationInterfaceServer) testEmbeddedByValue() {}
+
+// UnsafeCommonAuthorizationInterfaceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CommonAuthorizationInterfaceServer will
+// result in compilation errors.
+type UnsafeCommonAuthorizationInterfaceServer interface {
+	mustEmbedUnimplementedCommonAuthorizationInterfaceServer()
+}
+
+func RegisterCommonAuthorizationInterfaceServer(s grpc.ServiceRegistrar, srv CommonAuthorizationInterfaceServer) {
+	// If the following call panics, it indicates UnimplementedCommonAuthorizationInterfaceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&CommonAuthorizationInterface_ServiceDesc, srv)
+}
+
+func _CommonAuthorizationInterface_Check_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommonAuthorizationInterfaceServer).Check(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CommonAuthorizationInterface_Check_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommonAuthorizationInterfaceServer).Check(ctx, req.(*CheckRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommonAuthorizationInterface_ListObjects_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListObjectsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommonAuthorizationInterfaceServer).ListObjects(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CommonAuthorizationInterface_ListObjects_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommonAuthorizationInterfaceServer).ListObjects(ctx, req.(*ListObjectsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommonAuthorizationInterface_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CommonAuthorizationInterfaceServer).Watch(m, &grpc.GenericServerStream[WatchRequest, WatchResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CommonAuthorizationInterface_WatchServer = grpc.ServerStreamingServer[WatchResponse]
+
+// CommonAuthorizationInterface_ServiceDesc is the grpc.ServiceDesc for CommonAuthorizationInterface service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CommonAuthorizationInterface_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cazi.v1.CommonAuthorizationInterface",
+	HandlerType: (*CommonAuthorizationInterfaceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Check",
+			Handler:    _CommonAuthorizationInterface_Check_Handler,
+		},
+		{
+			MethodName: "ListObjects",
+			Handler:    _CommonAuthorizationInterface_ListObjects_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _CommonAuthorizationInterface_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "cazi/v1/cazi.proto",
+}
@@ -0,0 +1,477 @@
+// Package memory is an in-memory, relationship-based (Zanzibar-style) implementation of CAZI.
+//
+// It is intended for tests, examples and prototyping: all data lives in memory,
+// and queries scan every stored relationship.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"github.com/alechenninger/cazi/pkg/claims"
+	"github.com/alechenninger/cazi/pkg/consistency"
+)
+
+// Backend identifies memory stores in their consistency tokens.
+const Backend = "memory"
+
+// maxDepth bounds the expansion of nested subject sets.
+const maxDepth = 25
+
+// Store holds relationships in memory and answers authorization queries from them.
+//
+// A subject can perform a verb on an object if there is a relationship with that verb
+// as its relation from the object to the subject, or to a subject set
+// (e.g. group:eng#member) the subject belongs to.
+//
+// Every write advances the store's revision. Consistency tokens encode the revision
+// as a consistency package envelope from the [Backend] backend, so they can be compared with consistency.Compare.
+// The store keeps every change, so watches can resume from any token it issued,
+// and requests can be evaluated at the exact snapshot of any token (cazi.ConsistencyAtExactSnapshot).
+type Store struct {
+	mu            sync.RWMutex
+	revision      uint64
+	relationships relationshipSet
+	changes       []cazi.Change // changes[i] was made at revision i+1
+	changed       chan struct{} // closed and replaced on every write
+}
+
+var (
+	_ cazi.Interface          = (*Store)(nil)
+	_ cazi.SubjectLister      = (*Store)(nil)
+	_ cazi.RelationshipWriter = (*Store)(nil)
+	_ cazi.Watcher            = (*Store)(nil)
+)
+
+// New creates an empty store.
+func New() *Store {
+	return &Store{
+		relationships: make(relationshipSet),
+		changed:       make(chan struct{}),
+	}
+}
+
+// Check implements cazi.Interface.
+func (s *Store) Check(ctx context.Context, req cazi.CheckRequest) (cazi.CheckResponse, error) {
+	subject, ok := req.Subject.Assertion.(cazi.ResourceReference)
+	if !ok {
+		return cazi.CheckResponse{Decision: cazi.DecisionDeny}, fmt.Errorf("%w: subject must be a ResourceReference", cazi.ErrUnsupportedAssertion)
+	}
+	object, ok := req.Object.Assertion.(cazi.ResourceReference)
+	if !ok {
+		return cazi.CheckResponse{Decision: cazi.DecisionDeny}, fmt.Errorf("%w: object must be a ResourceReference", cazi.ErrUnsupportedAssertion)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rels, rev, err := s.snapshot(req.Consistency, req.AtLeastAsFresh)
+	if err != nil {
+		return cazi.CheckResponse{Decision: cazi.DecisionDeny}, err
+	}
+
+	var allowed bool
+	var explanation *cazi.Explanation
+	if req.Explain {
+		var steps []cazi.Explanation
+		allowed, steps = rels.explain(object, req.Verb, subject, req.Subject.Relation, 0)
+		explanation = &cazi.Explanation{
+			Description: fmt.Sprintf("%s %s %s", subjectString(subject, req.Subject.Relation), req.Verb, resourceString(object)),
+			Decision:    decisionFor(allowed),
+			Steps:       steps,
+		}
+	} else {
+		allowed = rels.check(object, req.Verb, subject, req.Subject.Relation, 0)
+	}
+
+	if !allowed {
+		return cazi.CheckResponse{
+			Decision:         cazi.DecisionDeny,
+			ConsistencyToken: revisionToken(rev),
+			Explanation:      explanation,
+		}, nil
+	}
+
+	reqCtx := make(cazi.Claims)
+	claims.Sub.Set(reqCtx, subject.ID)
+
+	return cazi.CheckResponse{
+		Decision:         cazi.DecisionAllow,
+		Context:          cazi.AuthorizationContext{RequesterContext: reqCtx},
+		ConsistencyToken: revisionToken(rev),
+		Explanation:      explanation,
+	}, nil
+}
+
+// ListObjects implements cazi.Interface.
+// Authorized objects are returned as a CEL expression over their ids (e.g. `widget.id in ["a", "b"]`).
+func (s *Store) ListObjects(ctx context.Context, req cazi.ListObjectsRequest) (cazi.ListObjectsResponse, error) {
+	subject, ok := req.Subject.Assertion.(cazi.ResourceReference)
+	if !ok {
+		return cazi.ListObjectsResponse{Decision: cazi.DecisionDeny}, fmt.Errorf("%w: subject must be a ResourceReference", cazi.ErrUnsupportedAssertion)
+	}
+	if req.Filter.Expression != "" {
+		return cazi.ListObjectsResponse{Decision: cazi.DecisionDeny}, fmt.Errorf("%w %q: filter expressions are not supported", cazi.ErrUnsupportedLanguage, req.Filter.Language)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rels, rev, err := s.snapshot(req.Consistency, req.AtLeastAsFresh)
+	if err != nil {
+		return cazi.ListObjectsResponse{Decision: cazi.DecisionDeny}, err
+	}
+
+	var ids []string
+	for _, object := range rels.resources(req.ObjectType, func(r cazi.Relationship) cazi.ResourceReference { return r.Object }) {
+		if rels.check(object, req.Verb, subject, req.Subject.Relation, 0) {
+			ids = append(ids, object.ID)
+		}
+	}
+
+	if len(ids) == 0 {
+		return cazi.ListObjectsResponse{
+			Decision:         cazi.DecisionDeny,
+			ConsistencyToken: revisionToken(rev),
+		}, nil
+	}
+	return cazi.ListObjectsResponse{
+		Decision:         cazi.DecisionConditional,
+		Condition:        idsExpression(req.ObjectType, ids),
+		ConsistencyToken: revisionToken(rev),
+	}, nil
+}
+
+// ListSubjects implements cazi.SubjectLister.
+// The complete set of subjects is always returned (DecisionAllow).
+func (s *Store) ListSubjects(ctx context.Context, req cazi.ListSubjectsRequest) (cazi.ListSubjectsResponse, error) {
+	object, ok := req.Object.Assertion.(cazi.ResourceReference)
+	if !ok {
+		return cazi.ListSubjectsResponse{Decision: cazi.DecisionDeny}, fmt.Errorf("%w: object must be a ResourceReference", cazi.ErrUnsupportedAssertion)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rels, rev, err := s.snapshot(cazi.Consistency{}, req.AtLeastAsFresh)
+	if err != nil {
+		return cazi.ListSubjectsResponse{Decision: cazi.DecisionDeny}, err
+	}
+
+	var subjects []cazi.ResourceReference
+	for _, subject := range rels.resources(req.SubjectType, func(r cazi.Relationship) cazi.ResourceReference { return r.Subject }) {
+		if rels.check(object, req.Verb, subject, req.SubjectRelation, 0) {
+			subjects = append(subjects, subject)
+		}
+	}
+
+	return cazi.ListSubjectsResponse{
+		Decision:         cazi.DecisionAllow,
+		Subjects:         subjects,
+		ConsistencyToken: revisionToken(rev),
+	}, nil
+}
+
+// WriteRelationships implements cazi.RelationshipWriter.
+func (s *Store) WriteRelationships(ctx context.Context, req cazi.WriteRelationshipsRequest) (cazi.WriteRelationshipsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range req.Preconditions {
+		matched := s.relationships.anyMatch(p.Filter)
+		if (p.Operation == cazi.PreconditionMustMatch) != matched {
+			return cazi.WriteRelationshipsResponse{}, fmt.Errorf("%w: %+v", cazi.ErrPreconditionFailed, p)
+		}
+	}
+
+	for _, u := range req.Updates {
+		if u.Operation == cazi.OperationCreate {
+			if _, exists := s.relationships[u.Relationship]; exists {
+				return cazi.WriteRelationshipsResponse{}, fmt.Errorf("%w: %s", cazi.ErrRelationshipExists, u.Relationship)
+			}
+		}
+	}
+
+	for _, u := range req.Updates {
+		switch u.Operation {
+		case cazi.OperationTouch, cazi.OperationCreate, cazi.OperationDelete:
+		default:
+			return cazi.WriteRelationshipsResponse{}, fmt.Errorf("%w: unknown operation %d", cazi.ErrInvalidArgument, u.Operation)
+		}
+	}
+
+	var applied []cazi.RelationshipUpdate
+	for _, u := range req.Updates {
+		_, exists := s.relationships[u.Relationship]
+		switch {
+		case u.Operation == cazi.OperationDelete && exists:
+			delete(s.relationships, u.Relationship)
+			applied = append(applied, cazi.RelationshipUpdate{Operation: cazi.OperationDelete, Relationship: u.Relationship})
+		case u.Operation != cazi.OperationDelete && !exists:
+			s.relationships[u.Relationship] = struct{}{}
+			applied = append(applied, cazi.RelationshipUpdate{Operation: cazi.OperationTouch, Relationship: u.Relationship})
+		}
+	}
+
+	s.revision++
+	token := revisionToken(s.revision)
+	s.changes = append(s.changes, cazi.Change{Updates: applied, ChangedAt: token})
+	close(s.changed)
+	s.changed = make(chan struct{})
+	return cazi.WriteRelationshipsResponse{WrittenAt: token}, nil
+}
+
+// Watch implements cazi.Watcher.
+// Writes that changed no relationships matching the request are skipped.
+func (s *Store) Watch(ctx context.Context, req cazi.WatchRequest, onChange func(ctx context.Context, change cazi.Change) error) error {
+	s.mu.RLock()
+	next := s.revision
+	if len(req.StartAfter) > 0 {
+		if err := s.checkFreshness(req.StartAfter); err != nil {
+			s.mu.RUnlock()
+			return err
+		}
+		next, _ = parseRevision(req.StartAfter)
+	}
+	s.mu.RUnlock()
+
+	for {
+		s.mu.RLock()
+		pending := s.changes[next:]
+		changed := s.changed
+		s.mu.RUnlock()
+
+		for _, change := range pending {
+			next++
+			if change = filterChange(change, req.ObjectTypes); len(change.Updates) == 0 {
+				continue
+			}
+			if err := onChange(ctx, change); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// filterChange returns the updates in change to objects of the given types, or all updates if types is empty.
+func filterChange(change cazi.Change, types []string) cazi.Change {
+	if len(types) == 0 {
+		return change
+	}
+	filtered := cazi.Change{ChangedAt: change.ChangedAt}
+	for _, u := range change.Updates {
+		if slices.Contains(types, u.Relationship.Object.Type) {
+			filtered.Updates = append(filtered.Updates, u)
+		}
+	}
+	return filtered
+}
+
+// relationshipSet is the set of relationships at some revision.
+type relationshipSet map[cazi.Relationship]struct{}
+
+// snapshot returns the relationships and revision a request with the given consistency requirement
+// is evaluated at. Callers must hold s.mu.
+func (s *Store) snapshot(c cazi.Consistency, atLeastAsFresh cazi.ConsistencyToken) (relationshipSet, uint64, error) {
+	c, err := c.Resolve(atLeastAsFresh)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch c.Mode {
+	case cazi.ConsistencyAtLeastAsFresh:
+		if err := s.checkFreshness(c.Token); err != nil {
+			return nil, 0, err
+		}
+	case cazi.ConsistencyAtExactSnapshot:
+		if err := s.checkFreshness(c.Token); err != nil {
+			return nil, 0, err
+		}
+		rev, _ := parseRevision(c.Token)
+		return s.relationshipsAt(rev), rev, nil
+	}
+	// Writes are immediately visible, so the latest revision satisfies every other mode.
+	return s.relationships, s.revision, nil
+}
+
+// relationshipsAt reconstructs the relationships at a past revision by undoing later changes.
+// Callers must hold s.mu.
+func (s *Store) relationshipsAt(rev uint64) relationshipSet {
+	if rev == s.revision {
+		return s.relationships
+	}
+	rels := maps.Clone(s.relationships)
+	for i := len(s.changes) - 1; i >= int(rev); i-- {
+		updates := s.changes[i].Updates
+		for j := len(updates) - 1; j >= 0; j-- {
+			if updates[j].Operation == cazi.OperationDelete {
+				rels[updates[j].Relationship] = struct{}{}
+			} else {
+				delete(rels, updates[j].Relationship)
+			}
+		}
+	}
+	return rels
+}
+
+// check reports whether subject (or subject set subject#subjectRelation) has relation to object.
+func (rels relationshipSet) check(object cazi.ResourceReference, relation string, subject cazi.ResourceReference, subjectRelation string, depth int) bool {
+	if depth > maxDepth {
+		return false
+	}
+
+	direct := cazi.Relationship{Object: object, Relation: relation, Subject: subject, SubjectRelation: subjectRelation}
+	if _, ok := rels[direct]; ok {
+		return true
+	}
+
+	// Expand subject sets, e.g. widget:w1#viewer@group:eng#member
+	for r := range rels {
+		if r.Object != object || r.Relation != relation || r.SubjectRelation == "" {
+			continue
+		}
+		if rels.check(r.Subject, r.SubjectRelation, subject, subjectRelation, depth+1) {
+			return true
+		}
+	}
+	return false
+}
+
+// explain is like check, but also returns the relationships it looked up and the subject sets it expanded,
+// in the order they were evaluated. Subject sets are expanded in sorted order so explanations are stable.
+func (rels relationshipSet) explain(object cazi.ResourceReference, relation string, subject cazi.ResourceReference, subjectRelation string, depth int) (bool, []cazi.Explanation) {
+	if depth > maxDepth {
+		return false, []cazi.Explanation{{Description: fmt.Sprintf("maximum depth %d exceeded", maxDepth), Decision: cazi.DecisionDeny}}
+	}
+
+	direct := cazi.Relationship{Object: object, Relation: relation, Subject: subject, SubjectRelation: subjectRelation}
+	_, ok := rels[direct]
+	steps := []cazi.Explanation{{
+		Description:  "relationship " + direct.String(),
+		Decision:     decisionFor(ok),
+		Relationship: &direct,
+	}}
+	if ok {
+		return true, steps
+	}
+
+	var subjectSets []cazi.Relationship
+	for r := range rels {
+		if r.Object == object && r.Relation == relation && r.SubjectRelation != "" {
+			subjectSets = append(subjectSets, r)
+		}
+	}
+	sort.Slice(subjectSets, func(i, j int) bool { return subjectSets[i].String() < subjectSets[j].String() })
+
+	for _, r := range subjectSets {
+		found, nested := rels.explain(r.Subject, r.SubjectRelation, subject, subjectRelation, depth+1)
+		steps = append(steps, cazi.Explanation{
+			Description:  "subject set " + subjectString(r.Subject, r.SubjectRelation),
+			Decision:     decisionFor(found),
+			Relationship: &r,
+			Steps:        nested,
+		})
+		if found {
+			return true, steps
+		}
+	}
+	return false, steps
+}
+
+// resources returns the distinct resources of a type found in relationships, sorted by id.
+func (rels relationshipSet) resources(resourceType string, from func(cazi.Relationship) cazi.ResourceReference) []cazi.ResourceReference {
+	seen := make(map[cazi.ResourceReference]struct{})
+	for r := range rels {
+		if ref := from(r); ref.Type == resourceType {
+			seen[ref] = struct{}{}
+		}
+	}
+
+	refs := make([]cazi.ResourceReference, 0, len(seen))
+	for ref := range seen {
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].ID < refs[j].ID })
+	return refs
+}
+
+// anyMatch reports whether any relationship matches the filter.
+func (rels relationshipSet) anyMatch(f cazi.RelationshipFilter) bool {
+	for r := range rels {
+		if f.Matches(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkFreshness returns an error wrapping cazi.ErrTokenTooNew if token is from a revision this store has not reached.
+// Callers must hold s.mu.
+func (s *Store) checkFreshness(token cazi.ConsistencyToken) error {
+	if len(token) == 0 {
+		return nil
+	}
+	rev, err := parseRevision(token)
+	if err != nil {
+		return err
+	}
+	if rev > s.revision {
+		return fmt.Errorf("%w: revision %d is newer than store revision %d", cazi.ErrTokenTooNew, rev, s.revision)
+	}
+	return nil
+}
+
+func decisionFor(allowed bool) cazi.DecisionKind {
+	if allowed {
+		return cazi.DecisionAllow
+	}
+	return cazi.DecisionDeny
+}
+
+func resourceString(r cazi.ResourceReference) string {
+	return r.Type + ":" + r.ID
+}
+
+func subjectString(subject cazi.ResourceReference, relation string) string {
+	if relation == "" {
+		return resourceString(subject)
+	}
+	return resourceString(subject) + "#" + relation
+}
+
+func revisionToken(rev uint64) cazi.ConsistencyToken {
+	return consistency.Encode(consistency.Token{Backend: Backend, Revision: rev})
+}
+
+func parseRevision(token cazi.ConsistencyToken) (uint64, error) {
+	t, err := consistency.Decode(token)
+	if err != nil || t.Backend != Backend {
+		return 0, fmt.Errorf("%w: invalid consistency token", cazi.ErrInvalidArgument)
+	}
+	return t.Revision, nil
+}
+
+// idsExpression builds a CEL expression matching objects whose id is one of ids.
+func idsExpression(objectType string, ids []string) cazi.Expression {
+	quoted := make([]string, len(ids))
+	for i, id := range ids {
+		quoted[i] = strconv.Quote(id)
+	}
+	return cazi.Expression{
+		Language:   "cel",
+		Expression: fmt.Sprintf("%s.id in [%s]", objectType, strings.Join(quoted, ", ")),
+	}
+}
@@ -0,0 +1,482 @@
+package memory_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alechenninger/cazi/implementations/memory"
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"github.com/alechenninger/cazi/pkg/claims"
+	"github.com/alechenninger/cazi/pkg/consistency"
+)
+
+func user(id string) cazi.ResourceReference   { return cazi.ResourceReference{Type: "user", ID: id} }
+func widget(id string) cazi.ResourceReference { return cazi.ResourceReference{Type: "widget", ID: id} }
+func group(id string) cazi.ResourceReference  { return cazi.ResourceReference{Type: "group", ID: id} }
+
+func write(t *testing.T, store *memory.Store, updates ...cazi.RelationshipUpdate) cazi.ConsistencyToken {
+	t.Helper()
+	resp, err := store.WriteRelationships(context.Background(), cazi.WriteRelationshipsRequest{Updates: updates})
+	if err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	return resp.WrittenAt
+}
+
+func touch(object cazi.ResourceReference, relation string, subject cazi.ResourceReference, subjectRelation string) cazi.RelationshipUpdate {
+	return cazi.RelationshipUpdate{
+		Operation: cazi.OperationTouch,
+		Relationship: cazi.Relationship{
+			Object: object, Relation: relation, Subject: subject, SubjectRelation: subjectRelation,
+		},
+	}
+}
+
+func check(t *testing.T, store *memory.Store, subject cazi.ResourceReference, verb string, object cazi.ResourceReference, token cazi.ConsistencyToken) cazi.CheckResponse {
+	t.Helper()
+	resp, err := store.Check(context.Background(), cazi.CheckRequest{
+		Subject:        cazi.Subject{Assertion: subject},
+		Verb:           verb,
+		Object:         cazi.Object{Assertion: object},
+		AtLeastAsFresh: token,
+	})
+	if err != nil {
+		t.Fatalf("unexpected check error: %v", err)
+	}
+	return resp
+}
+
+func TestCheck(t *testing.T) {
+	store := memory.New()
+	write(t, store,
+		touch(widget("w1"), "owner", user("alice"), ""),
+		touch(widget("w1"), "viewer", group("eng"), "member"),
+		touch(group("eng"), "member", user("bob"), ""),
+	)
+
+	t.Run("Direct relationship", func(t *testing.T) {
+		resp := check(t, store, user("alice"), "owner", widget("w1"), nil)
+		if resp.Decision != cazi.DecisionAllow {
+			t.Fatalf("expected allow, got %v", resp.Decision)
+		}
+		if sub, _ := claims.Sub.Get(resp.Context.RequesterContext); sub != "alice" {
+			t.Errorf("expected requester sub 'alice', got '%s'", sub)
+		}
+	})
+
+	t.Run("Subject set", func(t *testing.T) {
+		if resp := check(t, store, user("bob"), "viewer", widget("w1"), nil); resp.Decision != cazi.DecisionAllow {
+			t.Errorf("expected allow, got %v", resp.Decision)
+		}
+	})
+
+	t.Run("No relationship", func(t *testing.T) {
+		if resp := check(t, store, user("bob"), "owner", widget("w1"), nil); resp.Decision != cazi.DecisionDeny {
+			t.Errorf("expected deny, got %v", resp.Decision)
+		}
+	})
+
+	t.Run("Unsupported assertion", func(t *testing.T) {
+		_, err := store.Check(context.Background(), cazi.CheckRequest{
+			Subject: cazi.Subject{Assertion: cazi.Claims{"sub": "alice"}},
+			Verb:    "owner",
+			Object:  cazi.Object{Assertion: widget("w1")},
+		})
+		if !errors.Is(err, cazi.ErrUnsupportedAssertion) {
+			t.Errorf("expected cazi.ErrUnsupportedAssertion, got %v", err)
+		}
+	})
+}
+
+func TestExplain(t *testing.T) {
+	store := memory.New()
+	write(t, store,
+		touch(widget("w1"), "viewer", group("ops"), "member"),
+		touch(widget("w1"), "viewer", group("eng"), "member"),
+		touch(group("eng"), "member", user("bob"), ""),
+	)
+
+	explain := func(t *testing.T, subject cazi.ResourceReference) cazi.CheckResponse {
+		t.Helper()
+		resp, err := store.Check(context.Background(), cazi.CheckRequest{
+			Subject: cazi.Subject{Assertion: subject},
+			Verb:    "viewer",
+			Object:  cazi.Object{Assertion: widget("w1")},
+			Explain: true,
+		})
+		if err != nil {
+			t.Fatalf("unexpected check error: %v", err)
+		}
+		if resp.Explanation == nil {
+			t.Fatal("expected explanation")
+		}
+		return resp
+	}
+
+	t.Run("Allow", func(t *testing.T) {
+		resp := explain(t, user("bob"))
+
+		want := `[allow] user:bob viewer widget:w1
+├── [deny] relationship widget:w1#viewer@user:bob
+└── [allow] subject set group:eng#member
+    └── [allow] relationship group:eng#member@user:bob
+`
+		if got := resp.Explanation.String(); got != want {
+			t.Errorf("expected:\n%s\ngot:\n%s", want, got)
+		}
+		if rel := resp.Explanation.Steps[1].Relationship; rel == nil || rel.Subject != group("eng") {
+			t.Errorf("expected subject set relationship, got %+v", rel)
+		}
+	})
+
+	t.Run("Deny", func(t *testing.T) {
+		resp := explain(t, user("carol"))
+
+		want := `[deny] user:carol viewer widget:w1
+├── [deny] relationship widget:w1#viewer@user:carol
+├── [deny] subject set group:eng#member
+│   └── [deny] relationship group:eng#member@user:carol
+└── [deny] subject set group:ops#member
+    └── [deny] relationship group:ops#member@user:carol
+`
+		if got := resp.Explanation.String(); got != want {
+			t.Errorf("expected:\n%s\ngot:\n%s", want, got)
+		}
+	})
+
+	t.Run("Not requested", func(t *testing.T) {
+		if resp := check(t, store, user("bob"), "viewer", widget("w1"), nil); resp.Explanation != nil {
+			t.Errorf("expected no explanation, got %+v", resp.Explanation)
+		}
+	})
+}
+
+func TestWriteRelationships(t *testing.T) {
+	t.Run("Token makes write visible", func(t *testing.T) {
+		store := memory.New()
+		token := write(t, store, touch(widget("w1"), "owner", user("alice"), ""))
+
+		if resp := check(t, store, user("alice"), "owner", widget("w1"), token); resp.Decision != cazi.DecisionAllow {
+			t.Errorf("expected allow, got %v", resp.Decision)
+		}
+	})
+
+	t.Run("Token from the future is rejected", func(t *testing.T) {
+		other := memory.New()
+		write(t, other, touch(widget("w1"), "owner", user("alice"), ""))
+		token := write(t, other, touch(widget("w2"), "owner", user("alice"), ""))
+
+		store := memory.New()
+		_, err := store.Check(context.Background(), cazi.CheckRequest{
+			Subject:        cazi.Subject{Assertion: user("alice")},
+			Verb:           "owner",
+			Object:         cazi.Object{Assertion: widget("w1")},
+			AtLeastAsFresh: token,
+		})
+		if !errors.Is(err, cazi.ErrTokenTooNew) {
+			t.Errorf("expected cazi.ErrTokenTooNew, got %v", err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		store := memory.New()
+		write(t, store, touch(widget("w1"), "owner", user("alice"), ""))
+		token := write(t, store, cazi.RelationshipUpdate{
+			Operation:    cazi.OperationDelete,
+			Relationship: cazi.Relationship{Object: widget("w1"), Relation: "owner", Subject: user("alice")},
+		})
+
+		if resp := check(t, store, user("alice"), "owner", widget("w1"), token); resp.Decision != cazi.DecisionDeny {
+			t.Errorf("expected deny after delete, got %v", resp.Decision)
+		}
+	})
+
+	t.Run("Create existing fails", func(t *testing.T) {
+		store := memory.New()
+		write(t, store, touch(widget("w1"), "owner", user("alice"), ""))
+
+		_, err := store.WriteRelationships(context.Background(), cazi.WriteRelationshipsRequest{
+			Updates: []cazi.RelationshipUpdate{{
+				Operation:    cazi.OperationCreate,
+				Relationship: cazi.Relationship{Object: widget("w1"), Relation: "owner", Subject: user("alice")},
+			}},
+		})
+		if !errors.Is(err, cazi.ErrRelationshipExists) {
+			t.Errorf("expected ErrRelationshipExists, got %v", err)
+		}
+	})
+
+	t.Run("Preconditions", func(t *testing.T) {
+		store := memory.New()
+		write(t, store, touch(widget("w1"), "owner", user("alice"), ""))
+
+		// Transfer ownership only if alice still owns the widget and nobody else does
+		transfer := cazi.WriteRelationshipsRequest{
+			Preconditions: []cazi.Precondition{{
+				Operation: cazi.PreconditionMustMatch,
+				Filter:    cazi.RelationshipFilter{ObjectType: "widget", ObjectID: "w1", Relation: "owner", SubjectID: "alice"},
+			}},
+			Updates: []cazi.RelationshipUpdate{
+				{Operation: cazi.OperationDelete, Relationship: cazi.Relationship{Object: widget("w1"), Relation: "owner", Subject: user("alice")}},
+				{Operation: cazi.OperationCreate, Relationship: cazi.Relationship{Object: widget("w1"), Relation: "owner", Subject: user("bob")}},
+			},
+		}
+		if _, err := store.WriteRelationships(context.Background(), transfer); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// Repeating the transfer fails because alice no longer owns the widget
+		_, err := store.WriteRelationships(context.Background(), transfer)
+		if !errors.Is(err, cazi.ErrPreconditionFailed) {
+			t.Errorf("expected ErrPreconditionFailed, got %v", err)
+		}
+	})
+}
+
+func TestListObjects(t *testing.T) {
+	store := memory.New()
+	write(t, store,
+		touch(widget("w2"), "owner", user("alice"), ""),
+		touch(widget("w1"), "owner", user("alice"), ""),
+		touch(widget("w3"), "owner", user("bob"), ""),
+	)
+
+	resp, err := store.ListObjects(context.Background(), cazi.ListObjectsRequest{
+		Subject:    cazi.Subject{Assertion: user("alice")},
+		Verb:       "owner",
+		ObjectType: "widget",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.Decision != cazi.DecisionConditional {
+		t.Fatalf("expected conditional, got %v", resp.Decision)
+	}
+	want := `widget.id in ["w1", "w2"]`
+	if resp.Condition.Expression != want {
+		t.Errorf("expected %s, got %s", want, resp.Condition.Expression)
+	}
+}
+
+func TestListSubjects(t *testing.T) {
+	store := memory.New()
+	write(t, store,
+		touch(widget("w1"), "viewer", user("alice"), ""),
+		touch(widget("w1"), "viewer", group("eng"), "member"),
+		touch(group("eng"), "member", user("bob"), ""),
+		touch(group("eng"), "member", user("carol"), ""),
+		touch(widget("w2"), "viewer", user("dave"), ""),
+	)
+
+	resp, err := cazi.ListSubjects(context.Background(), store, cazi.ListSubjectsRequest{
+		Object:      cazi.Object{Assertion: widget("w1")},
+		Verb:        "viewer",
+		SubjectType: "user",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []cazi.ResourceReference{user("alice"), user("bob"), user("carol")}
+	if !reflect.DeepEqual(resp.Subjects, want) {
+		t.Errorf("expected %v, got %v", want, resp.Subjects)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	store := memory.New()
+	first := write(t, store, touch(widget("w1"), "owner", user("alice"), ""))
+	write(t, store,
+		touch(widget("w1"), "owner", user("alice"), ""), // already exists: not a change
+		touch(group("eng"), "member", user("bob"), ""),
+	)
+	write(t, store, cazi.RelationshipUpdate{
+		Operation:    cazi.OperationDelete,
+		Relationship: cazi.Relationship{Object: widget("w1"), Relation: "owner", Subject: user("alice")},
+	})
+
+	// watch collects changes until it has n, then stops.
+	watch := func(req cazi.WatchRequest, n int) ([]cazi.Change, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		var changes []cazi.Change
+		stop := errors.New("stop")
+		err := store.Watch(ctx, req, func(ctx context.Context, change cazi.Change) error {
+			changes = append(changes, change)
+			if len(changes) == n {
+				return stop
+			}
+			return nil
+		})
+		if !errors.Is(err, stop) {
+			return changes, fmt.Errorf("expected watch to stop after %d changes, got %w (changes: %v)", n, err, changes)
+		}
+		return changes, nil
+	}
+
+	t.Run("Resumes after token", func(t *testing.T) {
+		changes, err := watch(cazi.WatchRequest{StartAfter: first}, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(changes[0].Updates) != 1 || changes[0].Updates[0].Relationship.Object != group("eng") {
+			t.Errorf("expected only the new group membership, got %v", changes[0].Updates)
+		}
+		if got := changes[1].Updates; len(got) != 1 || got[0].Operation != cazi.OperationDelete {
+			t.Errorf("expected delete, got %v", got)
+		}
+	})
+
+	t.Run("Filters object types", func(t *testing.T) {
+		changes, err := watch(cazi.WatchRequest{ObjectTypes: []string{"widget"}, StartAfter: revision(0)}, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, c := range changes {
+			for _, u := range c.Updates {
+				if u.Relationship.Object.Type != "widget" {
+					t.Errorf("expected only widget changes, got %v", u.Relationship)
+				}
+			}
+		}
+	})
+
+	t.Run("Streams new changes", func(t *testing.T) {
+		current := write(t, store, touch(widget("w2"), "owner", user("carol"), ""))
+
+		type result struct {
+			changes []cazi.Change
+			err     error
+		}
+		done := make(chan result, 1)
+		go func() {
+			changes, err := watch(cazi.WatchRequest{StartAfter: current}, 1)
+			done <- result{changes, err}
+		}()
+
+		written := write(t, store, touch(widget("w3"), "owner", user("carol"), ""))
+
+		r := <-done
+		if r.err != nil {
+			t.Fatal(r.err)
+		}
+		if !bytes.Equal(r.changes[0].ChangedAt, written) {
+			t.Errorf("expected change at %v, got %v", written, r.changes[0].ChangedAt)
+		}
+	})
+
+	t.Run("Token from the future", func(t *testing.T) {
+		err := store.Watch(context.Background(), cazi.WatchRequest{StartAfter: revision(1 << 40)}, func(context.Context, cazi.Change) error {
+			return nil
+		})
+		if !errors.Is(err, cazi.ErrTokenTooNew) {
+			t.Errorf("expected cazi.ErrTokenTooNew, got %v", err)
+		}
+	})
+}
+
+func revision(rev uint64) cazi.ConsistencyToken {
+	return consistency.Encode(consistency.Token{Backend: memory.Backend, Revision: rev})
+}
+
+func TestConsistency(t *testing.T) {
+	store := memory.New()
+	before := write(t, store, touch(widget("w1"), "owner", user("alice"), ""))
+	after := write(t, store,
+		cazi.RelationshipUpdate{
+			Operation:    cazi.OperationDelete,
+			Relationship: cazi.Relationship{Object: widget("w1"), Relation: "owner", Subject: user("alice")},
+		},
+		touch(widget("w1"), "owner", user("bob"), ""),
+	)
+
+	checkAt := func(t *testing.T, c cazi.Consistency, subject cazi.ResourceReference) cazi.CheckResponse {
+		t.Helper()
+		resp, err := store.Check(context.Background(), cazi.CheckRequest{
+			Subject:     cazi.Subject{Assertion: subject},
+			Verb:        "owner",
+			Object:      cazi.Object{Assertion: widget("w1")},
+			Consistency: c,
+		})
+		if err != nil {
+			t.Fatalf("unexpected check error: %v", err)
+		}
+		return resp
+	}
+
+	t.Run("At exact snapshot", func(t *testing.T) {
+		resp := checkAt(t, cazi.AtExactSnapshot(before), user("alice"))
+		if resp.Decision != cazi.DecisionAllow {
+			t.Errorf("expected allow at the earlier snapshot, got %v", resp.Decision)
+		}
+		if !bytes.Equal(resp.ConsistencyToken, before) {
+			t.Errorf("expected token %v, got %v", before, resp.ConsistencyToken)
+		}
+		if resp := checkAt(t, cazi.AtExactSnapshot(before), user("bob")); resp.Decision != cazi.DecisionDeny {
+			t.Errorf("expected deny at the earlier snapshot, got %v", resp.Decision)
+		}
+	})
+
+	t.Run("Modes using the latest snapshot", func(t *testing.T) {
+		for _, c := range []cazi.Consistency{
+			{},
+			cazi.MinimizeLatency(),
+			cazi.FullyConsistent(),
+			cazi.AtLeastAsFresh(before),
+		} {
+			resp := checkAt(t, c, user("bob"))
+			if resp.Decision != cazi.DecisionAllow {
+				t.Errorf("%s: expected allow, got %v", c.Mode, resp.Decision)
+			}
+			if !bytes.Equal(resp.ConsistencyToken, after) {
+				t.Errorf("%s: expected token %v, got %v", c.Mode, after, resp.ConsistencyToken)
+			}
+		}
+	})
+
+	t.Run("List objects at exact snapshot", func(t *testing.T) {
+		resp, err := store.ListObjects(context.Background(), cazi.ListObjectsRequest{
+			Subject:     cazi.Subject{Assertion: user("alice")},
+			Verb:        "owner",
+			ObjectType:  "widget",
+			Consistency: cazi.AtExactSnapshot(before),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.Decision != cazi.DecisionConditional || !bytes.Equal(resp.ConsistencyToken, before) {
+			t.Errorf("expected conditional at %v, got %v at %v", before, resp.Decision, resp.ConsistencyToken)
+		}
+	})
+
+	t.Run("Unsatisfiable", func(t *testing.T) {
+		for _, tt := range []struct {
+			consistency cazi.Consistency
+			err         error
+		}{
+			{cazi.AtExactSnapshot(revision(1 << 40)), cazi.ErrTokenTooNew},
+			{cazi.AtLeastAsFresh(revision(1 << 40)), cazi.ErrTokenTooNew},
+			{cazi.Consistency{Mode: cazi.ConsistencyAtExactSnapshot}, cazi.ErrInvalidArgument},
+			{cazi.AtLeastAsFresh(cazi.ConsistencyToken("bogus")), cazi.ErrInvalidArgument},
+		} {
+			_, err := store.Check(context.Background(), cazi.CheckRequest{
+				Subject:     cazi.Subject{Assertion: user("bob")},
+				Verb:        "owner",
+				Object:      cazi.Object{Assertion: widget("w1")},
+				Consistency: tt.consistency,
+			})
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s: expected %v, got %v", tt.consistency.Mode, tt.err, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,185 @@
+// Package accurate implements [cazi.AccurateStore] with optimistic concurrency across the
+// application's store and the authorization store.
+//
+// A write runs in an application transaction that locks the application's copy of the attribute:
+//
+//  1. The application's copy must have the version the write is based on.
+//  2. The authorization store's copy is replaced with compare-and-swap on the same version.
+//  3. The application's copy is saved with the new version, and the transaction commits.
+//
+// If another writer got there first, step 1 or 2 fails with [cazi.ErrVersionConflict]
+// instead of overwriting its change.
+// If the transaction fails after step 2, the authorization store is ahead of the application;
+// the next Read sees the authorization store's version is after the application's and copies it back,
+// so the write is applied rather than lost, and writes based on the old version still conflict.
+package accurate
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+)
+
+// Replica is the authorization store's copy of versioned attributes.
+type Replica[T any] interface {
+	// Load returns an attribute, or a zero Versioned if it doesn't exist.
+	Load(ctx context.Context, key string) (cazi.Versioned[T], error)
+
+	// CompareAndSwap replaces an attribute if its current version equals expected,
+	// returning the token at which the new copy is visible.
+	// Returns an error wrapping [cazi.ErrVersionConflict] if the current version is different.
+	CompareAndSwap(ctx context.Context, key string, expected cazi.Version, data cazi.Versioned[T]) (cazi.ConsistencyToken, error)
+}
+
+// Store implements cazi.AccurateStore.
+type Store[T any] struct {
+	writer string
+	authz  Replica[T]
+
+	mu       sync.Mutex
+	protocol *cazi.AccurateReplication[T]
+}
+
+var _ cazi.AccurateStore[any] = (*Store[any])(nil)
+
+// New creates a Store replicating to authz.
+// writer identifies this Store's writes in versions, and must be unique among concurrent writers
+// (e.g. a hostname or instance ID).
+func New[T any](writer string, authz Replica[T]) *Store[T] {
+	return &Store[T]{writer: writer, authz: authz}
+}
+
+// WithAccurateReplication implements cazi.AccurateStore.
+func (s *Store[T]) WithAccurateReplication(protocol cazi.AccurateReplication[T]) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.protocol = &protocol
+}
+
+// Read implements cazi.AccurateStore.
+func (s *Store[T]) Read(ctx context.Context, key string) (cazi.Versioned[T], error) {
+	protocol, err := s.replication()
+	if err != nil {
+		return cazi.Versioned[T]{}, err
+	}
+
+	var result cazi.Versioned[T]
+	err = protocol.Commit(ctx, func(ctx context.Context) error {
+		local, err := protocol.Load(ctx, key)
+		if err != nil {
+			return err
+		}
+		remote, err := s.authz.Load(ctx, key)
+		if err != nil {
+			return fmt.Errorf("failed to load %q from authorization store: %w", key, err)
+		}
+
+		switch order := local.Version.Compare(remote.Version); order {
+		case cazi.VersionEqual:
+			result = local
+			result.WrittenAt = remote.WrittenAt
+		case cazi.VersionBefore:
+			// A write reached the authorization store but its application transaction failed.
+			result = remote
+			return protocol.Save(ctx, key, remote)
+		case cazi.VersionAfter:
+			// Only possible if the application's copy was saved outside of this Store.
+			token, err := s.authz.CompareAndSwap(ctx, key, remote.Version, local)
+			if err != nil {
+				return fmt.Errorf("failed to replicate %q to authorization store: %w", key, err)
+			}
+			result = local
+			result.WrittenAt = token
+		default:
+			return fmt.Errorf("%w: %q has concurrent versions %v and %v", cazi.ErrVersionConflict, key, local.Version, remote.Version)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// Write implements cazi.AccurateStore.
+//
+// If Write returns an error other than a conflict, the write may still have reached the
+// authorization store; it is then applied to the application's store by the next Read.
+func (s *Store[T]) Write(ctx context.Context, key string, data cazi.Versioned[T]) (cazi.Versioned[T], error) {
+	protocol, err := s.replication()
+	if err != nil {
+		return cazi.Versioned[T]{}, err
+	}
+
+	var result cazi.Versioned[T]
+	err = protocol.Commit(ctx, func(ctx context.Context) error {
+		local, err := protocol.Load(ctx, key)
+		if err != nil {
+			return err
+		}
+		if local.Version.Compare(data.Version) != cazi.VersionEqual {
+			return fmt.Errorf("%w: %q is at version %v, not %v", cazi.ErrVersionConflict, key, local.Version, data.Version)
+		}
+
+		next := cazi.Versioned[T]{Version: data.Version.Increment(s.writer), Data: data.Data}
+		token, err := s.authz.CompareAndSwap(ctx, key, data.Version, next)
+		if err != nil {
+			return fmt.Errorf("failed to replicate %q to authorization store: %w", key, err)
+		}
+		next.WrittenAt = token
+
+		if err := protocol.Save(ctx, key, next); err != nil {
+			return err
+		}
+		result = next
+		return nil
+	})
+	return result, err
+}
+
+func (s *Store[T]) replication() (*cazi.AccurateReplication[T], error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.protocol == nil {
+		return nil, errors.New("accurate: no replication protocol configured (see WithAccurateReplication)")
+	}
+	return s.protocol, nil
+}
+
+// MemoryReplica is an in-memory Replica, for tests and local development.
+// Its consistency tokens are its revision, incremented on every write.
+type MemoryReplica[T any] struct {
+	mu         sync.RWMutex
+	revision   uint64
+	attributes map[string]cazi.Versioned[T]
+}
+
+// NewMemoryReplica creates an empty MemoryReplica.
+func NewMemoryReplica[T any]() *MemoryReplica[T] {
+	return &MemoryReplica[T]{attributes: make(map[string]cazi.Versioned[T])}
+}
+
+// Load implements Replica.
+func (r *MemoryReplica[T]) Load(ctx context.Context, key string) (cazi.Versioned[T], error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.attributes[key], nil
+}
+
+// CompareAndSwap implements Replica.
+func (r *MemoryReplica[T]) CompareAndSwap(ctx context.Context, key string, expected cazi.Version, data cazi.Versioned[T]) (cazi.ConsistencyToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current := r.attributes[key]
+	if current.Version.Compare(expected) != cazi.VersionEqual {
+		return nil, fmt.Errorf("%w: %q is at version %v, not %v", cazi.ErrVersionConflict, key, current.Version, expected)
+	}
+
+	r.revision++
+	token := binary.BigEndian.AppendUint64(nil, r.revision)
+	data.WrittenAt = token
+	r.attributes[key] = data
+	return token, nil
+}
@@ -0,0 +1,214 @@
+package accurate_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/alechenninger/cazi/pkg/accurate"
+	"github.com/alechenninger/cazi/pkg/cazi"
+)
+
+type stagedKey struct{}
+
+// appStore stands in for an application database. Commit holds a lock for the whole transaction,
+// as a row lock would, and applies saves only if the transaction succeeds.
+type appStore struct {
+	mu         sync.Mutex
+	rows       map[string]cazi.Versioned[string]
+	failCommit bool
+}
+
+func newAppStore() *appStore {
+	return &appStore{rows: make(map[string]cazi.Versioned[string])}
+}
+
+func (a *appStore) replication() cazi.AccurateReplication[string] {
+	return cazi.AccurateReplication[string]{
+		Commit: func(ctx context.Context, fn func(ctx context.Context) error) error {
+			a.mu.Lock()
+			defer a.mu.Unlock()
+
+			staged := make(map[string]cazi.Versioned[string])
+			if err := fn(context.WithValue(ctx, stagedKey{}, staged)); err != nil {
+				return err
+			}
+			if a.failCommit {
+				return errors.New("commit failed")
+			}
+			for k, v := range staged {
+				a.rows[k] = v
+			}
+			return nil
+		},
+		Load: func(ctx context.Context, key string) (cazi.Versioned[string], error) {
+			if v, ok := ctx.Value(stagedKey{}).(map[string]cazi.Versioned[string])[key]; ok {
+				return v, nil
+			}
+			return a.rows[key], nil
+		},
+		Save: func(ctx context.Context, key string, data cazi.Versioned[string]) error {
+			ctx.Value(stagedKey{}).(map[string]cazi.Versioned[string])[key] = data
+			return nil
+		},
+	}
+}
+
+func newStore(writer string, app *appStore, authz accurate.Replica[string]) *accurate.Store[string] {
+	store := accurate.New(writer, authz)
+	store.WithAccurateReplication(app.replication())
+	return store
+}
+
+func TestWriteAndRead(t *testing.T) {
+	ctx := context.Background()
+	app := newAppStore()
+	authz := accurate.NewMemoryReplica[string]()
+	store := newStore("app-1", app, authz)
+
+	written, err := store.Write(ctx, "widget:w1#owner", cazi.Versioned[string]{Data: "alice"})
+	if err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	if len(written.WrittenAt) == 0 {
+		t.Error("expected WrittenAt token")
+	}
+
+	remote, _ := authz.Load(ctx, "widget:w1#owner")
+	if remote.Data != "alice" {
+		t.Errorf("expected authorization store owner 'alice', got '%s'", remote.Data)
+	}
+
+	read, err := store.Read(ctx, "widget:w1#owner")
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	if read.Data != "alice" {
+		t.Errorf("expected owner 'alice', got '%s'", read.Data)
+	}
+	if read.Version.Compare(written.Version) != cazi.VersionEqual {
+		t.Errorf("expected version %v, got %v", written.Version, read.Version)
+	}
+}
+
+// Two transactions transfer ownership of the same widget based on the same read.
+// With FastStore, both writes succeed and the last one silently replaces the first.
+// Here the second fails, so it can re-read and decide what to do.
+func TestConcurrentWritesConflict(t *testing.T) {
+	ctx := context.Background()
+	app := newAppStore()
+	authz := accurate.NewMemoryReplica[string]()
+	first := newStore("app-1", app, authz)
+	second := newStore("app-2", app, authz)
+
+	initial, err := first.Write(ctx, "widget:w1#owner", cazi.Versioned[string]{Data: "alice"})
+	if err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+
+	if _, err := first.Write(ctx, "widget:w1#owner", cazi.Versioned[string]{Version: initial.Version, Data: "bob"}); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+
+	_, err = second.Write(ctx, "widget:w1#owner", cazi.Versioned[string]{Version: initial.Version, Data: "carol"})
+	if !errors.Is(err, cazi.ErrVersionConflict) {
+		t.Fatalf("expected version conflict, got %v", err)
+	}
+
+	current, err := second.Read(ctx, "widget:w1#owner")
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	if current.Data != "bob" {
+		t.Errorf("expected owner 'bob', got '%s'", current.Data)
+	}
+
+	t.Run("Retry after re-reading succeeds", func(t *testing.T) {
+		retried, err := second.Write(ctx, "widget:w1#owner", cazi.Versioned[string]{Version: current.Version, Data: "carol"})
+		if err != nil {
+			t.Fatalf("unexpected write error: %v", err)
+		}
+		remote, _ := authz.Load(ctx, "widget:w1#owner")
+		if remote.Data != "carol" || remote.Version.Compare(retried.Version) != cazi.VersionEqual {
+			t.Errorf("expected authorization store to have 'carol' at %v, got '%s' at %v", retried.Version, remote.Data, remote.Version)
+		}
+	})
+}
+
+func TestConcurrentWritersOnlyOneWins(t *testing.T) {
+	ctx := context.Background()
+	app := newAppStore()
+	authz := accurate.NewMemoryReplica[string]()
+
+	writers := []string{"app-1", "app-2", "app-3", "app-4"}
+	errs := make([]error, len(writers))
+	var wg sync.WaitGroup
+	for i, writer := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = newStore(writer, app, authz).Write(ctx, "widget:w1#owner", cazi.Versioned[string]{Data: writer})
+		}()
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, cazi.ErrVersionConflict):
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("expected exactly 1 write to succeed, got %d", succeeded)
+	}
+}
+
+// If the application transaction fails after the authorization store was written,
+// the stores disagree. Read repairs the application's copy rather than letting the
+// stale copy be replicated back over the newer one.
+func TestReadRepairsFailedCommit(t *testing.T) {
+	ctx := context.Background()
+	app := newAppStore()
+	authz := accurate.NewMemoryReplica[string]()
+	store := newStore("app-1", app, authz)
+
+	initial, err := store.Write(ctx, "widget:w1#owner", cazi.Versioned[string]{Data: "alice"})
+	if err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+
+	app.failCommit = true
+	if _, err := store.Write(ctx, "widget:w1#owner", cazi.Versioned[string]{Version: initial.Version, Data: "bob"}); err == nil {
+		t.Fatal("expected commit error")
+	}
+	app.failCommit = false
+
+	t.Run("Stale write conflicts", func(t *testing.T) {
+		_, err := store.Write(ctx, "widget:w1#owner", cazi.Versioned[string]{Version: initial.Version, Data: "carol"})
+		if !errors.Is(err, cazi.ErrVersionConflict) {
+			t.Errorf("expected version conflict, got %v", err)
+		}
+	})
+
+	read, err := store.Read(ctx, "widget:w1#owner")
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	if read.Data != "bob" {
+		t.Errorf("expected owner 'bob', got '%s'", read.Data)
+	}
+	if got := app.rows["widget:w1#owner"]; got.Data != "bob" {
+		t.Errorf("expected application store owner 'bob', got '%s'", got.Data)
+	}
+}
+
+func TestWriteRequiresReplication(t *testing.T) {
+	store := accurate.New[string]("app-1", accurate.NewMemoryReplica[string]())
+	if _, err := store.Write(context.Background(), "k", cazi.Versioned[string]{}); err == nil {
+		t.Error("expected error without a replication protocol")
+	}
+}
@@ -0,0 +1,144 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultBufferSize is the default number of records an AsyncSink buffers.
+const DefaultBufferSize = 1024
+
+var (
+	// ErrBufferFull is returned by an AsyncSink with OverflowDrop when its buffer is full.
+	ErrBufferFull = errors.New("audit buffer full")
+
+	// ErrSinkClosed is returned when writing to a closed AsyncSink.
+	ErrSinkClosed = errors.New("audit sink closed")
+)
+
+// OverflowPolicy decides what an AsyncSink does with a record when its buffer is full.
+type OverflowPolicy int
+
+const (
+	OverflowBlock OverflowPolicy = iota // wait for space, until the write's context is done
+	OverflowDrop                        // drop the record and return ErrBufferFull
+)
+
+// AsyncOptions configure an AsyncSink.
+type AsyncOptions struct {
+	// BufferSize is the number of records buffered before the overflow policy applies.
+	// Defaults to DefaultBufferSize.
+	BufferSize int
+
+	// Overflow decides what happens when the buffer is full. Defaults to OverflowBlock,
+	// which applies backpressure to callers rather than losing records.
+	Overflow OverflowPolicy
+
+	// OnError is called with errors from the wrapped sink, which the caller of Write has no way to see.
+	// Defaults to ignoring them.
+	OnError func(ctx context.Context, err error)
+}
+
+// AsyncSink buffers records and writes them to another sink in the background,
+// so slow sinks don't add to authorization latency.
+// Records are written in the order they were buffered.
+type AsyncSink struct {
+	next Sink
+	opts AsyncOptions
+
+	mu      sync.RWMutex // held for reading while buffering, and for writing to close
+	closed  bool
+	records chan asyncRecord
+	done    chan struct{}
+
+	dropped atomic.Uint64
+}
+
+type asyncRecord struct {
+	ctx    context.Context
+	record Record
+}
+
+var _ Sink = (*AsyncSink)(nil)
+
+// NewAsyncSink starts writing buffered records to next. Call Close to flush and stop.
+func NewAsyncSink(next Sink, opts AsyncOptions) *AsyncSink {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = DefaultBufferSize
+	}
+	if opts.OnError == nil {
+		opts.OnError = func(context.Context, error) {}
+	}
+
+	s := &AsyncSink{
+		next:    next,
+		opts:    opts,
+		records: make(chan asyncRecord, opts.BufferSize),
+		done:    make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Write implements Sink by buffering the record.
+// The record is written to the wrapped sink with ctx's values, but not its cancellation.
+func (s *AsyncSink) Write(ctx context.Context, record Record) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return ErrSinkClosed
+	}
+
+	r := asyncRecord{ctx: context.WithoutCancel(ctx), record: record}
+	if s.opts.Overflow == OverflowDrop {
+		select {
+		case s.records <- r:
+			return nil
+		default:
+			s.dropped.Add(1)
+			return ErrBufferFull
+		}
+	}
+
+	select {
+	case s.records <- r:
+		return nil
+	case <-ctx.Done():
+		s.dropped.Add(1)
+		return ctx.Err()
+	}
+}
+
+// Dropped returns the number of records that were not buffered because the buffer was full.
+func (s *AsyncSink) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close stops accepting records and waits for buffered records to be written,
+// or until ctx is done.
+func (s *AsyncSink) Close(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.records)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *AsyncSink) run() {
+	defer close(s.done)
+	for r := range s.records {
+		if err := s.next.Write(r.ctx, r.record); err != nil {
+			s.opts.OnError(r.ctx, err)
+		}
+	}
+}
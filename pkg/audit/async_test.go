@@ -0,0 +1,135 @@
+package audit_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/alechenninger/cazi/pkg/audit"
+)
+
+// blockingSink blocks each write until release is closed.
+type blockingSink struct {
+	memorySink
+	release chan struct{}
+}
+
+func (s *blockingSink) Write(ctx context.Context, record audit.Record) error {
+	<-s.release
+	return s.memorySink.Write(ctx, record)
+}
+
+func TestAsyncSink(t *testing.T) {
+	next := &memorySink{}
+	sink := audit.NewAsyncSink(next, audit.AsyncOptions{BufferSize: 4})
+
+	for i := range 100 {
+		if err := sink.Write(context.Background(), audit.Record{Verb: fmt.Sprint(i)}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := sink.Close(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	records := next.all()
+	if len(records) != 100 {
+		t.Fatalf("expected 100 records, got %d", len(records))
+	}
+	for i, r := range records {
+		if r.Verb != fmt.Sprint(i) {
+			t.Fatalf("expected records in order, got %s at %d", r.Verb, i)
+		}
+	}
+
+	t.Run("Writes after close fail", func(t *testing.T) {
+		if err := sink.Write(context.Background(), audit.Record{}); !errors.Is(err, audit.ErrSinkClosed) {
+			t.Errorf("expected audit.ErrSinkClosed, got %v", err)
+		}
+	})
+}
+
+func TestAsyncSinkOverflow(t *testing.T) {
+	t.Run("Block applies backpressure until the context is done", func(t *testing.T) {
+		next := &blockingSink{release: make(chan struct{})}
+		sink := audit.NewAsyncSink(next, audit.AsyncOptions{BufferSize: 1})
+
+		// One record is held by the blocked sink and one fills the buffer.
+		for range 2 {
+			if err := sink.Write(context.Background(), audit.Record{}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := sink.Write(ctx, audit.Record{}); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+		if dropped := sink.Dropped(); dropped != 1 {
+			t.Errorf("expected 1 dropped record, got %d", dropped)
+		}
+
+		close(next.release)
+		if err := sink.Close(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := len(next.all()); got != 2 {
+			t.Errorf("expected 2 records, got %d", got)
+		}
+	})
+
+	t.Run("Drop returns ErrBufferFull", func(t *testing.T) {
+		next := &blockingSink{release: make(chan struct{})}
+		sink := audit.NewAsyncSink(next, audit.AsyncOptions{BufferSize: 1, Overflow: audit.OverflowDrop})
+
+		var err error
+		for i := 0; i < 3 && err == nil; i++ {
+			err = sink.Write(context.Background(), audit.Record{})
+		}
+		if !errors.Is(err, audit.ErrBufferFull) {
+			t.Errorf("expected audit.ErrBufferFull, got %v", err)
+		}
+		if dropped := sink.Dropped(); dropped != 1 {
+			t.Errorf("expected 1 dropped record, got %d", dropped)
+		}
+
+		close(next.release)
+		if err := sink.Close(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Close gives up when its context is done", func(t *testing.T) {
+		next := &blockingSink{release: make(chan struct{})}
+		defer close(next.release)
+		sink := audit.NewAsyncSink(next, audit.AsyncOptions{})
+		if err := sink.Write(context.Background(), audit.Record{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := sink.Close(ctx); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	})
+
+	t.Run("Sink errors are reported", func(t *testing.T) {
+		sinkErr := errors.New("disk full")
+		reported := make(chan error, 1)
+		sink := audit.NewAsyncSink(&memorySink{err: sinkErr}, audit.AsyncOptions{
+			OnError: func(ctx context.Context, err error) { reported <- err },
+		})
+		if err := sink.Write(context.Background(), audit.Record{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := sink.Close(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := <-reported; !errors.Is(err, sinkErr) {
+			t.Errorf("expected %v, got %v", sinkErr, err)
+		}
+	})
+}
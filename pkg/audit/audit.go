@@ -0,0 +1,207 @@
+// Package audit records authorization decisions for compliance.
+//
+// [Middleware] wraps a cazi.Interface and writes a [Record] of every Check and ListObjects call
+// to a [Sink]: a structured logger ([SlogSink]), a rotating JSON-lines file ([FileSink]),
+// or any sink behind an asynchronous buffer ([AsyncSink]).
+//
+// Claims and opaque token payloads often carry personal data or credentials,
+// so they are redacted from records unless allowed by [Redaction].
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+)
+
+// Operations recorded in Record.Operation.
+const (
+	OperationCheck       = "check"
+	OperationListObjects = "list_objects"
+)
+
+// Redacted replaces redacted claim values.
+const Redacted = "[REDACTED]"
+
+// Record is an audit record of a single authorization decision.
+type Record struct {
+	Time             time.Time             `json:"time"`                        // when the call started
+	Operation        string                `json:"operation"`                   // OperationCheck or OperationListObjects
+	Subject          cazi.Subject          `json:"subject"`                     // redacted subject
+	Verb             string                `json:"verb"`                        // verb/relation
+	Object           *cazi.Object          `json:"object,omitempty"`            // redacted object, for checks
+	ObjectType       string                `json:"object_type,omitempty"`       // listed object type, for list objects
+	Decision         cazi.DecisionKind     `json:"decision"`                    // the decision; DecisionUnknown if the call failed
+	Condition        cazi.Expression       `json:"condition,omitzero"`          // condition of a conditional decision
+	ConsistencyToken cazi.ConsistencyToken `json:"consistency_token,omitempty"` // snapshot the decision was evaluated at
+	Latency          time.Duration         `json:"latency_ns"`                  // how long the call took
+	Error            string                `json:"error,omitempty"`             // error returned by the call, if any
+}
+
+// Sink writes audit records.
+// Sinks must be safe for concurrent use.
+type Sink interface {
+	Write(ctx context.Context, record Record) error
+}
+
+// SinkFunc adapts a function to a Sink.
+type SinkFunc func(ctx context.Context, record Record) error
+
+// Write implements Sink.
+func (f SinkFunc) Write(ctx context.Context, record Record) error {
+	return f(ctx, record)
+}
+
+// Multi returns a Sink that writes each record to every sink, returning their joined errors.
+func Multi(sinks ...Sink) Sink {
+	return SinkFunc(func(ctx context.Context, record Record) error {
+		var errs []error
+		for _, sink := range sinks {
+			if err := sink.Write(ctx, record); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	})
+}
+
+// Redaction configures which sensitive assertion data is recorded.
+// The zero value redacts every claim value and opaque token payload.
+type Redaction struct {
+	// AllowClaims lists the top-level claims whose values are recorded, e.g. "sub" or "iss".
+	// Other claims are recorded with the value [Redacted], so records still show which claims were asserted.
+	AllowClaims []string
+
+	// AllowRawTokens records the payload of opaque tokens. Otherwise, only their type is recorded.
+	AllowRawTokens bool
+}
+
+// Options configure auditing.
+type Options struct {
+	// Redaction configures which claims and token payloads are recorded.
+	Redaction Redaction
+
+	// FailClosed returns an error from calls whose record could not be written,
+	// so no decision is acted on without an audit trail.
+	// Otherwise, sink errors are only reported to OnError.
+	FailClosed bool
+
+	// OnError is called with errors writing records. Defaults to ignoring them.
+	OnError func(ctx context.Context, err error)
+
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// Middleware returns cazi.Middleware that writes a record of every Check and ListObjects call to sink.
+// Other operations are passed through.
+func Middleware(sink Sink, opts Options) cazi.Middleware {
+	if opts.OnError == nil {
+		opts.OnError = func(context.Context, error) {}
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	a := &auditor{sink: sink, opts: opts}
+
+	return cazi.Intercept(cazi.Hooks{
+		Check: func(ctx context.Context, req cazi.CheckRequest, next cazi.CheckFunc) (cazi.CheckResponse, error) {
+			start := opts.Now()
+			resp, err := next(ctx, req)
+
+			object := cazi.Object{Assertion: opts.Redaction.assertion(req.Object.Assertion)}
+			record := Record{
+				Time:             start,
+				Operation:        OperationCheck,
+				Subject:          opts.Redaction.subject(req.Subject),
+				Verb:             req.Verb,
+				Object:           &object,
+				Decision:         resp.Decision,
+				Condition:        resp.Condition,
+				ConsistencyToken: resp.ConsistencyToken,
+			}
+			if err = a.write(ctx, record, start, err); err != nil {
+				return cazi.CheckResponse{}, err
+			}
+			return resp, nil
+		},
+		ListObjects: func(ctx context.Context, req cazi.ListObjectsRequest, next cazi.ListObjectsFunc) (cazi.ListObjectsResponse, error) {
+			start := opts.Now()
+			resp, err := next(ctx, req)
+
+			record := Record{
+				Time:             start,
+				Operation:        OperationListObjects,
+				Subject:          opts.Redaction.subject(req.Subject),
+				Verb:             req.Verb,
+				ObjectType:       req.ObjectType,
+				Decision:         resp.Decision,
+				Condition:        resp.Condition,
+				ConsistencyToken: resp.ConsistencyToken,
+			}
+			if err = a.write(ctx, record, start, err); err != nil {
+				return cazi.ListObjectsResponse{}, err
+			}
+			return resp, nil
+		},
+	})
+}
+
+type auditor struct {
+	sink Sink
+	opts Options
+}
+
+// write completes and writes record for a call that started at start and returned callErr.
+// It returns the error the call should return.
+func (a *auditor) write(ctx context.Context, record Record, start time.Time, callErr error) error {
+	record.Latency = a.opts.Now().Sub(start)
+	if callErr != nil {
+		record.Decision = cazi.DecisionUnknown
+		record.Condition = cazi.Expression{}
+		record.ConsistencyToken = nil
+		record.Error = callErr.Error()
+	}
+
+	if err := a.sink.Write(ctx, record); err != nil {
+		err = fmt.Errorf("failed to write audit record: %w", err)
+		a.opts.OnError(ctx, err)
+		if a.opts.FailClosed && callErr == nil {
+			return err
+		}
+	}
+	return callErr
+}
+
+func (r Redaction) subject(s cazi.Subject) cazi.Subject {
+	s.Assertion = r.assertion(s.Assertion)
+	return s
+}
+
+// assertion returns a redacted copy of a.
+func (r Redaction) assertion(a cazi.Assertion) cazi.Assertion {
+	switch a := a.(type) {
+	case cazi.Claims:
+		redacted := make(cazi.Claims, len(a))
+		for k, v := range a {
+			redacted[k] = Redacted
+			for _, allowed := range r.AllowClaims {
+				if k == allowed {
+					redacted[k] = v
+					break
+				}
+			}
+		}
+		return redacted
+	case cazi.OpaqueToken:
+		if r.AllowRawTokens {
+			return a
+		}
+		return cazi.OpaqueToken{Type: a.Type}
+	default:
+		return a
+	}
+}
@@ -0,0 +1,260 @@
+package audit_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alechenninger/cazi/pkg/audit"
+	"github.com/alechenninger/cazi/pkg/cazi"
+)
+
+// stubAuthz is a cazi.Interface returning canned responses.
+type stubAuthz struct {
+	checkResp cazi.CheckResponse
+	listResp  cazi.ListObjectsResponse
+	err       error
+}
+
+func (s *stubAuthz) Check(ctx context.Context, req cazi.CheckRequest) (cazi.CheckResponse, error) {
+	return s.checkResp, s.err
+}
+
+func (s *stubAuthz) ListObjects(ctx context.Context, req cazi.ListObjectsRequest) (cazi.ListObjectsResponse, error) {
+	return s.listResp, s.err
+}
+
+// memorySink collects records.
+type memorySink struct {
+	mu      sync.Mutex
+	records []audit.Record
+	err     error
+}
+
+func (s *memorySink) Write(ctx context.Context, record audit.Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.records = append(s.records, record)
+	return nil
+}
+
+func (s *memorySink) all() []audit.Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]audit.Record(nil), s.records...)
+}
+
+// clock advances by a second each time it is read.
+func clock() func() time.Time {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	return func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+}
+
+func checkRequest() cazi.CheckRequest {
+	return cazi.CheckRequest{
+		Subject: cazi.Subject{Assertion: cazi.Claims{"sub": "alice", "email": "alice@example.com"}},
+		Verb:    "read",
+		Object:  cazi.Object{Assertion: cazi.ResourceReference{Type: "widget", ID: "w1"}},
+	}
+}
+
+func TestCheck(t *testing.T) {
+	sink := &memorySink{}
+	authz := audit.Middleware(sink, audit.Options{Now: clock()})(&stubAuthz{checkResp: cazi.CheckResponse{
+		Decision:         cazi.DecisionConditional,
+		Condition:        cazi.Expression{Language: "cel", Expression: "widget.owner_id == 'alice'"},
+		ConsistencyToken: cazi.ConsistencyToken("rev-1"),
+	}})
+
+	resp, err := authz.Check(context.Background(), checkRequest())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Decision != cazi.DecisionConditional {
+		t.Errorf("expected conditional response, got %v", resp.Decision)
+	}
+
+	records := sink.all()
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+	r := records[0]
+	if r.Operation != audit.OperationCheck || r.Verb != "read" || r.Decision != cazi.DecisionConditional {
+		t.Errorf("expected check/read/conditional, got %s/%s/%v", r.Operation, r.Verb, r.Decision)
+	}
+	if r.Object == nil || r.Object.Assertion != (cazi.ResourceReference{Type: "widget", ID: "w1"}) {
+		t.Errorf("expected object widget:w1, got %+v", r.Object)
+	}
+	if r.Condition.Expression != "widget.owner_id == 'alice'" || string(r.ConsistencyToken) != "rev-1" {
+		t.Errorf("expected condition and token, got %+v", r)
+	}
+	if r.Latency != time.Second {
+		t.Errorf("expected latency 1s, got %v", r.Latency)
+	}
+	if r.Error != "" {
+		t.Errorf("expected no error, got %q", r.Error)
+	}
+}
+
+func TestListObjects(t *testing.T) {
+	sink := &memorySink{}
+	authz := audit.Middleware(sink, audit.Options{})(&stubAuthz{listResp: cazi.ListObjectsResponse{Decision: cazi.DecisionAllow}})
+
+	if _, err := authz.ListObjects(context.Background(), cazi.ListObjectsRequest{
+		Subject:    cazi.Subject{Assertion: cazi.ResourceReference{Type: "user", ID: "alice"}},
+		Verb:       "read",
+		ObjectType: "widget",
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	records := sink.all()
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+	if r := records[0]; r.Operation != audit.OperationListObjects || r.ObjectType != "widget" || r.Object != nil || r.Decision != cazi.DecisionAllow {
+		t.Errorf("expected list objects record for widget, got %+v", r)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	t.Run("Call errors are recorded", func(t *testing.T) {
+		sink := &memorySink{}
+		authz := audit.Middleware(sink, audit.Options{})(&stubAuthz{err: errors.New("backend down")})
+
+		if _, err := authz.Check(context.Background(), checkRequest()); err == nil {
+			t.Fatal("expected error")
+		}
+		if r := sink.all()[0]; r.Error != "backend down" || r.Decision != cazi.DecisionUnknown {
+			t.Errorf("expected error record, got %+v", r)
+		}
+	})
+
+	t.Run("Sink errors are reported", func(t *testing.T) {
+		sinkErr := errors.New("disk full")
+		var reported error
+		authz := audit.Middleware(&memorySink{err: sinkErr}, audit.Options{
+			OnError: func(ctx context.Context, err error) { reported = err },
+		})(&stubAuthz{checkResp: cazi.CheckResponse{Decision: cazi.DecisionAllow}})
+
+		resp, err := authz.Check(context.Background(), checkRequest())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.Decision != cazi.DecisionAllow {
+			t.Errorf("expected allow, got %v", resp.Decision)
+		}
+		if !errors.Is(reported, sinkErr) {
+			t.Errorf("expected %v, got %v", sinkErr, reported)
+		}
+	})
+
+	t.Run("Fail closed", func(t *testing.T) {
+		sinkErr := errors.New("disk full")
+		authz := audit.Middleware(&memorySink{err: sinkErr}, audit.Options{FailClosed: true})(
+			&stubAuthz{checkResp: cazi.CheckResponse{Decision: cazi.DecisionAllow}})
+
+		resp, err := authz.Check(context.Background(), checkRequest())
+		if !errors.Is(err, sinkErr) {
+			t.Errorf("expected %v, got %v", sinkErr, err)
+		}
+		if resp.Decision != cazi.DecisionUnknown {
+			t.Errorf("expected no decision, got %v", resp.Decision)
+		}
+	})
+}
+
+func TestRedaction(t *testing.T) {
+	req := checkRequest()
+	req.Object.Assertion = cazi.OpaqueToken{Type: "jwt", Raw: []byte("secret")}
+
+	t.Run("Redacts by default", func(t *testing.T) {
+		sink := &memorySink{}
+		authz := audit.Middleware(sink, audit.Options{})(&stubAuthz{})
+		if _, err := authz.Check(context.Background(), req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		r := sink.all()[0]
+		claims := r.Subject.Assertion.(cazi.Claims)
+		if claims["sub"] != audit.Redacted || claims["email"] != audit.Redacted {
+			t.Errorf("expected redacted claims, got %v", claims)
+		}
+		if token := r.Object.Assertion.(cazi.OpaqueToken); token.Type != "jwt" || token.Raw != nil {
+			t.Errorf("expected token type without payload, got %+v", token)
+		}
+		if req.Subject.Assertion.(cazi.Claims)["sub"] != "alice" {
+			t.Error("expected request claims to be unchanged")
+		}
+	})
+
+	t.Run("Allowed claims and tokens are recorded", func(t *testing.T) {
+		sink := &memorySink{}
+		authz := audit.Middleware(sink, audit.Options{
+			Redaction: audit.Redaction{AllowClaims: []string{"sub"}, AllowRawTokens: true},
+		})(&stubAuthz{})
+		if _, err := authz.Check(context.Background(), req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		r := sink.all()[0]
+		claims := r.Subject.Assertion.(cazi.Claims)
+		if claims["sub"] != "alice" || claims["email"] != audit.Redacted {
+			t.Errorf("expected only sub to be recorded, got %v", claims)
+		}
+		if token := r.Object.Assertion.(cazi.OpaqueToken); string(token.Raw) != "secret" {
+			t.Errorf("expected token payload, got %+v", token)
+		}
+	})
+}
+
+func TestSlogSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := &audit.SlogSink{Logger: slog.New(slog.NewJSONHandler(&buf, nil))}
+	authz := audit.Middleware(sink, audit.Options{})(&stubAuthz{checkResp: cazi.CheckResponse{
+		Decision:  cazi.DecisionConditional,
+		Condition: cazi.Expression{Language: "cel", Expression: "true"},
+	}})
+
+	if _, err := authz.Check(context.Background(), checkRequest()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("failed to decode log entry %q: %v", buf.String(), err)
+	}
+	if entry["msg"] != "authorization decision" || entry["object"] != "widget:w1" || entry["decision"] != "conditional" {
+		t.Errorf("expected decision entry, got %v", entry)
+	}
+	if subject, _ := entry["subject"].(string); strings.Contains(subject, "alice") {
+		t.Errorf("expected redacted subject, got %q", subject)
+	}
+	if condition, _ := entry["condition"].(map[string]any); condition["language"] != "cel" {
+		t.Errorf("expected condition group, got %v", entry["condition"])
+	}
+}
+
+func TestMulti(t *testing.T) {
+	a, b := &memorySink{}, &memorySink{err: errors.New("b failed")}
+	err := audit.Multi(a, b).Write(context.Background(), audit.Record{Verb: "read"})
+
+	if err == nil || err.Error() != "b failed" {
+		t.Errorf("expected b's error, got %v", err)
+	}
+	if len(a.all()) != 1 {
+		t.Errorf("expected record in a, got %d", len(a.all()))
+	}
+}
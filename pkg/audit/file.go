@@ -0,0 +1,147 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// Default file sink options.
+const (
+	DefaultMaxBytes   = 100 << 20
+	DefaultMaxBackups = 5
+)
+
+// FileOptions configure a FileSink.
+type FileOptions struct {
+	// Path is the file records are appended to. Required.
+	Path string
+
+	// MaxBytes is the size after which the file is rotated. Defaults to DefaultMaxBytes.
+	MaxBytes int64
+
+	// MaxBackups is the number of rotated files to keep, named Path.1 (the newest) to Path.MaxBackups.
+	// Defaults to DefaultMaxBackups.
+	MaxBackups int
+}
+
+// FileSink appends records to a file as JSON lines, rotating it when it grows past a size limit.
+type FileSink struct {
+	opts FileOptions
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+var _ Sink = (*FileSink)(nil)
+
+// NewFileSink opens (or creates) the file at opts.Path for appending.
+func NewFileSink(opts FileOptions) (*FileSink, error) {
+	if opts.Path == "" {
+		return nil, errors.New("file sink requires a path")
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultMaxBytes
+	}
+	if opts.MaxBackups <= 0 {
+		opts.MaxBackups = DefaultMaxBackups
+	}
+
+	s := &FileSink{opts: opts}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Write implements Sink. The record is written as a single line.
+// If the line would take the file past MaxBytes, the file is rotated first.
+func (s *FileSink) Write(ctx context.Context, record Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit record: %w", err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return fs.ErrClosed
+	}
+
+	if s.size > 0 && s.size+int64(len(line)) > s.opts.MaxBytes {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+	return nil
+}
+
+// Close closes the file. Later writes fail with fs.ErrClosed.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+func (s *FileSink) open() error {
+	f, err := os.OpenFile(s.opts.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to stat audit file: %w", err)
+	}
+	s.file, s.size = f, info.Size()
+	return nil
+}
+
+// rotate shifts Path.N to Path.N+1, dropping the oldest, moves the current file to Path.1,
+// and opens a new file. If rotation fails, the current file is reopened so later writes can retry.
+func (s *FileSink) rotate() error {
+	err := s.file.Close()
+	s.file = nil
+	if err != nil {
+		err = fmt.Errorf("failed to close audit file: %w", err)
+	} else {
+		err = s.shift()
+	}
+	if openErr := s.open(); openErr != nil {
+		return errors.Join(err, openErr)
+	}
+	return err
+}
+
+func (s *FileSink) shift() error {
+	for i := s.opts.MaxBackups - 1; i >= 1; i-- {
+		err := os.Rename(s.backup(i), s.backup(i+1))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to rotate audit file: %w", err)
+		}
+	}
+	if err := os.Rename(s.opts.Path, s.backup(1)); err != nil {
+		return fmt.Errorf("failed to rotate audit file: %w", err)
+	}
+	return nil
+}
+
+func (s *FileSink) backup(n int) string {
+	return fmt.Sprintf("%s.%d", s.opts.Path, n)
+}
@@ -0,0 +1,124 @@
+package audit_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alechenninger/cazi/pkg/audit"
+	"github.com/alechenninger/cazi/pkg/cazi"
+)
+
+// readRecords decodes the JSON lines in a file.
+func readRecords(t *testing.T, path string) []audit.Record {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", path, err)
+	}
+	defer f.Close()
+
+	var records []audit.Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r audit.Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatalf("failed to decode %q: %v", scanner.Text(), err)
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := audit.NewFileSink(audit.FileOptions{Path: path})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	authz := audit.Middleware(sink, audit.Options{})(&stubAuthz{checkResp: cazi.CheckResponse{Decision: cazi.DecisionAllow}})
+	for range 3 {
+		if _, err := authz.Check(context.Background(), checkRequest()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	records := readRecords(t, path)
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(records))
+	}
+	r := records[0]
+	if r.Decision != cazi.DecisionAllow || r.Object.Assertion != (cazi.ResourceReference{Type: "widget", ID: "w1"}) {
+		t.Errorf("expected allow for widget:w1, got %+v", r)
+	}
+	if claims := r.Subject.Assertion.(cazi.Claims); claims["sub"] != audit.Redacted {
+		t.Errorf("expected redacted claims, got %v", claims)
+	}
+
+	t.Run("Writes after close fail", func(t *testing.T) {
+		if err := sink.Write(context.Background(), audit.Record{}); !errors.Is(err, fs.ErrClosed) {
+			t.Errorf("expected fs.ErrClosed, got %v", err)
+		}
+	})
+
+	t.Run("Reopening appends", func(t *testing.T) {
+		sink, err := audit.NewFileSink(audit.FileOptions{Path: path})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer sink.Close()
+		if err := sink.Write(context.Background(), audit.Record{Verb: "edit"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if records := readRecords(t, path); len(records) != 4 || records[3].Verb != "edit" {
+			t.Errorf("expected 4 records ending with edit, got %+v", records)
+		}
+	})
+}
+
+func TestFileSinkRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	line, _ := json.Marshal(audit.Record{Verb: "v0"})
+
+	// Room for two records per file.
+	sink, err := audit.NewFileSink(audit.FileOptions{Path: path, MaxBytes: int64(2 * (len(line) + 1)), MaxBackups: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer sink.Close()
+
+	for i := range 7 {
+		if err := sink.Write(context.Background(), audit.Record{Verb: "v" + string(rune('0'+i))}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	verbs := func(path string) []string {
+		var verbs []string
+		for _, r := range readRecords(t, path) {
+			verbs = append(verbs, r.Verb)
+		}
+		return verbs
+	}
+	for file, want := range map[string][]string{
+		path:        {"v6"},
+		path + ".1": {"v4", "v5"},
+		path + ".2": {"v2", "v3"},
+	} {
+		if got := verbs(file); len(got) != len(want) || got[0] != want[0] {
+			t.Errorf("%s: expected %v, got %v", filepath.Base(file), want, got)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected oldest backup to be dropped, got %v", err)
+	}
+}
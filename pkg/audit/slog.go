@@ -0,0 +1,69 @@
+package audit
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"github.com/alechenninger/cazi/pkg/consistency"
+)
+
+// SlogSink writes records to a structured logger, one log entry per record.
+type SlogSink struct {
+	Logger *slog.Logger // defaults to slog.Default()
+	Level  slog.Level   // level of the log entries; defaults to slog.LevelInfo
+}
+
+var _ Sink = (*SlogSink)(nil)
+
+// Write implements Sink.
+func (s *SlogSink) Write(ctx context.Context, record Record) error {
+	logger := s.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	attrs := []slog.Attr{
+		slog.Time("started_at", record.Time),
+		slog.String("operation", record.Operation),
+		slog.String("subject", assertionString(record.Subject.Assertion)),
+		slog.String("verb", record.Verb),
+	}
+	if record.Subject.Relation != "" {
+		attrs = append(attrs, slog.String("subject_relation", record.Subject.Relation))
+	}
+	if record.Object != nil {
+		attrs = append(attrs, slog.String("object", assertionString(record.Object.Assertion)))
+	}
+	if record.ObjectType != "" {
+		attrs = append(attrs, slog.String("object_type", record.ObjectType))
+	}
+	attrs = append(attrs, slog.String("decision", record.Decision.String()))
+	if record.Condition.Language != "" {
+		attrs = append(attrs, slog.Group("condition",
+			slog.String("language", record.Condition.Language),
+			slog.String("expression", record.Condition.Expression)))
+	}
+	if len(record.ConsistencyToken) > 0 {
+		attrs = append(attrs, slog.String("consistency_token", consistency.Format(record.ConsistencyToken)))
+	}
+	attrs = append(attrs, slog.Duration("latency", record.Latency))
+	if record.Error != "" {
+		attrs = append(attrs, slog.String("error", record.Error))
+	}
+
+	logger.LogAttrs(ctx, s.Level, "authorization decision", attrs...)
+	return nil
+}
+
+// assertionString formats a resource reference as "type:id", and other assertions as JSON.
+func assertionString(a cazi.Assertion) string {
+	if ref, ok := a.(cazi.ResourceReference); ok {
+		return ref.Type + ":" + ref.ID
+	}
+	data, err := cazi.MarshalAssertion(a)
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
@@ -0,0 +1,314 @@
+// Package authzen adapts cazi.Interface to the OpenID AuthZEN Authorization API 1.0.
+//
+// See: https://openid.net/specs/authorization-api-1_0.html
+//
+// # Mapping
+//
+// Subjects and objects map to AuthZEN subject and resource entities:
+//
+//   - A cazi.ResourceReference{Type, ID} is an entity {"type": Type, "id": ID} without properties.
+//   - cazi.Claims are an entity with properties. The entity type and id are taken from the
+//     "type" and "id" claims, which must be strings ("sub" is used when a subject has no "id" claim);
+//     all other claims become properties, and the [ClaimsProperty] marker is added.
+//     Decoding an entity that has the marker or any other properties yields Claims containing the
+//     properties plus "type" and "id", so claims with an "id" claim round trip.
+//     Claims without one come back with "id" set to their "sub" claim.
+//   - cazi.OpaqueToken has no AuthZEN equivalent and is rejected.
+//
+// The verb is the action name. A subject relation, if any, is sent as the "subject_relation" action property.
+//
+// AuthZEN decisions are booleans, so the remaining parts of a cazi response are carried in the
+// response context:
+//
+//   - DecisionAllow is {"decision": true}. DecisionDeny is {"decision": false}.
+//   - DecisionConditional is {"decision": false, "context": {"condition": {"language": ..., "expression": ...}}}.
+//     A caller that does not understand conditions therefore fails closed.
+//   - AuthorizationContext is carried as "requester_context" and "transaction_context".
+//   - Consistency tokens are carried as base64 "consistency_token" in the response context
+//     and "at_least_as_fresh" in the request context. A consistency requirement is carried as
+//     "consistency" in the request context, in its canonical JSON form (e.g. {"mode": "fully_consistent"}).
+package authzen
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+)
+
+// Paths of the AuthZEN endpoints, relative to the PDP base URL.
+const (
+	EvaluationPath     = "/access/v1/evaluation"
+	EvaluationsPath    = "/access/v1/evaluations"
+	ResourceSearchPath = "/access/v1/search/resource"
+)
+
+// Evaluations semantics, sent as options.evaluations_semantic.
+const (
+	ExecuteAll          = "execute_all"
+	DenyOnFirstDeny     = "deny_on_first_deny"
+	PermitOnFirstPermit = "permit_on_first_permit"
+)
+
+// Entity is an AuthZEN subject or resource.
+type Entity struct {
+	Type       string         `json:"type"`
+	ID         string         `json:"id,omitempty"`
+	Properties map[string]any `json:"properties,omitempty"`
+}
+
+// Action is an AuthZEN action.
+type Action struct {
+	Name       string         `json:"name"`
+	Properties map[string]any `json:"properties,omitempty"`
+}
+
+// EvaluationRequest is the body of an access evaluation request.
+// In an evaluations request, unset fields default to those of the enclosing request.
+type EvaluationRequest struct {
+	Subject  *Entity        `json:"subject,omitempty"`
+	Action   *Action        `json:"action,omitempty"`
+	Resource *Entity        `json:"resource,omitempty"`
+	Context  map[string]any `json:"context,omitempty"`
+}
+
+// EvaluationResponse is the body of an access evaluation response.
+type EvaluationResponse struct {
+	Decision bool           `json:"decision"`
+	Context  map[string]any `json:"context,omitempty"`
+}
+
+// EvaluationsRequest is the body of an access evaluations (batch) request.
+type EvaluationsRequest struct {
+	EvaluationRequest
+	Evaluations []EvaluationRequest `json:"evaluations,omitempty"`
+	Options     *Options            `json:"options,omitempty"`
+}
+
+// Options are the options of an evaluations request.
+type Options struct {
+	EvaluationsSemantic string `json:"evaluations_semantic,omitempty"`
+}
+
+// EvaluationsResponse is the body of an access evaluations response, in request order.
+type EvaluationsResponse struct {
+	Evaluations []EvaluationResponse `json:"evaluations"`
+}
+
+// ResourceSearchRequest is the body of a resource search request.
+type ResourceSearchRequest struct {
+	Subject  *Entity        `json:"subject"`
+	Action   *Action        `json:"action"`
+	Resource *Entity        `json:"resource"`
+	Context  map[string]any `json:"context,omitempty"`
+	Page     *Page          `json:"page,omitempty"`
+}
+
+// ResourceSearchResponse is the body of a resource search response.
+type ResourceSearchResponse struct {
+	Results []Entity `json:"results"`
+	Page    *Page    `json:"page,omitempty"`
+}
+
+// Page carries search pagination tokens.
+type Page struct {
+	NextToken string `json:"next_token,omitempty"`
+}
+
+// actionPropertySubjectRelation is the action property carrying cazi.Subject.Relation.
+const actionPropertySubjectRelation = "subject_relation"
+
+// ClaimsProperty is the entity property marking an entity converted from cazi.Claims,
+// so claims with only "type" and "id" aren't decoded as a cazi.ResourceReference.
+// Claims may not use it as a claim name.
+const ClaimsProperty = "cazi_claims"
+
+// responseContext is the typed form of the cazi keys in a response context.
+type responseContext struct {
+	Condition          *cazi.Expression      `json:"condition,omitempty"`
+	ConsistencyToken   cazi.ConsistencyToken `json:"consistency_token,omitempty"`
+	RequesterContext   cazi.Claims           `json:"requester_context,omitempty"`
+	TransactionContext cazi.Claims           `json:"transaction_context,omitempty"`
+}
+
+// requestContext is the typed form of the cazi keys in a request context.
+type requestContext struct {
+	AtLeastAsFresh cazi.ConsistencyToken `json:"at_least_as_fresh,omitempty"`
+	Consistency    cazi.Consistency      `json:"consistency,omitzero"`
+}
+
+// EntityFromAssertion converts a subject or object assertion to an AuthZEN entity.
+func EntityFromAssertion(a cazi.Assertion) (Entity, error) {
+	switch a := a.(type) {
+	case cazi.ResourceReference:
+		return Entity{Type: a.Type, ID: a.ID}, nil
+	case cazi.Claims:
+		e := Entity{Properties: make(map[string]any, len(a))}
+		for k, v := range a {
+			switch k {
+			case "type", "id":
+				s, ok := v.(string)
+				if !ok {
+					return Entity{}, fmt.Errorf("claim %q must be a string, got %T", k, v)
+				}
+				if k == "type" {
+					e.Type = s
+				} else {
+					e.ID = s
+				}
+			case ClaimsProperty:
+				return Entity{}, fmt.Errorf("claim %q is reserved", k)
+			default:
+				e.Properties[k] = v
+			}
+		}
+		e.Properties[ClaimsProperty] = true
+		if e.ID == "" {
+			e.ID, _ = a["sub"].(string)
+		}
+		if e.Type == "" {
+			return Entity{}, fmt.Errorf("claims must include a string \"type\" claim to be represented as an AuthZEN entity")
+		}
+		return e, nil
+	case nil:
+		return Entity{}, fmt.Errorf("missing assertion")
+	default:
+		return Entity{}, fmt.Errorf("assertion type %T cannot be represented as an AuthZEN entity", a)
+	}
+}
+
+// AssertionFromEntity converts an AuthZEN entity to an assertion.
+// Entities without properties become a ResourceReference; otherwise Claims, without the [ClaimsProperty] marker.
+func AssertionFromEntity(e Entity) cazi.Assertion {
+	if len(e.Properties) == 0 {
+		return cazi.ResourceReference{Type: e.Type, ID: e.ID}
+	}
+	c := make(cazi.Claims, len(e.Properties)+2)
+	for k, v := range e.Properties {
+		if k != ClaimsProperty {
+			c[k] = v
+		}
+	}
+	c["type"] = e.Type
+	c["id"] = e.ID
+	return c
+}
+
+// EvaluationFromCheckRequest converts a cazi.CheckRequest to an AuthZEN evaluation request.
+func EvaluationFromCheckRequest(req cazi.CheckRequest) (EvaluationRequest, error) {
+	subject, err := EntityFromAssertion(req.Subject.Assertion)
+	if err != nil {
+		return EvaluationRequest{}, fmt.Errorf("subject: %w", err)
+	}
+	resource, err := EntityFromAssertion(req.Object.Assertion)
+	if err != nil {
+		return EvaluationRequest{}, fmt.Errorf("object: %w", err)
+	}
+	reqCtx, err := toMap(requestContext{AtLeastAsFresh: req.AtLeastAsFresh, Consistency: req.Consistency})
+	if err != nil {
+		return EvaluationRequest{}, err
+	}
+	return EvaluationRequest{
+		Subject:  &subject,
+		Action:   actionFor(req.Verb, req.Subject.Relation),
+		Resource: &resource,
+		Context:  reqCtx,
+	}, nil
+}
+
+// CheckRequestFromEvaluation converts an AuthZEN evaluation request to a cazi.CheckRequest.
+// Context keys other than "at_least_as_fresh" and "consistency" are ignored, since cazi requests do not carry context.
+func CheckRequestFromEvaluation(req EvaluationRequest) (cazi.CheckRequest, error) {
+	if req.Subject == nil || req.Action == nil || req.Resource == nil {
+		return cazi.CheckRequest{}, fmt.Errorf("subject, action and resource are required")
+	}
+	var reqCtx requestContext
+	if err := fromMap(req.Context, &reqCtx); err != nil {
+		return cazi.CheckRequest{}, fmt.Errorf("context: %w", err)
+	}
+	relation, _ := req.Action.Properties[actionPropertySubjectRelation].(string)
+	return cazi.CheckRequest{
+		Subject:        cazi.Subject{Assertion: AssertionFromEntity(*req.Subject), Relation: relation},
+		Verb:           req.Action.Name,
+		Object:         cazi.Object{Assertion: AssertionFromEntity(*req.Resource)},
+		AtLeastAsFresh: reqCtx.AtLeastAsFresh,
+		Consistency:    reqCtx.Consistency,
+	}, nil
+}
+
+// EvaluationFromCheckResponse converts a cazi.CheckResponse to an AuthZEN evaluation response.
+func EvaluationFromCheckResponse(resp cazi.CheckResponse) (EvaluationResponse, error) {
+	respCtx := responseContext{
+		ConsistencyToken:   resp.ConsistencyToken,
+		RequesterContext:   resp.Context.RequesterContext,
+		TransactionContext: resp.Context.TransactionContext,
+	}
+	if resp.Decision == cazi.DecisionConditional {
+		respCtx.Condition = &resp.Condition
+	}
+	m, err := toMap(respCtx)
+	if err != nil {
+		return EvaluationResponse{}, err
+	}
+	return EvaluationResponse{Decision: resp.Decision == cazi.DecisionAllow, Context: m}, nil
+}
+
+// CheckResponseFromEvaluation converts an AuthZEN evaluation response to a cazi.CheckResponse.
+func CheckResponseFromEvaluation(resp EvaluationResponse) (cazi.CheckResponse, error) {
+	var respCtx responseContext
+	if err := fromMap(resp.Context, &respCtx); err != nil {
+		return cazi.CheckResponse{}, fmt.Errorf("context: %w", err)
+	}
+	out := cazi.CheckResponse{
+		Decision: cazi.DecisionDeny,
+		Context: cazi.AuthorizationContext{
+			RequesterContext:   respCtx.RequesterContext,
+			TransactionContext: respCtx.TransactionContext,
+		},
+		ConsistencyToken: respCtx.ConsistencyToken,
+	}
+	switch {
+	case resp.Decision:
+		out.Decision = cazi.DecisionAllow
+	case respCtx.Condition != nil:
+		out.Decision = cazi.DecisionConditional
+		out.Condition = *respCtx.Condition
+	}
+	return out, nil
+}
+
+func actionFor(verb, relation string) *Action {
+	action := &Action{Name: verb}
+	if relation != "" {
+		action.Properties = map[string]any{actionPropertySubjectRelation: relation}
+	}
+	return action
+}
+
+// toMap converts a struct to a JSON object map, returning nil if it has no fields set.
+func toMap(v any) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	if len(m) == 0 {
+		return nil, nil
+	}
+	return m, nil
+}
+
+// fromMap converts a JSON object map to a struct.
+func fromMap(m map[string]any, v any) error {
+	if len(m) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,434 @@
+package authzen_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alechenninger/cazi/pkg/authzen"
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ownerAuthz allows "alice" to read, returns a condition for "bob", and denies everyone else.
+type ownerAuthz struct {
+	requests []cazi.CheckRequest
+}
+
+func (a *ownerAuthz) Check(ctx context.Context, req cazi.CheckRequest) (cazi.CheckResponse, error) {
+	a.requests = append(a.requests, req)
+	subject, _ := req.Subject.Assertion.(cazi.ResourceReference)
+	switch subject.ID {
+	case "alice":
+		return cazi.CheckResponse{Decision: cazi.DecisionAllow, ConsistencyToken: cazi.ConsistencyToken("rev-1")}, nil
+	case "bob":
+		return cazi.CheckResponse{
+			Decision:  cazi.DecisionConditional,
+			Condition: cazi.Expression{Language: "cel", Expression: "widget.owner_id == 'bob'"},
+			Context:   cazi.AuthorizationContext{RequesterContext: cazi.Claims{"sub": "bob"}},
+		}, nil
+	default:
+		return cazi.CheckResponse{Decision: cazi.DecisionDeny}, nil
+	}
+}
+
+func (a *ownerAuthz) ListObjects(ctx context.Context, req cazi.ListObjectsRequest) (cazi.ListObjectsResponse, error) {
+	return cazi.ListObjectsResponse{Decision: cazi.DecisionDeny}, nil
+}
+
+func post(t *testing.T, url, body string, out any) int {
+	t.Helper()
+	resp, err := http.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK && out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestHandlerEvaluation(t *testing.T) {
+	authz := &ownerAuthz{}
+	srv := httptest.NewServer(authzen.NewHandler(authz))
+	defer srv.Close()
+
+	t.Run("Allow", func(t *testing.T) {
+		var resp authzen.EvaluationResponse
+		code := post(t, srv.URL+authzen.EvaluationPath, `{
+			"subject": {"type": "user", "id": "alice"},
+			"action": {"name": "read"},
+			"resource": {"type": "widget", "id": "w1"}
+		}`, &resp)
+
+		if code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", code)
+		}
+		if !resp.Decision {
+			t.Error("expected decision true")
+		}
+		if resp.Context["consistency_token"] != "cmV2LTE=" {
+			t.Errorf("expected consistency token in context, got %v", resp.Context)
+		}
+		last := authz.requests[len(authz.requests)-1]
+		if last.Object.Assertion != (cazi.ResourceReference{Type: "widget", ID: "w1"}) {
+			t.Errorf("unexpected object: %v", last.Object.Assertion)
+		}
+	})
+
+	t.Run("Conditional is false with condition in context", func(t *testing.T) {
+		var resp authzen.EvaluationResponse
+		post(t, srv.URL+authzen.EvaluationPath, `{
+			"subject": {"type": "user", "id": "bob"},
+			"action": {"name": "read"},
+			"resource": {"type": "widget", "id": "w1"}
+		}`, &resp)
+
+		if resp.Decision {
+			t.Error("expected decision false for conditional")
+		}
+		want := map[string]any{"language": "cel", "expression": "widget.owner_id == 'bob'"}
+		if !reflect.DeepEqual(resp.Context["condition"], want) {
+			t.Errorf("expected condition %v, got %v", want, resp.Context["condition"])
+		}
+	})
+
+	t.Run("Consistency requirement in context", func(t *testing.T) {
+		post(t, srv.URL+authzen.EvaluationPath, `{
+			"subject": {"type": "user", "id": "alice"},
+			"action": {"name": "read"},
+			"resource": {"type": "widget", "id": "w1"},
+			"context": {"consistency": {"mode": "at_exact_snapshot", "token": "cmV2LTE="}}
+		}`, nil)
+
+		last := authz.requests[len(authz.requests)-1]
+		if want := cazi.AtExactSnapshot(cazi.ConsistencyToken("rev-1")); !reflect.DeepEqual(last.Consistency, want) {
+			t.Errorf("expected consistency %+v, got %+v", want, last.Consistency)
+		}
+	})
+
+	t.Run("Missing fields", func(t *testing.T) {
+		code := post(t, srv.URL+authzen.EvaluationPath, `{"subject": {"type": "user", "id": "alice"}}`, nil)
+		if code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", code)
+		}
+	})
+}
+
+func TestHandlerEvaluations(t *testing.T) {
+	srv := httptest.NewServer(authzen.NewHandler(&ownerAuthz{}))
+	defer srv.Close()
+
+	body := func(semantic string) string {
+		return `{
+			"action": {"name": "read"},
+			"resource": {"type": "widget", "id": "w1"},
+			"evaluations": [
+				{"subject": {"type": "user", "id": "alice"}},
+				{"subject": {"type": "user", "id": "eve"}},
+				{"subject": {"type": "user", "id": "alice"}, "resource": {"type": "widget", "id": "w2"}}
+			],
+			"options": {"evaluations_semantic": "` + semantic + `"}
+		}`
+	}
+
+	tests := []struct {
+		semantic string
+		want     []bool
+	}{
+		{authzen.ExecuteAll, []bool{true, false, true}},
+		{authzen.DenyOnFirstDeny, []bool{true, false}},
+		{authzen.PermitOnFirstPermit, []bool{true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.semantic, func(t *testing.T) {
+			var resp authzen.EvaluationsResponse
+			if code := post(t, srv.URL+authzen.EvaluationsPath, body(tt.semantic), &resp); code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", code)
+			}
+
+			var got []bool
+			for _, e := range resp.Evaluations {
+				got = append(got, e.Decision)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected decisions %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestClientCheck(t *testing.T) {
+	srv := httptest.NewServer(authzen.NewHandler(&ownerAuthz{}))
+	defer srv.Close()
+	client := authzen.NewClient(srv.URL, nil)
+
+	check := func(user string) cazi.CheckResponse {
+		t.Helper()
+		resp, err := client.Check(context.Background(), cazi.CheckRequest{
+			Subject: cazi.Subject{Assertion: cazi.ResourceReference{Type: "user", ID: user}},
+			Verb:    "read",
+			Object:  cazi.Object{Assertion: cazi.ResourceReference{Type: "widget", ID: "w1"}},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return resp
+	}
+
+	if resp := check("alice"); resp.Decision != cazi.DecisionAllow || string(resp.ConsistencyToken) != "rev-1" {
+		t.Errorf("expected allow with token, got %+v", resp)
+	}
+
+	resp := check("bob")
+	if resp.Decision != cazi.DecisionConditional {
+		t.Fatalf("expected conditional, got %v", resp.Decision)
+	}
+	if resp.Condition.Expression != "widget.owner_id == 'bob'" {
+		t.Errorf("unexpected condition: %v", resp.Condition)
+	}
+	if resp.Context.RequesterContext["sub"] != "bob" {
+		t.Errorf("unexpected requester context: %v", resp.Context.RequesterContext)
+	}
+
+	if resp := check("eve"); resp.Decision != cazi.DecisionDeny {
+		t.Errorf("expected deny, got %v", resp.Decision)
+	}
+}
+
+// errAuthz fails every request with err.
+type errAuthz struct {
+	err error
+}
+
+func (a *errAuthz) Check(ctx context.Context, req cazi.CheckRequest) (cazi.CheckResponse, error) {
+	return cazi.CheckResponse{}, a.err
+}
+
+func (a *errAuthz) ListObjects(ctx context.Context, req cazi.ListObjectsRequest) (cazi.ListObjectsResponse, error) {
+	return cazi.ListObjectsResponse{}, a.err
+}
+
+func TestClientErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		kind   error
+	}{
+		{"Unsupported", fmt.Errorf("no conditions: %w", errors.ErrUnsupported), http.StatusNotImplemented, errors.ErrUnsupported},
+		{"Unsupported verb", fmt.Errorf("%w: delete", cazi.ErrUnsupportedVerb), http.StatusBadRequest, cazi.ErrInvalidArgument},
+		{"Token too new", fmt.Errorf("%w: revision 2", cazi.ErrTokenTooNew), http.StatusPreconditionFailed, nil},
+		{"Unavailable", fmt.Errorf("%w: database down", cazi.ErrUnavailable), http.StatusServiceUnavailable, cazi.ErrUnavailable},
+		{"Deadline exceeded", context.DeadlineExceeded, http.StatusGatewayTimeout, nil},
+		{"Other", errors.New("boom"), http.StatusInternalServerError, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(authzen.NewHandler(&errAuthz{err: tt.err}))
+			defer srv.Close()
+
+			_, err := authzen.NewClient(srv.URL, nil).Check(context.Background(), cazi.CheckRequest{
+				Subject: cazi.Subject{Assertion: cazi.ResourceReference{Type: "user", ID: "alice"}},
+				Verb:    "read",
+				Object:  cazi.Object{Assertion: cazi.ResourceReference{Type: "widget", ID: "w1"}},
+			})
+
+			var authzenErr *authzen.Error
+			if !errors.As(err, &authzenErr) {
+				t.Fatalf("expected *authzen.Error, got %T: %v", err, err)
+			}
+			if authzenErr.StatusCode != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, authzenErr.StatusCode)
+			}
+			if tt.kind != nil && !errors.Is(err, tt.kind) {
+				t.Errorf("expected %v, got %v", tt.kind, err)
+			}
+		})
+	}
+}
+
+func TestClientListObjects(t *testing.T) {
+	pages := map[string]authzen.ResourceSearchResponse{
+		"": {
+			Results: []authzen.Entity{{Type: "widget", ID: "w1"}, {Type: "widget", ID: "w2"}},
+			Page:    &authzen.Page{NextToken: "p2"},
+		},
+		"p2": {
+			Results: []authzen.Entity{{Type: "widget", ID: "w3"}},
+		},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != authzen.ResourceSearchPath {
+			http.NotFound(w, r)
+			return
+		}
+		var req authzen.ResourceSearchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		token := ""
+		if req.Page != nil {
+			token = req.Page.NextToken
+		}
+		_ = json.NewEncoder(w).Encode(pages[token])
+	}))
+	defer srv.Close()
+
+	resp, err := authzen.NewClient(srv.URL, nil).ListObjects(context.Background(), cazi.ListObjectsRequest{
+		Subject:    cazi.Subject{Assertion: cazi.ResourceReference{Type: "user", ID: "alice"}},
+		Verb:       "read",
+		ObjectType: "widget",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.Decision != cazi.DecisionConditional {
+		t.Errorf("expected conditional, got %v", resp.Decision)
+	}
+	want := `widget.id in ["w1", "w2", "w3"]`
+	if resp.Condition.Expression != want {
+		t.Errorf("expected %s, got %s", want, resp.Condition.Expression)
+	}
+}
+
+func TestEntityMapping(t *testing.T) {
+	t.Run("Claims round trip", func(t *testing.T) {
+		c := cazi.Claims{"type": "user", "id": "alice", "department": "eng"}
+
+		e, err := authzen.EntityFromAssertion(c)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if e.Type != "user" || e.ID != "alice" || e.Properties["department"] != "eng" {
+			t.Errorf("unexpected entity: %+v", e)
+		}
+
+		if got := authzen.AssertionFromEntity(e); !reflect.DeepEqual(got, c) {
+			t.Errorf("expected %v, got %v", c, got)
+		}
+	})
+
+	t.Run("Claims without other properties round trip", func(t *testing.T) {
+		c := cazi.Claims{"type": "user", "id": "alice"}
+
+		e, err := authzen.EntityFromAssertion(c)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := authzen.AssertionFromEntity(e); !reflect.DeepEqual(got, c) {
+			t.Errorf("expected %v, got %v", c, got)
+		}
+	})
+
+	t.Run("Entities without properties are references", func(t *testing.T) {
+		want := cazi.ResourceReference{Type: "user", ID: "alice"}
+		if got := authzen.AssertionFromEntity(authzen.Entity{Type: "user", ID: "alice"}); got != want {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("Subject id falls back to sub", func(t *testing.T) {
+		e, err := authzen.EntityFromAssertion(cazi.Claims{"type": "user", "sub": "alice"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if e.ID != "alice" {
+			t.Errorf("expected id 'alice', got '%s'", e.ID)
+		}
+
+		// Lossy: the decoded claims gain an "id" claim.
+		want := cazi.Claims{"type": "user", "id": "alice", "sub": "alice"}
+		if got := authzen.AssertionFromEntity(e); !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("Claims without type are rejected", func(t *testing.T) {
+		if _, err := authzen.EntityFromAssertion(cazi.Claims{"sub": "alice"}); err == nil {
+			t.Error("expected error for claims without type")
+		}
+	})
+
+	t.Run("Non-string type and id are rejected", func(t *testing.T) {
+		for _, c := range []cazi.Claims{
+			{"type": 1, "id": "alice"},
+			{"type": "user", "id": 1},
+		} {
+			if _, err := authzen.EntityFromAssertion(c); err == nil {
+				t.Errorf("expected error for %v", c)
+			}
+		}
+	})
+
+	t.Run("Marker claim is rejected", func(t *testing.T) {
+		if _, err := authzen.EntityFromAssertion(cazi.Claims{"type": "user", "id": "alice", authzen.ClaimsProperty: true}); err == nil {
+			t.Error("expected error for reserved claim")
+		}
+	})
+
+	t.Run("Opaque tokens are rejected", func(t *testing.T) {
+		if _, err := authzen.EntityFromAssertion(cazi.OpaqueToken{Type: "jwt"}); err == nil {
+			t.Error("expected error for opaque token")
+		}
+	})
+}
+
+// spanAuthz records the span context of the last check.
+type spanAuthz struct {
+	ownerAuthz
+	spanContext trace.SpanContext
+}
+
+func (a *spanAuthz) Check(ctx context.Context, req cazi.CheckRequest) (cazi.CheckResponse, error) {
+	a.spanContext = trace.SpanContextFromContext(ctx)
+	return a.ownerAuthz.Check(ctx, req)
+}
+
+func TestHandlerTraceContext(t *testing.T) {
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+
+	authz := &spanAuthz{}
+	srv := httptest.NewServer(authzen.NewHandler(authz))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+authzen.EvaluationPath, strings.NewReader(`{
+		"subject": {"type": "user", "id": "alice"},
+		"action": {"name": "read"},
+		"resource": {"type": "widget", "id": "w1"}
+	}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req.Header.Set("Traceparent", "00-0102030405060708090a0b0c0d0e0f10-0102030405060708-01")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	want := trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	if got := authz.spanContext; got.TraceID() != want || !got.IsRemote() {
+		t.Errorf("expected remote span context with trace %v, got %v", want, got)
+	}
+}
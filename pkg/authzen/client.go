@@ -0,0 +1,174 @@
+package authzen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+)
+
+// Client implements cazi.Interface against an AuthZEN PDP.
+//
+// Check uses the access evaluation endpoint.
+// ListObjects uses the resource search endpoint, following pagination, and returns the found
+// resources as a conditional CEL expression over the object type (e.g. `widget.id in ["a", "b"]`).
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+var _ cazi.Interface = (*Client)(nil)
+
+// NewClient creates a client for the PDP at baseURL (e.g. "https://pdp.example.com").
+// If httpClient is nil, http.DefaultClient is used.
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+// Check implements cazi.Interface.
+func (c *Client) Check(ctx context.Context, req cazi.CheckRequest) (cazi.CheckResponse, error) {
+	in, err := EvaluationFromCheckRequest(req)
+	if err != nil {
+		return cazi.CheckResponse{}, fmt.Errorf("invalid check request: %w", err)
+	}
+
+	var out EvaluationResponse
+	if err := c.do(ctx, EvaluationPath, in, &out); err != nil {
+		return cazi.CheckResponse{}, err
+	}
+
+	resp, err := CheckResponseFromEvaluation(out)
+	if err != nil {
+		return cazi.CheckResponse{}, fmt.Errorf("invalid evaluation response: %w", err)
+	}
+	return resp, nil
+}
+
+// ListObjects implements cazi.Interface.
+// A filter expression in the request is not supported, since AuthZEN has no equivalent.
+func (c *Client) ListObjects(ctx context.Context, req cazi.ListObjectsRequest) (cazi.ListObjectsResponse, error) {
+	if req.Filter.Expression != "" {
+		return cazi.ListObjectsResponse{}, fmt.Errorf("filter expressions are not supported by AuthZEN resource search")
+	}
+	subject, err := EntityFromAssertion(req.Subject.Assertion)
+	if err != nil {
+		return cazi.ListObjectsResponse{}, fmt.Errorf("invalid list objects request: subject: %w", err)
+	}
+	reqCtx, err := toMap(requestContext{AtLeastAsFresh: req.AtLeastAsFresh, Consistency: req.Consistency})
+	if err != nil {
+		return cazi.ListObjectsResponse{}, err
+	}
+
+	in := ResourceSearchRequest{
+		Subject:  &subject,
+		Action:   actionFor(req.Verb, req.Subject.Relation),
+		Resource: &Entity{Type: req.ObjectType},
+		Context:  reqCtx,
+	}
+
+	var ids []string
+	for {
+		var out ResourceSearchResponse
+		if err := c.do(ctx, ResourceSearchPath, in, &out); err != nil {
+			return cazi.ListObjectsResponse{}, err
+		}
+		for _, r := range out.Results {
+			ids = append(ids, r.ID)
+		}
+		if out.Page == nil || out.Page.NextToken == "" {
+			break
+		}
+		in.Page = &Page{NextToken: out.Page.NextToken}
+	}
+
+	if len(ids) == 0 {
+		return cazi.ListObjectsResponse{Decision: cazi.DecisionDeny}, nil
+	}
+	return cazi.ListObjectsResponse{
+		Decision:  cazi.DecisionConditional,
+		Condition: idsExpression(req.ObjectType, ids),
+	}, nil
+}
+
+// idsExpression builds a CEL expression matching objects whose id is one of ids.
+func idsExpression(objectType string, ids []string) cazi.Expression {
+	quoted := make([]string, len(ids))
+	for i, id := range ids {
+		quoted[i] = strconv.Quote(id)
+	}
+	return cazi.Expression{
+		Language:   "cel",
+		Expression: fmt.Sprintf("%s.id in [%s]", objectType, strings.Join(quoted, ", ")),
+	}
+}
+
+func (c *Client) do(ctx context.Context, path string, in, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(httpResp.Body, maxRequestBytes))
+		return &Error{StatusCode: httpResp.StatusCode, Message: strings.TrimSpace(string(data))}
+	}
+
+	if err := json.NewDecoder(httpResp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// Error is returned by Client when the PDP responds with a non-200 status.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("authzen error: status = %d message = %s", e.StatusCode, e.Message)
+}
+
+// Is reports whether the error matches target.
+func (e *Error) Is(target error) bool {
+	return target == errors.ErrUnsupported && e.StatusCode == http.StatusNotImplemented
+}
+
+// Unwrap returns the kind of cazi error the status represents, where it is unambiguous.
+// AuthZEN responses don't carry a cazi error code, so refined kinds such as cazi.ErrUnsupportedVerb are lost.
+func (e *Error) Unwrap() error {
+	switch e.StatusCode {
+	case http.StatusBadRequest:
+		return cazi.ErrInvalidArgument
+	case http.StatusServiceUnavailable:
+		return cazi.ErrUnavailable
+	default:
+		return nil
+	}
+}
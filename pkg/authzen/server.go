@@ -0,0 +1,166 @@
+package authzen
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxRequestBytes bounds the size of request bodies accepted by the handler.
+const maxRequestBytes = 1 << 20
+
+// Handler serves the AuthZEN access evaluation endpoints on top of any cazi.Interface.
+//
+// Only [EvaluationPath] and [EvaluationsPath] are served. Resource search is not,
+// since cazi.Interface.ListObjects returns a filter expression rather than a list of resources.
+type Handler struct {
+	authz cazi.Interface
+	mux   *http.ServeMux
+}
+
+// NewHandler creates an AuthZEN handler for the given implementation.
+func NewHandler(authz cazi.Interface) *Handler {
+	h := &Handler{authz: authz, mux: http.NewServeMux()}
+	h.mux.HandleFunc("POST "+EvaluationPath, h.evaluation)
+	h.mux.HandleFunc("POST "+EvaluationsPath, h.evaluations)
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+//
+// OpenTelemetry trace context in the request headers is extracted using the global propagator,
+// unless the request context already has a span (e.g. from instrumentation middleware).
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !trace.SpanContextFromContext(r.Context()).IsValid() {
+		r = r.WithContext(otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header)))
+	}
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) evaluation(w http.ResponseWriter, r *http.Request) {
+	var req EvaluationRequest
+	if err := decodeRequest(w, r, &req); err != nil {
+		http.Error(w, "invalid evaluation request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	checkReq, err := CheckRequestFromEvaluation(req)
+	if err != nil {
+		http.Error(w, "invalid evaluation request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.evaluate(r.Context(), checkReq)
+	if err != nil {
+		http.Error(w, err.Error(), statusFor(err))
+		return
+	}
+
+	writeJSON(w, resp)
+}
+
+func (h *Handler) evaluations(w http.ResponseWriter, r *http.Request) {
+	var req EvaluationsRequest
+	if err := decodeRequest(w, r, &req); err != nil {
+		http.Error(w, "invalid evaluations request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Without an evaluations array, the request is a single evaluation
+	items := req.Evaluations
+	if len(items) == 0 {
+		items = []EvaluationRequest{{}}
+	}
+
+	semantic := ExecuteAll
+	if req.Options != nil && req.Options.EvaluationsSemantic != "" {
+		semantic = req.Options.EvaluationsSemantic
+	}
+	if semantic != ExecuteAll && semantic != DenyOnFirstDeny && semantic != PermitOnFirstPermit {
+		http.Error(w, "unsupported evaluations_semantic: "+semantic, http.StatusBadRequest)
+		return
+	}
+
+	out := EvaluationsResponse{Evaluations: make([]EvaluationResponse, 0, len(items))}
+	for _, item := range items {
+		checkReq, err := CheckRequestFromEvaluation(withDefaults(item, req.EvaluationRequest))
+		var resp EvaluationResponse
+		if err == nil {
+			resp, err = h.evaluate(r.Context(), checkReq)
+		}
+		if err != nil {
+			// Per-item errors are reported as a denial with the error in the context
+			resp = EvaluationResponse{Context: map[string]any{
+				"error": map[string]any{"status": statusFor(err), "message": err.Error()},
+			}}
+		}
+		out.Evaluations = append(out.Evaluations, resp)
+
+		if (semantic == DenyOnFirstDeny && !resp.Decision) || (semantic == PermitOnFirstPermit && resp.Decision) {
+			break
+		}
+	}
+
+	writeJSON(w, out)
+}
+
+func (h *Handler) evaluate(ctx context.Context, req cazi.CheckRequest) (EvaluationResponse, error) {
+	resp, err := h.authz.Check(ctx, req)
+	if err != nil {
+		return EvaluationResponse{}, err
+	}
+	return EvaluationFromCheckResponse(resp)
+}
+
+// withDefaults fills unset fields of an evaluation from the enclosing request.
+func withDefaults(item, defaults EvaluationRequest) EvaluationRequest {
+	if item.Subject == nil {
+		item.Subject = defaults.Subject
+	}
+	if item.Action == nil {
+		item.Action = defaults.Action
+	}
+	if item.Resource == nil {
+		item.Resource = defaults.Resource
+	}
+	if item.Context == nil {
+		item.Context = defaults.Context
+	}
+	return item
+}
+
+func decodeRequest(w http.ResponseWriter, r *http.Request, v any) error {
+	return json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(v)
+}
+
+// statusFor maps an error returned by a cazi.Interface implementation to an HTTP status code,
+// the same way cazihttp does.
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, errors.ErrUnsupported):
+		return http.StatusNotImplemented
+	case errors.Is(err, cazi.ErrInvalidArgument):
+		return http.StatusBadRequest
+	case errors.Is(err, cazi.ErrTokenTooNew), errors.Is(err, cazi.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, cazi.ErrRelationshipExists), errors.Is(err, cazi.ErrVersionConflict):
+		return http.StatusConflict
+	case errors.Is(err, cazi.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
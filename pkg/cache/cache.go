@@ -0,0 +1,255 @@
+// Package cache provides a cazi.Interface decorator that caches Check responses.
+//
+// Responses are cached by a hash of the canonical JSON encoding of the subject, verb and object,
+// so equal assertions share an entry regardless of how they were built.
+// Allow, deny and conditional responses are all cached; errors are not.
+// Requests for an explanation (cazi.CheckRequest.Explain) bypass the cache.
+//
+// Cached responses are only used when they satisfy the request's consistency requirement:
+//
+//   - Minimize latency (the default without a token): any unexpired entry.
+//   - At least as fresh: an entry whose token is at least as fresh as the request's.
+//   - At exact snapshot: an entry whose token is exactly the request's.
+//   - Fully consistent: never; the response replaces the entry.
+//
+// Otherwise, the request is sent to the wrapped implementation and its response replaces the entry.
+package cache
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"github.com/alechenninger/cazi/pkg/consistency"
+)
+
+// Default options.
+const (
+	DefaultMaxEntries = 10000
+	DefaultTTL        = time.Minute
+)
+
+// Options configure a Cache.
+type Options struct {
+	// MaxEntries bounds the number of cached responses. The least recently used are evicted first.
+	// Defaults to DefaultMaxEntries.
+	MaxEntries int
+
+	// TTL is how long a response is cached. Defaults to DefaultTTL.
+	TTL time.Duration
+
+	// Compare compares consistency tokens, returning a negative number if a is less fresh than b.
+	// Entries whose freshness cannot be compared are not used for requests with a token.
+	// Defaults to consistency.Compare, which only compares consistency package envelopes;
+	// set it when caching an implementation that issues other tokens.
+	Compare func(a, b cazi.ConsistencyToken) (int, error)
+
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// Stats are cumulative cache statistics.
+type Stats struct {
+	Hits      uint64 // responses served from the cache
+	Misses    uint64 // requests with no usable entry, including stale ones
+	Stale     uint64 // misses where an entry existed but did not satisfy the consistency requirement
+	Evictions uint64 // entries removed to make room for new ones
+	Entries   int    // current number of entries
+}
+
+// Cache is a cazi.Interface that caches Check responses from another implementation.
+// ListObjects is passed through uncached.
+//
+// Cached responses are shared between callers, who must not modify them.
+type Cache struct {
+	next cazi.Interface
+	opts Options
+
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte]*list.Element
+	lru     *list.List // front is most recently used
+	stats   Stats
+}
+
+type entry struct {
+	key       [sha256.Size]byte
+	resp      cazi.CheckResponse
+	expiresAt time.Time
+}
+
+var _ cazi.Interface = (*Cache)(nil)
+
+// New creates a Cache in front of next.
+func New(next cazi.Interface, opts Options) *Cache {
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = DefaultMaxEntries
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultTTL
+	}
+	if opts.Compare == nil {
+		opts.Compare = consistency.Compare
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Cache{
+		next:    next,
+		opts:    opts,
+		entries: make(map[[sha256.Size]byte]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// Middleware returns cazi.Middleware that caches Check responses with opts.
+// The optional interfaces of the wrapped implementation are preserved and passed through uncached.
+// Batches are cached item by item unless the wrapped implementation is a cazi.BatchChecker.
+func Middleware(opts Options) cazi.Middleware {
+	return func(next cazi.Interface) cazi.Interface {
+		c := New(next, opts)
+		return cazi.Intercept(cazi.Hooks{
+			Check: func(ctx context.Context, req cazi.CheckRequest, _ cazi.CheckFunc) (cazi.CheckResponse, error) {
+				return c.Check(ctx, req)
+			},
+		})(next)
+	}
+}
+
+// Check implements cazi.Interface.
+func (c *Cache) Check(ctx context.Context, req cazi.CheckRequest) (cazi.CheckResponse, error) {
+	if req.Explain {
+		// Explanations describe a fresh evaluation, and are too large to cache.
+		return c.next.Check(ctx, req)
+	}
+	required, err := req.Consistency.Resolve(req.AtLeastAsFresh)
+	if err != nil {
+		// Let the implementation report the invalid requirement.
+		return c.next.Check(ctx, req)
+	}
+	key, err := cacheKey(req)
+	if err != nil {
+		return c.next.Check(ctx, req)
+	}
+
+	if resp, ok := c.get(key, required); ok {
+		return resp, nil
+	}
+
+	resp, err := c.next.Check(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+	c.put(key, resp)
+	return resp, nil
+}
+
+// ListObjects implements cazi.Interface. Responses are not cached.
+func (c *Cache) ListObjects(ctx context.Context, req cazi.ListObjectsRequest) (cazi.ListObjectsResponse, error) {
+	return c.next.ListObjects(ctx, req)
+}
+
+// Stats returns the cache statistics.
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries = c.lru.Len()
+	return stats
+}
+
+// Purge removes all entries, e.g. after a change to authorization data is observed with cazi.Watch.
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+	c.lru.Init()
+}
+
+func (c *Cache) get(key [sha256.Size]byte, required cazi.Consistency) (cazi.CheckResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		c.stats.Misses++
+		return cazi.CheckResponse{}, false
+	}
+	e := el.Value.(*entry)
+	if !c.opts.Now().Before(e.expiresAt) {
+		c.lru.Remove(el)
+		delete(c.entries, key)
+		c.stats.Misses++
+		return cazi.CheckResponse{}, false
+	}
+	if !c.satisfies(e.resp.ConsistencyToken, required) {
+		c.stats.Misses++
+		c.stats.Stale++
+		return cazi.CheckResponse{}, false
+	}
+
+	c.lru.MoveToFront(el)
+	c.stats.Hits++
+	return e.resp, true
+}
+
+// satisfies reports whether a response with token satisfies the consistency requirement.
+func (c *Cache) satisfies(token cazi.ConsistencyToken, required cazi.Consistency) bool {
+	switch required.Mode {
+	case cazi.ConsistencyMinimizeLatency:
+		return true
+	case cazi.ConsistencyAtLeastAsFresh:
+		if len(token) == 0 {
+			return false
+		}
+		cmp, err := c.opts.Compare(token, required.Token)
+		return err == nil && cmp >= 0
+	case cazi.ConsistencyAtExactSnapshot:
+		return bytes.Equal(token, required.Token)
+	default:
+		return false
+	}
+}
+
+func (c *Cache) put(key [sha256.Size]byte, resp cazi.CheckResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := c.opts.Now().Add(c.opts.TTL)
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*entry)
+		// Concurrent misses may finish out of order; don't replace a fresher response.
+		if cmp, err := c.opts.Compare(e.resp.ConsistencyToken, resp.ConsistencyToken); err == nil && cmp > 0 {
+			return
+		}
+		e.resp, e.expiresAt = resp, expiresAt
+		c.lru.MoveToFront(el)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&entry{key: key, resp: resp, expiresAt: expiresAt})
+	for c.lru.Len() > c.opts.MaxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry).key)
+		c.stats.Evictions++
+	}
+}
+
+// cacheKey hashes the canonical JSON encoding of the parts of a request that determine its decision.
+// encoding/json sorts map keys, so equal claims encode identically.
+func cacheKey(req cazi.CheckRequest) ([sha256.Size]byte, error) {
+	data, err := json.Marshal(struct {
+		Subject cazi.Subject `json:"subject"`
+		Verb    string       `json:"verb"`
+		Object  cazi.Object  `json:"object"`
+	}{req.Subject, req.Verb, req.Object})
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(data), nil
+}
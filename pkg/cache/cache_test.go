@@ -0,0 +1,326 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alechenninger/cazi/implementations/memory"
+	"github.com/alechenninger/cazi/pkg/cache"
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"github.com/alechenninger/cazi/pkg/consistency"
+)
+
+// revisionAuthz answers every check with a conditional response at its current revision.
+type revisionAuthz struct {
+	mu       sync.Mutex
+	revision uint64
+	calls    int
+	err      error
+}
+
+func (a *revisionAuthz) Check(ctx context.Context, req cazi.CheckRequest) (cazi.CheckResponse, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.calls++
+	if a.err != nil {
+		return cazi.CheckResponse{}, a.err
+	}
+	return cazi.CheckResponse{
+		Decision:         cazi.DecisionConditional,
+		Condition:        cazi.Expression{Language: "cel", Expression: "widget.owner_id == 'alice'"},
+		ConsistencyToken: token(a.revision),
+	}, nil
+}
+
+func (a *revisionAuthz) ListObjects(ctx context.Context, req cazi.ListObjectsRequest) (cazi.ListObjectsResponse, error) {
+	return cazi.ListObjectsResponse{Decision: cazi.DecisionDeny}, nil
+}
+
+func token(rev uint64) cazi.ConsistencyToken {
+	return consistency.Encode(consistency.Token{Backend: "test", Revision: rev})
+}
+
+func request(user string) cazi.CheckRequest {
+	return cazi.CheckRequest{
+		Subject: cazi.Subject{Assertion: cazi.ResourceReference{Type: "user", ID: user}},
+		Verb:    "read",
+		Object:  cazi.Object{Assertion: cazi.ResourceReference{Type: "widget", ID: "w1"}},
+	}
+}
+
+func check(t *testing.T, c *cache.Cache, req cazi.CheckRequest) cazi.CheckResponse {
+	t.Helper()
+	resp, err := c.Check(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return resp
+}
+
+func TestCheckCachesResponses(t *testing.T) {
+	authz := &revisionAuthz{revision: 1}
+	c := cache.New(authz, cache.Options{})
+
+	first := check(t, c, request("alice"))
+	second := check(t, c, request("alice"))
+
+	if authz.calls != 1 {
+		t.Errorf("expected 1 backend call, got %d", authz.calls)
+	}
+	if second.Decision != cazi.DecisionConditional || second.Condition != first.Condition {
+		t.Errorf("expected cached conditional response, got %+v", second)
+	}
+	if stats := c.Stats(); stats.Hits != 1 || stats.Misses != 1 || stats.Entries != 1 {
+		t.Errorf("expected 1 hit, 1 miss and 1 entry, got %+v", stats)
+	}
+
+	t.Run("Equal claims share an entry", func(t *testing.T) {
+		claimsReq := func() cazi.CheckRequest {
+			req := request("")
+			req.Subject.Assertion = cazi.Claims{"sub": "alice", "roles": []any{"admin"}, "tenant": "acme"}
+			return req
+		}
+		calls := authz.calls
+		check(t, c, claimsReq())
+		check(t, c, claimsReq())
+		if authz.calls != calls+1 {
+			t.Errorf("expected 1 backend call, got %d", authz.calls-calls)
+		}
+	})
+
+	t.Run("Different requests have different entries", func(t *testing.T) {
+		calls := authz.calls
+		check(t, c, request("bob"))
+		if authz.calls != calls+1 {
+			t.Errorf("expected a backend call, got %d", authz.calls-calls)
+		}
+	})
+}
+
+func TestCheckRespectsConsistency(t *testing.T) {
+	authz := &revisionAuthz{revision: 5}
+	c := cache.New(authz, cache.Options{})
+	check(t, c, request("alice"))
+
+	tests := []struct {
+		name string
+		req  func(cazi.CheckRequest) cazi.CheckRequest
+		hit  bool
+	}{
+		{"Older token", func(r cazi.CheckRequest) cazi.CheckRequest { r.AtLeastAsFresh = token(4); return r }, true},
+		{"Same token", func(r cazi.CheckRequest) cazi.CheckRequest { r.AtLeastAsFresh = token(5); return r }, true},
+		{"Newer token", func(r cazi.CheckRequest) cazi.CheckRequest { r.AtLeastAsFresh = token(6); return r }, false},
+		{"Incomparable token", func(r cazi.CheckRequest) cazi.CheckRequest { r.AtLeastAsFresh = cazi.ConsistencyToken("raw"); return r }, false},
+		{"Exact snapshot", func(r cazi.CheckRequest) cazi.CheckRequest { r.Consistency = cazi.AtExactSnapshot(token(5)); return r }, true},
+		{"Other exact snapshot", func(r cazi.CheckRequest) cazi.CheckRequest { r.Consistency = cazi.AtExactSnapshot(token(4)); return r }, false},
+		{"Fully consistent", func(r cazi.CheckRequest) cazi.CheckRequest { r.Consistency = cazi.FullyConsistent(); return r }, false},
+		{"Minimize latency", func(r cazi.CheckRequest) cazi.CheckRequest { r.Consistency = cazi.MinimizeLatency(); return r }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := authz.calls
+			check(t, c, tt.req(request("alice")))
+			if hit := authz.calls == calls; hit != tt.hit {
+				t.Errorf("expected hit %v, got %v", tt.hit, hit)
+			}
+		})
+	}
+
+	t.Run("Stale entries are replaced", func(t *testing.T) {
+		authz.revision = 7
+		req := request("alice")
+		req.AtLeastAsFresh = token(7)
+		check(t, c, req)
+
+		calls := authz.calls
+		if resp := check(t, c, req); string(resp.ConsistencyToken) != string(token(7)) || authz.calls != calls {
+			t.Errorf("expected cached response at revision 7, got %v after %d calls", resp.ConsistencyToken, authz.calls-calls)
+		}
+		if c.Stats().Stale == 0 {
+			t.Error("expected stale misses to be counted")
+		}
+	})
+}
+
+func TestCheckExpiresAndEvicts(t *testing.T) {
+	now := time.Unix(0, 0)
+	authz := &revisionAuthz{revision: 1}
+	c := cache.New(authz, cache.Options{MaxEntries: 2, TTL: time.Minute, Now: func() time.Time { return now }})
+
+	check(t, c, request("alice"))
+	now = now.Add(2 * time.Minute)
+	check(t, c, request("alice"))
+	if authz.calls != 2 {
+		t.Errorf("expected expired entry to be refetched, got %d calls", authz.calls)
+	}
+
+	check(t, c, request("bob"))
+	check(t, c, request("alice")) // alice is now most recently used
+	check(t, c, request("carol")) // evicts bob
+
+	calls := authz.calls
+	check(t, c, request("alice"))
+	if authz.calls != calls {
+		t.Error("expected alice to remain cached")
+	}
+	check(t, c, request("bob"))
+	if authz.calls != calls+1 {
+		t.Error("expected bob to have been evicted")
+	}
+
+	if stats := c.Stats(); stats.Evictions != 2 || stats.Entries != 2 {
+		t.Errorf("expected 2 evictions and 2 entries, got %+v", stats)
+	}
+}
+
+func TestCheckDoesNotCacheErrors(t *testing.T) {
+	authz := &revisionAuthz{err: errors.New("unavailable")}
+	c := cache.New(authz, cache.Options{})
+
+	for range 2 {
+		if _, err := c.Check(context.Background(), request("alice")); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+	if authz.calls != 2 {
+		t.Errorf("expected 2 backend calls, got %d", authz.calls)
+	}
+}
+
+func TestCheckBypassesCacheForExplanations(t *testing.T) {
+	authz := &revisionAuthz{revision: 1}
+	c := cache.New(authz, cache.Options{})
+	check(t, c, request("alice"))
+
+	req := request("alice")
+	req.Explain = true
+	check(t, c, req)
+
+	if authz.calls != 2 {
+		t.Errorf("expected 2 backend calls, got %d", authz.calls)
+	}
+	if stats := c.Stats(); stats.Hits != 0 {
+		t.Errorf("expected no hits, got %+v", stats)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	authz := &revisionAuthz{revision: 1}
+	c := cache.New(authz, cache.Options{})
+	check(t, c, request("alice"))
+
+	c.Purge()
+	check(t, c, request("alice"))
+	if authz.calls != 2 {
+		t.Errorf("expected purged entry to be refetched, got %d calls", authz.calls)
+	}
+}
+
+func TestConcurrentChecks(t *testing.T) {
+	c := cache.New(&revisionAuthz{revision: 1}, cache.Options{MaxEntries: 4})
+
+	var wg sync.WaitGroup
+	for i := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, user := range []string{"alice", "bob", "carol", "dave", "eve"} {
+				if _, err := c.Check(context.Background(), request(user)); err != nil {
+					t.Errorf("goroutine %d: unexpected error: %v", i, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if stats := c.Stats(); stats.Hits+stats.Misses != 16*5 {
+		t.Errorf("expected %d lookups, got %+v", 16*5, stats)
+	}
+}
+
+func TestCheckWithMemoryStore(t *testing.T) {
+	store := memory.New()
+	c := cache.New(store, cache.Options{})
+	ctx := context.Background()
+
+	reader := cazi.Relationship{
+		Object:   cazi.ResourceReference{Type: "widget", ID: "w1"},
+		Relation: "read",
+		Subject:  cazi.ResourceReference{Type: "user", ID: "alice"},
+	}
+	write := func(op cazi.RelationshipOperation) cazi.ConsistencyToken {
+		t.Helper()
+		resp, err := store.WriteRelationships(ctx, cazi.WriteRelationshipsRequest{
+			Updates: []cazi.RelationshipUpdate{{Operation: op, Relationship: reader}},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return resp.WrittenAt
+	}
+	fresh := func(token cazi.ConsistencyToken) cazi.CheckRequest {
+		req := request("alice")
+		req.AtLeastAsFresh = token
+		return req
+	}
+
+	written := write(cazi.OperationTouch)
+	check(t, c, fresh(written))
+	if resp := check(t, c, fresh(written)); resp.Decision != cazi.DecisionAllow {
+		t.Errorf("expected allow, got %v", resp.Decision)
+	}
+	if stats := c.Stats(); stats.Hits != 1 || stats.Stale != 0 {
+		t.Errorf("expected 1 hit and no stale entries, got %+v", stats)
+	}
+
+	deleted := write(cazi.OperationDelete)
+	if resp := check(t, c, fresh(deleted)); resp.Decision != cazi.DecisionDeny {
+		t.Errorf("expected deny after the relationship is deleted, got %v", resp.Decision)
+	}
+	if stats := c.Stats(); stats.Stale != 1 {
+		t.Errorf("expected 1 stale entry, got %+v", stats)
+	}
+
+	t.Run("Fresher entries satisfy older tokens", func(t *testing.T) {
+		if resp := check(t, c, fresh(written)); resp.Decision != cazi.DecisionDeny {
+			t.Errorf("expected the fresher deny, got %v", resp.Decision)
+		}
+	})
+}
+
+func TestMiddlewarePreservesOptionalInterfaces(t *testing.T) {
+	authz := cazi.Chain(cache.Middleware(cache.Options{}))(memory.New())
+	ctx := context.Background()
+
+	if _, ok := authz.(cazi.Watcher); !ok {
+		t.Error("expected cazi.Watcher")
+	}
+	writer, ok := authz.(cazi.RelationshipWriter)
+	if !ok {
+		t.Fatal("expected cazi.RelationshipWriter")
+	}
+
+	written, err := writer.WriteRelationships(ctx, cazi.WriteRelationshipsRequest{
+		Updates: []cazi.RelationshipUpdate{{Operation: cazi.OperationTouch, Relationship: cazi.Relationship{
+			Object:   cazi.ResourceReference{Type: "widget", ID: "w1"},
+			Relation: "read",
+			Subject:  cazi.ResourceReference{Type: "user", ID: "alice"},
+		}}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req := request("alice")
+	req.AtLeastAsFresh = written.WrittenAt
+	resp, err := authz.Check(ctx, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Decision != cazi.DecisionAllow {
+		t.Errorf("expected allow, got %v", resp.Decision)
+	}
+}
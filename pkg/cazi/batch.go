@@ -0,0 +1,84 @@
+package cazi
+
+import (
+	"context"
+	"sync"
+)
+
+// BatchChecker is an optional interface for implementations that can evaluate many checks in one call.
+// Use [BatchCheck] to call it, which falls back to [Interface.Check] for implementations that don't support it.
+type BatchChecker interface {
+	// BatchCheck evaluates each item independently.
+	//
+	// Results must be returned in request order, one per item.
+	// An error is returned only if the batch as a whole could not be evaluated;
+	// errors for individual items are reported in their result.
+	BatchCheck(ctx context.Context, req BatchCheckRequest) (BatchCheckResponse, error)
+}
+
+// BatchCheckRequest captures the inputs to a batch of authorization checks.
+type BatchCheckRequest struct {
+	Items []CheckRequest `json:"items"`
+}
+
+// BatchCheckResponse is the outcome of a batch of authorization checks.
+type BatchCheckResponse struct {
+	Results []BatchCheckResult `json:"results"` // one per request item, in request order
+}
+
+// BatchCheckResult is the outcome of a single item in a batch.
+type BatchCheckResult struct {
+	Response CheckResponse
+	Err      error // error checking this item, if any
+}
+
+// DefaultBatchConcurrency is the number of concurrent Check calls [BatchCheck] makes
+// for implementations that don't implement [BatchChecker].
+const DefaultBatchConcurrency = 8
+
+// BatchCheck evaluates a batch of checks.
+//
+// If authz implements [BatchChecker], the batch is delegated to it.
+// Otherwise, each item is checked with [CheckEach] using [DefaultBatchConcurrency].
+func BatchCheck(ctx context.Context, authz Interface, req BatchCheckRequest) (BatchCheckResponse, error) {
+	if batcher, ok := authz.(BatchChecker); ok {
+		return batcher.BatchCheck(ctx, req)
+	}
+	return CheckEach(ctx, authz, req, DefaultBatchConcurrency), nil
+}
+
+// CheckEach evaluates each item of a batch with authz.Check, making at most concurrency calls at a time.
+// Results are in request order. If ctx is done, items not yet started fail with ctx.Err().
+func CheckEach(ctx context.Context, authz Interface, req BatchCheckRequest, concurrency int) BatchCheckResponse {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]BatchCheckResult, len(req.Items))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, item := range req.Items {
+		if err := ctx.Err(); err != nil {
+			results[i].Err = err
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			resp, err := authz.Check(ctx, item)
+			results[i] = BatchCheckResult{Response: resp, Err: err}
+		}()
+	}
+
+	wg.Wait()
+	return BatchCheckResponse{Results: results}
+}
@@ -0,0 +1,134 @@
+package cazi_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+)
+
+// countingAuthz allows even-numbered widgets, fails for "bad", and tracks concurrent calls.
+type countingAuthz struct {
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+}
+
+func (a *countingAuthz) Check(ctx context.Context, req cazi.CheckRequest) (cazi.CheckResponse, error) {
+	n := a.inFlight.Add(1)
+	defer a.inFlight.Add(-1)
+	for {
+		m := a.maxInFlight.Load()
+		if n <= m || a.maxInFlight.CompareAndSwap(m, n) {
+			break
+		}
+	}
+	time.Sleep(time.Millisecond)
+
+	object := req.Object.Assertion.(cazi.ResourceReference)
+	if object.ID == "bad" {
+		return cazi.CheckResponse{}, errors.New("bad widget")
+	}
+	var id int
+	fmt.Sscanf(object.ID, "%d", &id)
+	if id%2 == 0 {
+		return cazi.CheckResponse{Decision: cazi.DecisionAllow}, nil
+	}
+	return cazi.CheckResponse{Decision: cazi.DecisionDeny}, nil
+}
+
+func (a *countingAuthz) ListObjects(ctx context.Context, req cazi.ListObjectsRequest) (cazi.ListObjectsResponse, error) {
+	return cazi.ListObjectsResponse{}, nil
+}
+
+// batchingAuthz implements cazi.BatchChecker.
+type batchingAuthz struct {
+	countingAuthz
+	batches int
+}
+
+func (a *batchingAuthz) BatchCheck(ctx context.Context, req cazi.BatchCheckRequest) (cazi.BatchCheckResponse, error) {
+	a.batches++
+	results := make([]cazi.BatchCheckResult, len(req.Items))
+	for i := range results {
+		results[i].Response.Decision = cazi.DecisionConditional
+	}
+	return cazi.BatchCheckResponse{Results: results}, nil
+}
+
+func widgetChecks(ids ...string) cazi.BatchCheckRequest {
+	var req cazi.BatchCheckRequest
+	for _, id := range ids {
+		req.Items = append(req.Items, cazi.CheckRequest{
+			Subject: cazi.Subject{Assertion: cazi.ResourceReference{Type: "user", ID: "alice"}},
+			Verb:    "edit",
+			Object:  cazi.Object{Assertion: cazi.ResourceReference{Type: "widget", ID: id}},
+		})
+	}
+	return req
+}
+
+func TestBatchCheckFallback(t *testing.T) {
+	var ids []string
+	for i := 0; i < 50; i++ {
+		ids = append(ids, fmt.Sprint(i))
+	}
+	ids = append(ids, "bad")
+	authz := &countingAuthz{}
+
+	resp, err := cazi.BatchCheck(context.Background(), authz, widgetChecks(ids...))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(resp.Results) != len(ids) {
+		t.Fatalf("expected %d results, got %d", len(ids), len(resp.Results))
+	}
+	for i := 0; i < 50; i++ {
+		want := cazi.DecisionDeny
+		if i%2 == 0 {
+			want = cazi.DecisionAllow
+		}
+		if got := resp.Results[i]; got.Err != nil || got.Response.Decision != want {
+			t.Errorf("item %d: expected %v, got %v (err %v)", i, want, got.Response.Decision, got.Err)
+		}
+	}
+	if resp.Results[50].Err == nil {
+		t.Error("expected error for bad widget")
+	}
+	if max := authz.maxInFlight.Load(); max > cazi.DefaultBatchConcurrency {
+		t.Errorf("expected at most %d concurrent calls, got %d", cazi.DefaultBatchConcurrency, max)
+	}
+}
+
+func TestBatchCheckUsesBatchChecker(t *testing.T) {
+	authz := &batchingAuthz{}
+
+	resp, err := cazi.BatchCheck(context.Background(), authz, widgetChecks("1", "2"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if authz.batches != 1 {
+		t.Errorf("expected one batch call, got %d", authz.batches)
+	}
+	if len(resp.Results) != 2 || resp.Results[0].Response.Decision != cazi.DecisionConditional {
+		t.Errorf("expected batch results, got %+v", resp.Results)
+	}
+}
+
+func TestCheckEachCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	resp := cazi.CheckEach(ctx, &countingAuthz{}, widgetChecks("1", "2", "3"), 1)
+
+	for i, result := range resp.Results {
+		if !errors.Is(result.Err, context.Canceled) {
+			t.Errorf("item %d: expected context.Canceled, got %v", i, result.Err)
+		}
+	}
+}
@@ -51,15 +51,67 @@ type FastReplication[T any, U any] struct {
 	Write func(ctx context.Context) (data T, err error)
 }
 
-type AccurateStore interface {
+// AccurateStore replicates mutable attributes between an application's local store and the authorization store
+// without lost writes.
+//
+// Every copy of an attribute carries a [Version].
+// A write states the version it was based on, and fails with [ErrVersionConflict]
+// unless that is the current version in both stores,
+// so a transaction can never silently overwrite a change it did not observe.
+// Callers handle conflicts by reading again and retrying.
+type AccurateStore[T any] interface {
+	// Replicates data to both an application's local store, and the authorization store,
+	// with optimistic concurrency.
+	WithAccurateReplication(protocol AccurateReplication[T])
+
+	// Read returns the current value of an attribute.
+	//
+	// If a previous write was applied to only one store, Read completes it,
+	// so both stores agree before the value is returned.
+	Read(ctx context.Context, key string) (Versioned[T], error)
+
+	// Write replaces the value of an attribute.
+	//
+	// data.Version must be the version the new value is based on, as returned by Read or a previous Write.
+	// Returns the value with its new version, or an error wrapping [ErrVersionConflict]
+	// if either store has a different version.
+	Write(ctx context.Context, key string, data Versioned[T]) (Versioned[T], error)
+}
+
+// Versioned is a copy of an attribute with its version.
+type Versioned[T any] struct {
+	Version   Version
+	WrittenAt ConsistencyToken // token at which the authorization store's copy is visible, if known
+	Data      T
+}
+
+type AccurateReplication[T any] struct {
+	// Commit calls the provided function [fn] within a transaction.
+	//
+	// Before the function is called, a transaction must be started.
+	// After the function completes successfully, the transaction is committed.
+	// If the function returns an error, the transaction is rolled back.
+	Commit func(ctx context.Context, fn func(ctx context.Context) error) error
+
+	// Load reads the application's copy of an attribute within a transaction.
+	// It returns a zero Versioned if the attribute doesn't exist.
+	//
+	// The copy must stay locked until the transaction ends (e.g. SELECT ... FOR UPDATE),
+	// so concurrent transactions on the same attribute are serialized.
+	Load func(ctx context.Context, key string) (Versioned[T], error)
+
+	// Save writes the application's copy of an attribute within a transaction.
+	Save func(ctx context.Context, key string, data Versioned[T]) error
 }
 
 // CheckRequest captures the inputs to an authorization check.
 type CheckRequest struct {
-	Subject        Subject          // subject assertion with optional relation
-	Verb           string           // verb/relation
-	Object         Object           // object assertion
-	AtLeastAsFresh ConsistencyToken // optional opaque token for causal consistency
+	Subject        Subject          `json:"subject"`                     // subject assertion with optional relation
+	Verb           string           `json:"verb"`                        // verb/relation
+	Object         Object           `json:"object"`                      // object assertion
+	AtLeastAsFresh ConsistencyToken `json:"at_least_as_fresh,omitempty"` // optional opaque token for causal consistency
+	Consistency    Consistency      `json:"consistency,omitzero"`        // optional consistency requirement; takes precedence over AtLeastAsFresh if its Mode is set
+	Explain        bool             `json:"explain,omitempty"`           // optional; asks for an Explanation of the decision
 }
 
 // Subject represents the actor performing the action.
@@ -91,16 +143,16 @@ func (Claims) isAssertion() {}
 
 // OpaqueToken carries an opaque payload with a declared type, optionally signed (e.g., JWT).
 type OpaqueToken struct {
-	Type string // media/type or scheme identifier (e.g., "jwt")
-	Raw  []byte // raw token bytes
+	Type string `json:"type"` // media/type or scheme identifier (e.g., "jwt")
+	Raw  []byte `json:"raw"`  // raw token bytes
 }
 
 func (OpaqueToken) isAssertion() {}
 
 // ResourceReference identifies a resource by type and id.
 type ResourceReference struct {
-	Type string
-	ID   string
+	Type string `json:"type"`
+	ID   string `json:"id"`
 }
 
 func (ResourceReference) isAssertion() {}
@@ -117,10 +169,11 @@ const (
 
 // CheckResponse is the outcome of a Check invocation.
 type CheckResponse struct {
-	Decision         DecisionKind         // allow/deny/conditional
-	Condition        Expression           // present when DecisionConditional (check Language != "" to detect if set)
-	Context          AuthorizationContext // additional context about the authorization decision (maps may be nil if not provided)
-	ConsistencyToken ConsistencyToken     // token representing the freshness of this authorization decision (check len > 0 to detect if set)
+	Decision         DecisionKind         `json:"decision"`                    // allow/deny/conditional
+	Condition        Expression           `json:"condition,omitzero"`          // present when DecisionConditional (check Language != "" to detect if set)
+	Context          AuthorizationContext `json:"context,omitzero"`            // additional context about the authorization decision (maps may be nil if not provided)
+	ConsistencyToken ConsistencyToken     `json:"consistency_token,omitempty"` // token identifying the snapshot this decision was evaluated at (check len > 0 to detect if set)
+	Explanation      *Explanation         `json:"explanation,omitempty"`       // how the decision was reached, if requested with Explain and supported by the implementation
 }
 
 // AuthorizationContext provides optional additional information about the authorization decision.
@@ -130,12 +183,12 @@ type AuthorizationContext struct {
 	// RequesterContext contains claims about the requester (subject).
 	// These are assertions about who is making the request, such as roles, attributes,
 	// or other identity-related information that may be useful for downstream processing.
-	RequesterContext Claims
+	RequesterContext Claims `json:"requester_context,omitempty"`
 
 	// TransactionContext contains claims about the requested operation itself.
 	// These are assertions about the transaction, such as environmental factors,
 	// computed context, or other operation-related information.
-	TransactionContext Claims
+	TransactionContext Claims `json:"transaction_context,omitempty"`
 }
 
 // Claim provides type-safe access to a specific claim in Claims.
@@ -160,17 +213,18 @@ func SetClaim[T any](claims Claims, claim Claim[T], value T) {
 // The language is intentionally unspecified; callers and implementations
 // may agree on a language such as CEL, Rego, etc.
 type Expression struct {
-	Language   string // optional (e.g., "cel")
-	Expression string // the expression to evaluate
+	Language   string `json:"language,omitempty"` // optional (e.g., "cel")
+	Expression string `json:"expression"`         // the expression to evaluate
 }
 
 // ListObjectsRequest captures the inputs to an object listing.
 type ListObjectsRequest struct {
-	Subject        Subject          // subject assertion with optional relation
-	Verb           string           // verb/relation
-	ObjectType     string           // type of objects to list
-	Filter         Expression       // optional filter expression
-	AtLeastAsFresh ConsistencyToken // optional opaque token for causal consistency
+	Subject        Subject          `json:"subject"`                     // subject assertion with optional relation
+	Verb           string           `json:"verb"`                        // verb/relation
+	ObjectType     string           `json:"object_type"`                 // type of objects to list
+	Filter         Expression       `json:"filter,omitzero"`             // optional filter expression
+	AtLeastAsFresh ConsistencyToken `json:"at_least_as_fresh,omitempty"` // optional opaque token for causal consistency
+	Consistency    Consistency      `json:"consistency,omitzero"`        // optional consistency requirement; takes precedence over AtLeastAsFresh if its Mode is set
 }
 
 // ListObjectsResponse captures the outputs of an object listing.
@@ -181,8 +235,8 @@ type ListObjectsRequest struct {
 //   - Attribute-based filters: "resource.owner_id == 'user123'"
 //   - Complex conditions: "resource.owner_id == 'user123' && resource.status == 'active'"
 type ListObjectsResponse struct {
-	Decision         DecisionKind // allow/deny/conditional
-	Condition        Expression   // filter expression to apply (check Language != "" to detect if set)
-	Context          AuthorizationContext
-	ConsistencyToken ConsistencyToken // token representing the freshness of this authorization decision (check len > 0 to detect if set)
+	Decision         DecisionKind         `json:"decision"`           // allow/deny/conditional
+	Condition        Expression           `json:"condition,omitzero"` // filter expression to apply (check Language != "" to detect if set)
+	Context          AuthorizationContext `json:"context,omitzero"`
+	ConsistencyToken ConsistencyToken     `json:"consistency_token,omitempty"` // token identifying the snapshot this decision was evaluated at (check len > 0 to detect if set)
 }
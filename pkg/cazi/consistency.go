@@ -0,0 +1,109 @@
+package cazi
+
+import "fmt"
+
+// ConsistencyMode selects the snapshot of authorization data a request is evaluated at.
+type ConsistencyMode int
+
+const (
+	ConsistencyUnspecified     ConsistencyMode = iota // at least as fresh as AtLeastAsFresh if set, otherwise minimize latency
+	ConsistencyMinimizeLatency                        // any snapshot the implementation can answer from fastest, possibly stale
+	ConsistencyAtLeastAsFresh                         // a snapshot at least as fresh as Token
+	ConsistencyAtExactSnapshot                        // exactly the snapshot identified by Token
+	ConsistencyFullyConsistent                        // the most recent snapshot
+)
+
+// Consistency is the consistency requirement of a request.
+// The zero value defers to the request's AtLeastAsFresh token.
+//
+// Implementations report the snapshot they used in the response's ConsistencyToken.
+// An implementation that cannot satisfy a requirement (e.g. the snapshot is no longer available)
+// must return an error rather than answer at a different snapshot.
+type Consistency struct {
+	Mode  ConsistencyMode  `json:"mode"`
+	Token ConsistencyToken `json:"token,omitempty"` // required for ConsistencyAtLeastAsFresh and ConsistencyAtExactSnapshot
+}
+
+// MinimizeLatency returns a requirement for the fastest available snapshot.
+func MinimizeLatency() Consistency {
+	return Consistency{Mode: ConsistencyMinimizeLatency}
+}
+
+// AtLeastAsFresh returns a requirement for a snapshot at least as fresh as token.
+func AtLeastAsFresh(token ConsistencyToken) Consistency {
+	return Consistency{Mode: ConsistencyAtLeastAsFresh, Token: token}
+}
+
+// AtExactSnapshot returns a requirement for exactly the snapshot identified by token.
+func AtExactSnapshot(token ConsistencyToken) Consistency {
+	return Consistency{Mode: ConsistencyAtExactSnapshot, Token: token}
+}
+
+// FullyConsistent returns a requirement for the most recent snapshot.
+func FullyConsistent() Consistency {
+	return Consistency{Mode: ConsistencyFullyConsistent}
+}
+
+// Resolve returns the effective requirement of a request with consistency c and atLeastAsFresh token,
+// so implementations only need to handle explicit modes:
+// an unspecified mode becomes ConsistencyAtLeastAsFresh if atLeastAsFresh is set,
+// and ConsistencyMinimizeLatency otherwise.
+// Returns an error wrapping [ErrInvalidArgument] if the mode is unknown or is missing a required token.
+func (c Consistency) Resolve(atLeastAsFresh ConsistencyToken) (Consistency, error) {
+	switch c.Mode {
+	case ConsistencyUnspecified:
+		if len(atLeastAsFresh) > 0 {
+			return AtLeastAsFresh(atLeastAsFresh), nil
+		}
+		return MinimizeLatency(), nil
+	case ConsistencyMinimizeLatency, ConsistencyFullyConsistent:
+		return c, nil
+	case ConsistencyAtLeastAsFresh, ConsistencyAtExactSnapshot:
+		if len(c.Token) == 0 {
+			return Consistency{}, fmt.Errorf("%w: consistency mode %s requires a token", ErrInvalidArgument, c.Mode)
+		}
+		return c, nil
+	default:
+		return Consistency{}, fmt.Errorf("%w: unknown consistency mode %d", ErrInvalidArgument, int(c.Mode))
+	}
+}
+
+// String returns the canonical name of the mode.
+func (m ConsistencyMode) String() string {
+	switch m {
+	case ConsistencyMinimizeLatency:
+		return "minimize_latency"
+	case ConsistencyAtLeastAsFresh:
+		return "at_least_as_fresh"
+	case ConsistencyAtExactSnapshot:
+		return "at_exact_snapshot"
+	case ConsistencyFullyConsistent:
+		return "fully_consistent"
+	default:
+		return "unspecified"
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (m ConsistencyMode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (m *ConsistencyMode) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "minimize_latency":
+		*m = ConsistencyMinimizeLatency
+	case "at_least_as_fresh":
+		*m = ConsistencyAtLeastAsFresh
+	case "at_exact_snapshot":
+		*m = ConsistencyAtExactSnapshot
+	case "fully_consistent":
+		*m = ConsistencyFullyConsistent
+	case "unspecified", "":
+		*m = ConsistencyUnspecified
+	default:
+		return fmt.Errorf("unknown consistency mode %q", text)
+	}
+	return nil
+}
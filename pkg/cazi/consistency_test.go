@@ -0,0 +1,71 @@
+package cazi_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+)
+
+func TestConsistencyResolve(t *testing.T) {
+	token := cazi.ConsistencyToken("rev-1")
+
+	tests := []struct {
+		name           string
+		consistency    cazi.Consistency
+		atLeastAsFresh cazi.ConsistencyToken
+		want           cazi.ConsistencyMode
+		wantErr        bool
+	}{
+		{"Unspecified", cazi.Consistency{}, nil, cazi.ConsistencyMinimizeLatency, false},
+		{"Unspecified with token", cazi.Consistency{}, token, cazi.ConsistencyAtLeastAsFresh, false},
+		{"Explicit mode takes precedence", cazi.FullyConsistent(), token, cazi.ConsistencyFullyConsistent, false},
+		{"Exact snapshot", cazi.AtExactSnapshot(token), nil, cazi.ConsistencyAtExactSnapshot, false},
+		{"Missing token", cazi.Consistency{Mode: cazi.ConsistencyAtLeastAsFresh}, token, 0, true},
+		{"Unknown mode", cazi.Consistency{Mode: 99}, nil, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.consistency.Resolve(tt.atLeastAsFresh)
+			if tt.wantErr {
+				if !errors.Is(err, cazi.ErrInvalidArgument) {
+					t.Errorf("expected cazi.ErrInvalidArgument, got %v, %v", got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Mode != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got.Mode)
+			}
+		})
+	}
+}
+
+func TestConsistencyJSON(t *testing.T) {
+	req := cazi.ListObjectsRequest{
+		Verb:        "read",
+		ObjectType:  "widget",
+		Consistency: cazi.AtExactSnapshot(cazi.ConsistencyToken{0x01}),
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"subject":{},"verb":"read","object_type":"widget","consistency":{"mode":"at_exact_snapshot","token":"AQ=="}}`
+	if string(data) != want {
+		t.Errorf("expected %s, got %s", want, data)
+	}
+
+	var got cazi.ListObjectsRequest
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Consistency.Mode != cazi.ConsistencyAtExactSnapshot || string(got.Consistency.Token) != "\x01" {
+		t.Errorf("expected exact snapshot requirement, got %+v", got.Consistency)
+	}
+}
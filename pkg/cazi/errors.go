@@ -0,0 +1,94 @@
+package cazi
+
+import "errors"
+
+// Error is a kind of failure that callers can handle differently from others,
+// such as a request the implementation doesn't support or a backend outage.
+//
+// Implementations return errors wrapping one of the Err values of this package, adding details with fmt.Errorf and %w,
+// e.g. fmt.Errorf("%w: %s", cazi.ErrUnsupportedVerb, req.Verb), so callers can test for them with errors.Is.
+// Some kinds refine others: every unsupported request is also an [ErrInvalidArgument].
+type Error struct {
+	// Code identifies the kind of error across process boundaries, e.g. "unsupported_verb".
+	Code string
+
+	msg    string
+	parent *Error
+}
+
+var (
+	// ErrInvalidArgument is returned when a request is malformed or asks for something the implementation can't answer.
+	// Retrying the same request will fail the same way.
+	ErrInvalidArgument = &Error{Code: "invalid_argument", msg: "invalid argument"}
+
+	// ErrUnsupportedAssertion is returned when a subject or object assertion is of a type the implementation doesn't accept,
+	// e.g. claims where a ResourceReference is required.
+	ErrUnsupportedAssertion = &Error{Code: "unsupported_assertion", msg: "unsupported assertion type", parent: ErrInvalidArgument}
+
+	// ErrUnsupportedVerb is returned when a request names a verb the implementation's policy doesn't define.
+	ErrUnsupportedVerb = &Error{Code: "unsupported_verb", msg: "unsupported verb", parent: ErrInvalidArgument}
+
+	// ErrUnsupportedResourceType is returned when a request names an object or subject type
+	// the implementation's policy doesn't define.
+	ErrUnsupportedResourceType = &Error{Code: "unsupported_resource_type", msg: "unsupported resource type", parent: ErrInvalidArgument}
+
+	// ErrUnsupportedLanguage is returned when a request includes an expression in a language the implementation can't evaluate.
+	ErrUnsupportedLanguage = &Error{Code: "unsupported_language", msg: "unsupported expression language", parent: ErrInvalidArgument}
+
+	// ErrTokenTooNew is returned when a consistency token is from a snapshot the implementation hasn't reached yet,
+	// e.g. a replica lagging behind the one that issued the token. The request may succeed if retried later.
+	ErrTokenTooNew = &Error{Code: "token_too_new", msg: "consistency token is newer than available data"}
+
+	// ErrUnavailable is returned when the implementation can't currently make decisions,
+	// e.g. because a backend is down. The request may succeed if retried later.
+	ErrUnavailable = &Error{Code: "unavailable", msg: "authorization unavailable"}
+)
+
+// errorKinds are all kinds of Error, for looking them up by code.
+var errorKinds = []*Error{
+	ErrInvalidArgument,
+	ErrUnsupportedAssertion,
+	ErrUnsupportedVerb,
+	ErrUnsupportedResourceType,
+	ErrUnsupportedLanguage,
+	ErrTokenTooNew,
+	ErrUnavailable,
+	ErrPreconditionFailed,
+	ErrRelationshipExists,
+	ErrVersionConflict,
+}
+
+func (e *Error) Error() string {
+	return e.msg
+}
+
+// Is reports whether e is a refinement of target,
+// e.g. ErrUnsupportedVerb is ErrInvalidArgument.
+func (e *Error) Is(target error) bool {
+	for p := e.parent; p != nil; p = p.parent {
+		if p == target {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrorKind returns the kind of Error that err wraps, if any.
+func ErrorKind(err error) (*Error, bool) {
+	var kind *Error
+	if errors.As(err, &kind) {
+		return kind, true
+	}
+	return nil, false
+}
+
+// ErrorForCode returns the kind of Error with the given code, if any.
+// Bindings use it to restore errors received from a remote implementation.
+func ErrorForCode(code string) (*Error, bool) {
+	for _, kind := range errorKinds {
+		if kind.Code == code {
+			return kind, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,67 @@
+package cazi_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+)
+
+func TestErrorIs(t *testing.T) {
+	err := fmt.Errorf("%w: delete", cazi.ErrUnsupportedVerb)
+
+	if !errors.Is(err, cazi.ErrUnsupportedVerb) {
+		t.Error("expected error to be ErrUnsupportedVerb")
+	}
+	if !errors.Is(err, cazi.ErrInvalidArgument) {
+		t.Error("expected unsupported verb to be an invalid argument")
+	}
+	if errors.Is(err, cazi.ErrUnsupportedResourceType) || errors.Is(err, cazi.ErrUnavailable) {
+		t.Error("expected unsupported verb not to match other kinds")
+	}
+	if errors.Is(cazi.ErrInvalidArgument, cazi.ErrUnsupportedVerb) {
+		t.Error("expected invalid argument not to be an unsupported verb")
+	}
+	if err.Error() != "unsupported verb: delete" {
+		t.Errorf("expected message 'unsupported verb: delete', got '%s'", err)
+	}
+}
+
+func TestErrorKind(t *testing.T) {
+	t.Run("Wrapped kind", func(t *testing.T) {
+		kind, ok := cazi.ErrorKind(fmt.Errorf("replica lagging: %w", cazi.ErrTokenTooNew))
+		if !ok || kind != cazi.ErrTokenTooNew {
+			t.Errorf("expected ErrTokenTooNew, got %v", kind)
+		}
+	})
+
+	t.Run("No kind", func(t *testing.T) {
+		if kind, ok := cazi.ErrorKind(errors.New("boom")); ok {
+			t.Errorf("expected no kind, got %v", kind)
+		}
+	})
+}
+
+func TestErrorForCode(t *testing.T) {
+	for _, kind := range []*cazi.Error{
+		cazi.ErrInvalidArgument,
+		cazi.ErrUnsupportedAssertion,
+		cazi.ErrUnsupportedVerb,
+		cazi.ErrUnsupportedResourceType,
+		cazi.ErrUnsupportedLanguage,
+		cazi.ErrTokenTooNew,
+		cazi.ErrUnavailable,
+		cazi.ErrPreconditionFailed,
+		cazi.ErrRelationshipExists,
+		cazi.ErrVersionConflict,
+	} {
+		if got, ok := cazi.ErrorForCode(kind.Code); !ok || got != kind {
+			t.Errorf("expected %s to round trip, got %v", kind.Code, got)
+		}
+	}
+
+	if got, ok := cazi.ErrorForCode("bogus"); ok {
+		t.Errorf("expected unknown code not to match, got %v", got)
+	}
+}
@@ -0,0 +1,52 @@
+package cazi
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Explanation is a structured trace of how an implementation reached a decision,
+// returned when a check is requested with Explain.
+//
+// Each step describes something the implementation evaluated, such as a rule or a relationship,
+// with the decision it contributed. Steps nest: a step's Steps are the evaluations it depended on.
+// The root step describes the check itself, with the overall decision.
+//
+// Explanations are intended for debugging and may be expensive to produce.
+// Their descriptions are for people; don't parse them.
+type Explanation struct {
+	Description  string        `json:"description"`            // what was evaluated, e.g. "rule: owners can read widgets"
+	Decision     DecisionKind  `json:"decision"`               // allow if it matched, deny if not, conditional if it produced a condition
+	Relationship *Relationship `json:"relationship,omitempty"` // the relationship looked up, if any
+	Condition    Expression    `json:"condition,omitzero"`     // the condition produced, if any
+	Steps        []Explanation `json:"steps,omitempty"`        // nested evaluations
+}
+
+// String formats the explanation as an indented tree for command line output, e.g.:
+//
+//	[allow] user:alice read widget:w1
+//	├── [deny] relationship widget:w1#read@user:alice
+//	└── [allow] subject set group:eng#member
+//	    └── [allow] relationship group:eng#member@user:alice
+func (e Explanation) String() string {
+	var b strings.Builder
+	e.format(&b, "", "")
+	return b.String()
+}
+
+// format writes e with prefix before its own line and indent before the lines of its steps.
+func (e Explanation) format(b *strings.Builder, prefix, indent string) {
+	fmt.Fprintf(b, "%s[%s] %s", prefix, e.Decision, e.Description)
+	if e.Condition.Expression != "" {
+		fmt.Fprintf(b, " (%s: %s)", e.Condition.Language, e.Condition.Expression)
+	}
+	b.WriteByte('\n')
+
+	for i, step := range e.Steps {
+		if i == len(e.Steps)-1 {
+			step.format(b, indent+"└── ", indent+"    ")
+		} else {
+			step.format(b, indent+"├── ", indent+"│   ")
+		}
+	}
+}
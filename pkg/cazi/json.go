@@ -0,0 +1,255 @@
+package cazi
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Canonical JSON encoding.
+//
+// All request and response types can be encoded with encoding/json.
+// Field names are snake_case, consistency tokens and opaque token bytes are base64 strings,
+// and decisions are lower case strings ("allow", "deny", "conditional").
+//
+// Assertions are tagged with their type, as a JSON object with exactly one key:
+//
+//	{"resource_reference": {"type": "user", "id": "alice"}}
+//	{"claims": {"sub": "alice", "roles": ["admin"]}}
+//	{"opaque_token": {"type": "jwt", "raw": "ZXlKaGJHY2lP..."}}
+//
+// Claims decode with encoding/json semantics (numbers as float64, lists as []any).
+//
+// Errors of batch items are encoded with the code of their kind of [Error], if any, and their message:
+//
+//	{"response": {...}, "error": {"code": "unsupported_verb", "message": "unsupported verb: delete"}}
+//
+// Decoded errors have the same message and wrap the kind of Error for the code, so errors.Is still works.
+
+const (
+	assertionKeyClaims            = "claims"
+	assertionKeyOpaqueToken       = "opaque_token"
+	assertionKeyResourceReference = "resource_reference"
+)
+
+// MarshalAssertion encodes an assertion in its tagged JSON form.
+// A nil assertion is encoded as null.
+func MarshalAssertion(a Assertion) ([]byte, error) {
+	var key string
+	switch a.(type) {
+	case nil:
+		return []byte("null"), nil
+	case Claims:
+		key = assertionKeyClaims
+	case OpaqueToken:
+		key = assertionKeyOpaqueToken
+	case ResourceReference:
+		key = assertionKeyResourceReference
+	default:
+		return nil, fmt.Errorf("%w %T", ErrUnsupportedAssertion, a)
+	}
+	return json.Marshal(map[string]any{key: a})
+}
+
+// UnmarshalAssertion decodes an assertion from its tagged JSON form.
+// null is decoded as a nil assertion.
+func UnmarshalAssertion(data []byte) (Assertion, error) {
+	var tagged map[string]json.RawMessage
+	if err := json.Unmarshal(data, &tagged); err != nil {
+		return nil, fmt.Errorf("invalid assertion: %w", err)
+	}
+	if tagged == nil {
+		return nil, nil
+	}
+	if len(tagged) != 1 {
+		return nil, fmt.Errorf("invalid assertion: expected exactly one of %q, %q or %q",
+			assertionKeyClaims, assertionKeyOpaqueToken, assertionKeyResourceReference)
+	}
+
+	key := slices.Collect(maps.Keys(tagged))[0]
+	raw := tagged[key]
+	switch key {
+	case assertionKeyClaims:
+		var c Claims
+		if err := json.Unmarshal(raw, &c); err != nil {
+			return nil, fmt.Errorf("invalid claims assertion: %w", err)
+		}
+		if c == nil {
+			c = Claims{}
+		}
+		return c, nil
+	case assertionKeyOpaqueToken:
+		var t OpaqueToken
+		if err := json.Unmarshal(raw, &t); err != nil {
+			return nil, fmt.Errorf("invalid opaque token assertion: %w", err)
+		}
+		return t, nil
+	case assertionKeyResourceReference:
+		var r ResourceReference
+		if err := json.Unmarshal(raw, &r); err != nil {
+			return nil, fmt.Errorf("invalid resource reference assertion: %w", err)
+		}
+		return r, nil
+	default:
+		return nil, fmt.Errorf("invalid assertion: unknown assertion type %q", key)
+	}
+}
+
+type subjectJSON struct {
+	Assertion json.RawMessage `json:"assertion,omitempty"`
+	Relation  string          `json:"relation,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (s Subject) MarshalJSON() ([]byte, error) {
+	assertion, err := marshalOptionalAssertion(s.Assertion)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(subjectJSON{Assertion: assertion, Relation: s.Relation})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *Subject) UnmarshalJSON(data []byte) error {
+	var v subjectJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	assertion, err := unmarshalOptionalAssertion(v.Assertion)
+	if err != nil {
+		return err
+	}
+	*s = Subject{Assertion: assertion, Relation: v.Relation}
+	return nil
+}
+
+type objectJSON struct {
+	Assertion json.RawMessage `json:"assertion,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (o Object) MarshalJSON() ([]byte, error) {
+	assertion, err := marshalOptionalAssertion(o.Assertion)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(objectJSON{Assertion: assertion})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (o *Object) UnmarshalJSON(data []byte) error {
+	var v objectJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	assertion, err := unmarshalOptionalAssertion(v.Assertion)
+	if err != nil {
+		return err
+	}
+	*o = Object{Assertion: assertion}
+	return nil
+}
+
+func marshalOptionalAssertion(a Assertion) (json.RawMessage, error) {
+	if a == nil {
+		return nil, nil
+	}
+	return MarshalAssertion(a)
+}
+
+func unmarshalOptionalAssertion(data json.RawMessage) (Assertion, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return UnmarshalAssertion(data)
+}
+
+type batchCheckResultJSON struct {
+	Response CheckResponse `json:"response"`
+	Error    *errorJSON    `json:"error,omitempty"`
+}
+
+type errorJSON struct {
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r BatchCheckResult) MarshalJSON() ([]byte, error) {
+	v := batchCheckResultJSON{Response: r.Response}
+	if r.Err != nil {
+		v.Error = &errorJSON{Message: r.Err.Error()}
+		if kind, ok := ErrorKind(r.Err); ok {
+			v.Error.Code = kind.Code
+		}
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (r *BatchCheckResult) UnmarshalJSON(data []byte) error {
+	var v batchCheckResultJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*r = BatchCheckResult{Response: v.Response}
+	if v.Error != nil {
+		kind, _ := ErrorForCode(v.Error.Code)
+		r.Err = &decodedError{msg: v.Error.Message, kind: kind}
+	}
+	return nil
+}
+
+// decodedError is an error decoded from JSON. It unwraps to its kind of Error, if known.
+type decodedError struct {
+	msg  string
+	kind *Error
+}
+
+func (e *decodedError) Error() string {
+	return e.msg
+}
+
+func (e *decodedError) Unwrap() error {
+	if e.kind == nil {
+		return nil
+	}
+	return e.kind
+}
+
+// String returns the canonical name of the decision.
+func (d DecisionKind) String() string {
+	switch d {
+	case DecisionAllow:
+		return "allow"
+	case DecisionDeny:
+		return "deny"
+	case DecisionConditional:
+		return "conditional"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d DecisionKind) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *DecisionKind) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "allow":
+		*d = DecisionAllow
+	case "deny":
+		*d = DecisionDeny
+	case "conditional":
+		*d = DecisionConditional
+	case "unknown", "":
+		*d = DecisionUnknown
+	default:
+		return fmt.Errorf("unknown decision %q", text)
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package cazi_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+)
+
+func TestCheckRequestJSON(t *testing.T) {
+	t.Run("Canonical encoding", func(t *testing.T) {
+		req := cazi.CheckRequest{
+			Subject:        cazi.Subject{Assertion: cazi.ResourceReference{Type: "user", ID: "alice"}},
+			Verb:           "read",
+			Object:         cazi.Object{Assertion: cazi.ResourceReference{Type: "widget", ID: "w1"}},
+			AtLeastAsFresh: cazi.ConsistencyToken{0x01, 0x02},
+		}
+
+		data, err := json.Marshal(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		want := `{"subject":{"assertion":{"resource_reference":{"type":"user","id":"alice"}}},` +
+			`"verb":"read","object":{"assertion":{"resource_reference":{"type":"widget","id":"w1"}}},` +
+			`"at_least_as_fresh":"AQI="}`
+		if string(data) != want {
+			t.Errorf("expected %s, got %s", want, data)
+		}
+	})
+
+	assertions := map[string]cazi.Assertion{
+		"ResourceReference": cazi.ResourceReference{Type: "user", ID: "alice"},
+		"OpaqueToken":       cazi.OpaqueToken{Type: "jwt", Raw: []byte("a.b.c")},
+		"Claims":            cazi.Claims{"sub": "alice", "roles": []any{"admin"}},
+		"Empty claims":      cazi.Claims{},
+		"nil":               nil,
+	}
+
+	for name, assertion := range assertions {
+		t.Run("Round trip "+name, func(t *testing.T) {
+			req := cazi.CheckRequest{
+				Subject: cazi.Subject{Assertion: assertion, Relation: "member"},
+				Verb:    "read",
+				Object:  cazi.Object{Assertion: cazi.ResourceReference{Type: "widget", ID: "w1"}},
+			}
+
+			data, err := json.Marshal(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got cazi.CheckRequest
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, req) {
+				t.Errorf("expected %+v, got %+v", req, got)
+			}
+		})
+	}
+}
+
+func TestCheckResponseJSON(t *testing.T) {
+	resp := cazi.CheckResponse{
+		Decision:  cazi.DecisionConditional,
+		Condition: cazi.Expression{Language: "cel", Expression: "widget.owner_id == 'alice'"},
+		Context: cazi.AuthorizationContext{
+			RequesterContext: cazi.Claims{"sub": "alice"},
+		},
+		ConsistencyToken: cazi.ConsistencyToken("rev-1"),
+	}
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `{"decision":"conditional","condition":{"language":"cel","expression":"widget.owner_id == 'alice'"},` +
+		`"context":{"requester_context":{"sub":"alice"}},"consistency_token":"cmV2LTE="}`
+	if string(data) != want {
+		t.Errorf("expected %s, got %s", want, data)
+	}
+
+	var got cazi.CheckResponse
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, resp) {
+		t.Errorf("expected %+v, got %+v", resp, got)
+	}
+}
+
+func TestListObjectsJSON(t *testing.T) {
+	req := cazi.ListObjectsRequest{
+		Subject:    cazi.Subject{Assertion: cazi.Claims{"sub": "alice"}},
+		Verb:       "read",
+		ObjectType: "widget",
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"subject":{"assertion":{"claims":{"sub":"alice"}}},"verb":"read","object_type":"widget"}`
+	if string(data) != want {
+		t.Errorf("expected %s, got %s", want, data)
+	}
+
+	resp := cazi.ListObjectsResponse{Decision: cazi.DecisionDeny}
+	data, err = json.Marshal(resp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != `{"decision":"deny"}` {
+		t.Errorf("expected deny response, got %s", data)
+	}
+}
+
+func TestBatchCheckResponseJSON(t *testing.T) {
+	resp := cazi.BatchCheckResponse{Results: []cazi.BatchCheckResult{
+		{Response: cazi.CheckResponse{Decision: cazi.DecisionAllow}},
+		{Err: fmt.Errorf("%w: delete", cazi.ErrUnsupportedVerb)},
+		{Err: errors.New("boom")},
+	}}
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got cazi.BatchCheckResponse
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got.Results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(got.Results))
+	}
+
+	t.Run("Response", func(t *testing.T) {
+		if r := got.Results[0]; r.Response.Decision != cazi.DecisionAllow || r.Err != nil {
+			t.Errorf("expected allow without error, got %+v", r)
+		}
+	})
+
+	t.Run("Error kind", func(t *testing.T) {
+		err := got.Results[1].Err
+		if !errors.Is(err, cazi.ErrUnsupportedVerb) || !errors.Is(err, cazi.ErrInvalidArgument) {
+			t.Errorf("expected cazi.ErrUnsupportedVerb, got %v", err)
+		}
+		if err.Error() != "unsupported verb: delete" {
+			t.Errorf("expected message 'unsupported verb: delete', got '%v'", err)
+		}
+	})
+
+	t.Run("Error without kind", func(t *testing.T) {
+		err := got.Results[2].Err
+		if err == nil || err.Error() != "boom" {
+			t.Fatalf("expected error 'boom', got %v", err)
+		}
+		if _, ok := cazi.ErrorKind(err); ok {
+			t.Errorf("expected no kind, got %v", err)
+		}
+	})
+}
+
+func TestUnmarshalAssertionErrors(t *testing.T) {
+	tests := map[string]string{
+		"Unknown type":   `{"certificate": {}}`,
+		"Multiple types": `{"claims": {}, "resource_reference": {"type": "user", "id": "a"}}`,
+		"Empty object":   `{}`,
+		"Not an object":  `"alice"`,
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := cazi.UnmarshalAssertion([]byte(data)); err == nil {
+				t.Errorf("expected error for %s", data)
+			}
+		})
+	}
+}
+
+func TestDecisionKindText(t *testing.T) {
+	var d cazi.DecisionKind
+	if err := json.Unmarshal([]byte(`"maybe"`), &d); err == nil {
+		t.Error("expected error for unknown decision")
+	}
+	if err := json.Unmarshal([]byte(`"allow"`), &d); err != nil || d != cazi.DecisionAllow {
+		t.Errorf("expected allow, got %v (err %v)", d, err)
+	}
+}
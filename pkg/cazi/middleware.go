@@ -0,0 +1,172 @@
+package cazi
+
+import (
+	"context"
+)
+
+// Middleware decorates an Interface with cross-cutting behavior such as logging, metrics,
+// caching, retries or auditing.
+//
+// Write middleware with [Intercept] to only handle the operations it cares about
+// while preserving the optional interfaces of the implementation it wraps.
+type Middleware func(next Interface) Interface
+
+// Chain composes middleware into one.
+// The first middleware is outermost: it sees each request first and each response last.
+func Chain(middleware ...Middleware) Middleware {
+	return func(next Interface) Interface {
+		for i := len(middleware) - 1; i >= 0; i-- {
+			next = middleware[i](next)
+		}
+		return next
+	}
+}
+
+// Handler types for each operation, as passed to [Hooks] to continue the chain.
+type (
+	CheckFunc              func(ctx context.Context, req CheckRequest) (CheckResponse, error)
+	ListObjectsFunc        func(ctx context.Context, req ListObjectsRequest) (ListObjectsResponse, error)
+	BatchCheckFunc         func(ctx context.Context, req BatchCheckRequest) (BatchCheckResponse, error)
+	ListSubjectsFunc       func(ctx context.Context, req ListSubjectsRequest) (ListSubjectsResponse, error)
+	WriteRelationshipsFunc func(ctx context.Context, req WriteRelationshipsRequest) (WriteRelationshipsResponse, error)
+	WatchFunc              func(ctx context.Context, req WatchRequest, onChange func(ctx context.Context, change Change) error) error
+)
+
+// Hooks intercept individual operations.
+// Each hook receives the request and the next handler, and decides whether and how to call it.
+// Nil hooks pass operations through unchanged.
+type Hooks struct {
+	Check              func(ctx context.Context, req CheckRequest, next CheckFunc) (CheckResponse, error)
+	ListObjects        func(ctx context.Context, req ListObjectsRequest, next ListObjectsFunc) (ListObjectsResponse, error)
+	BatchCheck         func(ctx context.Context, req BatchCheckRequest, next BatchCheckFunc) (BatchCheckResponse, error)
+	ListSubjects       func(ctx context.Context, req ListSubjectsRequest, next ListSubjectsFunc) (ListSubjectsResponse, error)
+	WriteRelationships func(ctx context.Context, req WriteRelationshipsRequest, next WriteRelationshipsFunc) (WriteRelationshipsResponse, error)
+	Watch              func(ctx context.Context, req WatchRequest, onChange func(ctx context.Context, change Change) error, next WatchFunc) error
+}
+
+// Intercept returns middleware that calls hooks around the operations of the implementation it wraps.
+//
+// The wrapped implementation always implements [BatchChecker].
+// If the next implementation does not, each item is checked with the Check hook.
+// [SubjectLister], [RelationshipWriter] and [Watcher] are implemented only if the next implementation does,
+// so callers can still detect them with a type assertion.
+func Intercept(hooks Hooks) Middleware {
+	return func(next Interface) Interface {
+		i := &intercepted{next: next, hooks: hooks}
+		_, lists := next.(SubjectLister)
+		_, writes := next.(RelationshipWriter)
+		_, watches := next.(Watcher)
+
+		switch {
+		case lists && writes && watches:
+			return struct {
+				*intercepted
+				subjectLister
+				relationshipWriter
+				watcher
+			}{i, subjectLister{i}, relationshipWriter{i}, watcher{i}}
+		case lists && writes:
+			return struct {
+				*intercepted
+				subjectLister
+				relationshipWriter
+			}{i, subjectLister{i}, relationshipWriter{i}}
+		case lists && watches:
+			return struct {
+				*intercepted
+				subjectLister
+				watcher
+			}{i, subjectLister{i}, watcher{i}}
+		case writes && watches:
+			return struct {
+				*intercepted
+				relationshipWriter
+				watcher
+			}{i, relationshipWriter{i}, watcher{i}}
+		case lists:
+			return struct {
+				*intercepted
+				subjectLister
+			}{i, subjectLister{i}}
+		case writes:
+			return struct {
+				*intercepted
+				relationshipWriter
+			}{i, relationshipWriter{i}}
+		case watches:
+			return struct {
+				*intercepted
+				watcher
+			}{i, watcher{i}}
+		default:
+			return i
+		}
+	}
+}
+
+// intercepted implements Interface and BatchChecker by calling hooks around next.
+type intercepted struct {
+	next  Interface
+	hooks Hooks
+}
+
+func (i *intercepted) Check(ctx context.Context, req CheckRequest) (CheckResponse, error) {
+	if i.hooks.Check == nil {
+		return i.next.Check(ctx, req)
+	}
+	return i.hooks.Check(ctx, req, i.next.Check)
+}
+
+func (i *intercepted) ListObjects(ctx context.Context, req ListObjectsRequest) (ListObjectsResponse, error) {
+	if i.hooks.ListObjects == nil {
+		return i.next.ListObjects(ctx, req)
+	}
+	return i.hooks.ListObjects(ctx, req, i.next.ListObjects)
+}
+
+func (i *intercepted) BatchCheck(ctx context.Context, req BatchCheckRequest) (BatchCheckResponse, error) {
+	next := i.batchCheck
+	if i.hooks.BatchCheck == nil {
+		return next(ctx, req)
+	}
+	return i.hooks.BatchCheck(ctx, req, next)
+}
+
+// batchCheck delegates to next if it implements BatchChecker,
+// otherwise checks each item through the Check hook.
+func (i *intercepted) batchCheck(ctx context.Context, req BatchCheckRequest) (BatchCheckResponse, error) {
+	if batcher, ok := i.next.(BatchChecker); ok {
+		return batcher.BatchCheck(ctx, req)
+	}
+	return CheckEach(ctx, i, req, DefaultBatchConcurrency), nil
+}
+
+type subjectLister struct{ i *intercepted }
+
+func (l subjectLister) ListSubjects(ctx context.Context, req ListSubjectsRequest) (ListSubjectsResponse, error) {
+	next := l.i.next.(SubjectLister).ListSubjects
+	if l.i.hooks.ListSubjects == nil {
+		return next(ctx, req)
+	}
+	return l.i.hooks.ListSubjects(ctx, req, next)
+}
+
+type relationshipWriter struct{ i *intercepted }
+
+func (w relationshipWriter) WriteRelationships(ctx context.Context, req WriteRelationshipsRequest) (WriteRelationshipsResponse, error) {
+	next := w.i.next.(RelationshipWriter).WriteRelationships
+	if w.i.hooks.WriteRelationships == nil {
+		return next(ctx, req)
+	}
+	return w.i.hooks.WriteRelationships(ctx, req, next)
+}
+
+type watcher struct{ i *intercepted }
+
+func (w watcher) Watch(ctx context.Context, req WatchRequest, onChange func(ctx context.Context, change Change) error) error {
+	next := w.i.next.(Watcher).Watch
+	if w.i.hooks.Watch == nil {
+		return next(ctx, req, onChange)
+	}
+	return w.i.hooks.Watch(ctx, req, onChange, next)
+}
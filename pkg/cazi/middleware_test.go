@@ -0,0 +1,159 @@
+package cazi_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+)
+
+// writingAuthz implements cazi.RelationshipWriter.
+type writingAuthz struct {
+	countingAuthz
+	writes int
+}
+
+func (a *writingAuthz) WriteRelationships(ctx context.Context, req cazi.WriteRelationshipsRequest) (cazi.WriteRelationshipsResponse, error) {
+	a.writes++
+	return cazi.WriteRelationshipsResponse{WrittenAt: cazi.ConsistencyToken("1")}, nil
+}
+
+// recorder returns middleware that appends "<name> before" and "<name> after" to log around each Check.
+func recorder(name string, mu *sync.Mutex, log *[]string) cazi.Middleware {
+	record := func(s string) {
+		mu.Lock()
+		defer mu.Unlock()
+		*log = append(*log, s)
+	}
+	return cazi.Intercept(cazi.Hooks{
+		Check: func(ctx context.Context, req cazi.CheckRequest, next cazi.CheckFunc) (cazi.CheckResponse, error) {
+			record(name + " before")
+			defer record(name + " after")
+			return next(ctx, req)
+		},
+	})
+}
+
+func TestChain(t *testing.T) {
+	var mu sync.Mutex
+	var log []string
+	authz := cazi.Chain(recorder("outer", &mu, &log), recorder("inner", &mu, &log))(&countingAuthz{})
+
+	resp, err := authz.Check(context.Background(), widgetChecks("2").Items[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Decision != cazi.DecisionAllow {
+		t.Errorf("expected allow, got %v", resp.Decision)
+	}
+
+	want := []string{"outer before", "inner before", "inner after", "outer after"}
+	if !slices.Equal(log, want) {
+		t.Errorf("expected %v, got %v", want, log)
+	}
+
+	t.Run("Empty chain returns the implementation", func(t *testing.T) {
+		next := &countingAuthz{}
+		if authz := cazi.Chain()(next); authz != next {
+			t.Errorf("expected %v, got %v", next, authz)
+		}
+	})
+}
+
+func TestIntercept(t *testing.T) {
+	t.Run("Hooks can short-circuit", func(t *testing.T) {
+		denied := errors.New("denied by middleware")
+		authz := cazi.Intercept(cazi.Hooks{
+			ListObjects: func(ctx context.Context, req cazi.ListObjectsRequest, next cazi.ListObjectsFunc) (cazi.ListObjectsResponse, error) {
+				return cazi.ListObjectsResponse{}, denied
+			},
+		})(&countingAuthz{})
+
+		if _, err := authz.ListObjects(context.Background(), cazi.ListObjectsRequest{}); !errors.Is(err, denied) {
+			t.Errorf("expected %v, got %v", denied, err)
+		}
+	})
+
+	t.Run("Nil hooks pass through", func(t *testing.T) {
+		authz := cazi.Intercept(cazi.Hooks{})(&countingAuthz{})
+
+		resp, err := authz.Check(context.Background(), widgetChecks("1").Items[0])
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.Decision != cazi.DecisionDeny {
+			t.Errorf("expected deny, got %v", resp.Decision)
+		}
+	})
+
+	t.Run("Batch items go through the Check hook", func(t *testing.T) {
+		var mu sync.Mutex
+		var log []string
+		authz := recorder("hook", &mu, &log)(&countingAuthz{})
+
+		resp, err := cazi.BatchCheck(context.Background(), authz, widgetChecks("1", "2", "3"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(resp.Results) != 3 {
+			t.Fatalf("expected 3 results, got %d", len(resp.Results))
+		}
+		if len(log) != 6 {
+			t.Errorf("expected 3 intercepted checks, got %v", log)
+		}
+	})
+
+	t.Run("Batches are delegated to a BatchChecker", func(t *testing.T) {
+		next := &batchingAuthz{}
+		var calls int
+		authz := cazi.Intercept(cazi.Hooks{
+			BatchCheck: func(ctx context.Context, req cazi.BatchCheckRequest, next cazi.BatchCheckFunc) (cazi.BatchCheckResponse, error) {
+				calls++
+				return next(ctx, req)
+			},
+		})(next)
+
+		if _, err := cazi.BatchCheck(context.Background(), authz, widgetChecks("1", "2")); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if calls != 1 || next.batches != 1 {
+			t.Errorf("expected 1 hook call and 1 batch, got %d and %d", calls, next.batches)
+		}
+	})
+
+	t.Run("Optional interfaces are preserved", func(t *testing.T) {
+		next := &writingAuthz{}
+		var hooked bool
+		authz := cazi.Intercept(cazi.Hooks{
+			WriteRelationships: func(ctx context.Context, req cazi.WriteRelationshipsRequest, next cazi.WriteRelationshipsFunc) (cazi.WriteRelationshipsResponse, error) {
+				hooked = true
+				return next(ctx, req)
+			},
+		})(next)
+
+		writer, ok := authz.(cazi.RelationshipWriter)
+		if !ok {
+			t.Fatal("expected cazi.RelationshipWriter")
+		}
+		resp, err := writer.WriteRelationships(context.Background(), cazi.WriteRelationshipsRequest{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !hooked || next.writes != 1 || string(resp.WrittenAt) != "1" {
+			t.Errorf("expected hooked write, got hooked=%v writes=%d resp=%+v", hooked, next.writes, resp)
+		}
+
+		if _, ok := authz.(cazi.SubjectLister); ok {
+			t.Error("expected no cazi.SubjectLister")
+		}
+		if _, ok := authz.(cazi.Watcher); ok {
+			t.Error("expected no cazi.Watcher")
+		}
+		if _, err := cazi.ListSubjects(context.Background(), authz, cazi.ListSubjectsRequest{}); !errors.Is(err, errors.ErrUnsupported) {
+			t.Errorf("expected errors.ErrUnsupported, got %v", err)
+		}
+	})
+}
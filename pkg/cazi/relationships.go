@@ -0,0 +1,108 @@
+package cazi
+
+import (
+	"context"
+	"fmt"
+)
+
+// RelationshipWriter is an optional interface for implementations that store
+// relationships (Zanzibar-style tuples), so applications can tell the authorizer
+// about authorization facts such as ownership.
+type RelationshipWriter interface {
+	// WriteRelationships atomically applies all updates if all preconditions hold.
+	//
+	// The returned WrittenAt token can be passed as AtLeastAsFresh on later requests
+	// to ensure they observe the write.
+	// Returns an error wrapping [ErrPreconditionFailed] if a precondition does not hold,
+	// or [ErrRelationshipExists] if a create operation targets an existing relationship.
+	WriteRelationships(ctx context.Context, req WriteRelationshipsRequest) (WriteRelationshipsResponse, error)
+}
+
+var (
+	// ErrPreconditionFailed is returned when a write precondition does not hold.
+	ErrPreconditionFailed = &Error{Code: "precondition_failed", msg: "precondition failed"}
+
+	// ErrRelationshipExists is returned when a create operation targets an existing relationship.
+	ErrRelationshipExists = &Error{Code: "relationship_exists", msg: "relationship already exists"}
+)
+
+// Relationship states that a subject has a relation to an object,
+// e.g. user:alice is owner of widget:w1, or members of group:eng are viewers of widget:w1.
+type Relationship struct {
+	Object          ResourceReference `json:"object"`
+	Relation        string            `json:"relation"`
+	Subject         ResourceReference `json:"subject"`
+	SubjectRelation string            `json:"subject_relation,omitempty"` // optional relation of a subject set (e.g., "member")
+}
+
+func (r Relationship) String() string {
+	s := fmt.Sprintf("%s:%s#%s@%s:%s", r.Object.Type, r.Object.ID, r.Relation, r.Subject.Type, r.Subject.ID)
+	if r.SubjectRelation != "" {
+		s += "#" + r.SubjectRelation
+	}
+	return s
+}
+
+// RelationshipOperation is the kind of change made by a RelationshipUpdate.
+type RelationshipOperation int
+
+const (
+	OperationTouch  RelationshipOperation = iota // create the relationship, or do nothing if it exists
+	OperationCreate                              // create the relationship, failing if it exists
+	OperationDelete                              // delete the relationship, or do nothing if it doesn't exist
+)
+
+// RelationshipUpdate is a single change to a relationship.
+type RelationshipUpdate struct {
+	Operation    RelationshipOperation `json:"operation"`
+	Relationship Relationship          `json:"relationship"`
+}
+
+// RelationshipFilter matches relationships. Empty fields match any value.
+type RelationshipFilter struct {
+	ObjectType      string `json:"object_type,omitempty"`
+	ObjectID        string `json:"object_id,omitempty"`
+	Relation        string `json:"relation,omitempty"`
+	SubjectType     string `json:"subject_type,omitempty"`
+	SubjectID       string `json:"subject_id,omitempty"`
+	SubjectRelation string `json:"subject_relation,omitempty"`
+}
+
+// Matches reports whether the relationship matches the filter.
+func (f RelationshipFilter) Matches(r Relationship) bool {
+	return matchField(f.ObjectType, r.Object.Type) &&
+		matchField(f.ObjectID, r.Object.ID) &&
+		matchField(f.Relation, r.Relation) &&
+		matchField(f.SubjectType, r.Subject.Type) &&
+		matchField(f.SubjectID, r.Subject.ID) &&
+		matchField(f.SubjectRelation, r.SubjectRelation)
+}
+
+func matchField(filter, value string) bool {
+	return filter == "" || filter == value
+}
+
+// PreconditionOperation is the kind of check made by a Precondition.
+type PreconditionOperation int
+
+const (
+	PreconditionMustMatch    PreconditionOperation = iota // at least one relationship must match the filter
+	PreconditionMustNotMatch                              // no relationship may match the filter
+)
+
+// Precondition must hold for a write to be applied.
+type Precondition struct {
+	Operation PreconditionOperation `json:"operation"`
+	Filter    RelationshipFilter    `json:"filter"`
+}
+
+// WriteRelationshipsRequest captures the inputs to a relationship write.
+type WriteRelationshipsRequest struct {
+	Updates       []RelationshipUpdate `json:"updates"`
+	Preconditions []Precondition       `json:"preconditions,omitempty"`
+}
+
+// WriteRelationshipsResponse captures the outputs of a relationship write.
+type WriteRelationshipsResponse struct {
+	WrittenAt ConsistencyToken `json:"written_at"` // token at which the write is visible
+}
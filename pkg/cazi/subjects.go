@@ -0,0 +1,50 @@
+package cazi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// SubjectLister is an optional interface for implementations that can answer
+// "who can perform a verb on an object" (reverse lookup).
+// Use [ListSubjects] to call it.
+type SubjectLister interface {
+	// ListSubjects returns the subjects of a given type that can perform a verb on an object.
+	//
+	// Like [ListObjectsResponse], the result may be a concrete set of subjects
+	// or a conditional expression the caller evaluates against its own data.
+	ListSubjects(ctx context.Context, req ListSubjectsRequest) (ListSubjectsResponse, error)
+}
+
+// ListSubjectsRequest captures the inputs to a subject listing.
+type ListSubjectsRequest struct {
+	Object          Object           `json:"object"`                      // object assertion
+	Verb            string           `json:"verb"`                        // verb/relation
+	SubjectType     string           `json:"subject_type"`                // type of subjects to list (e.g., "user")
+	SubjectRelation string           `json:"subject_relation,omitempty"`  // optional relation of subject sets (e.g., "member")
+	AtLeastAsFresh  ConsistencyToken `json:"at_least_as_fresh,omitempty"` // optional opaque token for causal consistency
+}
+
+// ListSubjectsResponse captures the outputs of a subject listing.
+//
+//   - DecisionAllow: Subjects is the complete set of subjects (which may be empty).
+//   - DecisionDeny: no subject can perform the verb.
+//   - DecisionConditional: Condition selects the subjects, e.g. "user.id == widget.owner_id".
+type ListSubjectsResponse struct {
+	Decision         DecisionKind         `json:"decision"`                    // allow/deny/conditional
+	Subjects         []ResourceReference  `json:"subjects,omitempty"`          // present when DecisionAllow
+	Condition        Expression           `json:"condition,omitzero"`          // present when DecisionConditional (check Language != "" to detect if set)
+	Context          AuthorizationContext `json:"context,omitzero"`            // additional context about the authorization decision
+	ConsistencyToken ConsistencyToken     `json:"consistency_token,omitempty"` // token representing the freshness of this authorization decision (check len > 0 to detect if set)
+}
+
+// ListSubjects lists the subjects that can perform a verb on an object.
+// If authz does not implement [SubjectLister], an error wrapping [errors.ErrUnsupported] is returned.
+func ListSubjects(ctx context.Context, authz Interface, req ListSubjectsRequest) (ListSubjectsResponse, error) {
+	lister, ok := authz.(SubjectLister)
+	if !ok {
+		return ListSubjectsResponse{}, fmt.Errorf("%T does not support ListSubjects: %w", authz, errors.ErrUnsupported)
+	}
+	return lister.ListSubjects(ctx, req)
+}
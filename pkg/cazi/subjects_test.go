@@ -0,0 +1,16 @@
+package cazi_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+)
+
+func TestListSubjectsUnsupported(t *testing.T) {
+	_, err := cazi.ListSubjects(context.Background(), &countingAuthz{}, cazi.ListSubjectsRequest{Verb: "read"})
+	if !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("expected errors.ErrUnsupported, got %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package cazi
+
+// ErrVersionConflict is returned when a write is based on a version that is no longer current.
+// The write may succeed if retried based on the current version.
+var ErrVersionConflict = &Error{Code: "version_conflict", msg: "version conflict"}
+
+// Version is a version vector: for each writer (e.g. an application instance),
+// the number of writes it has made to a value.
+//
+// Versions are partially ordered. A version that has seen all of another version's writes
+// is after it; versions where each has seen writes the other hasn't are concurrent.
+// The zero value is the version of a value that has never been written.
+type Version map[string]uint64
+
+// VersionOrder is the result of comparing two versions.
+type VersionOrder int
+
+const (
+	VersionEqual      VersionOrder = iota // both versions have seen the same writes
+	VersionBefore                         // the other version has seen every write this one has, and more
+	VersionAfter                          // this version has seen every write the other has, and more
+	VersionConcurrent                     // each version has seen writes the other hasn't
+)
+
+// Compare returns how v is ordered relative to other.
+func (v Version) Compare(other Version) VersionOrder {
+	before, after := false, false
+	for writer, n := range v {
+		if n > other[writer] {
+			after = true
+		}
+	}
+	for writer, n := range other {
+		if n > v[writer] {
+			before = true
+		}
+	}
+	switch {
+	case before && after:
+		return VersionConcurrent
+	case before:
+		return VersionBefore
+	case after:
+		return VersionAfter
+	default:
+		return VersionEqual
+	}
+}
+
+// Increment returns a copy of v with one more write by writer.
+func (v Version) Increment(writer string) Version {
+	next := make(Version, len(v)+1)
+	for w, n := range v {
+		next[w] = n
+	}
+	next[writer]++
+	return next
+}
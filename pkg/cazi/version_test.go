@@ -0,0 +1,46 @@
+package cazi_test
+
+import (
+	"testing"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+)
+
+func TestVersionCompare(t *testing.T) {
+	tests := []struct {
+		name  string
+		v     cazi.Version
+		other cazi.Version
+		want  cazi.VersionOrder
+	}{
+		{"Both zero", nil, cazi.Version{}, cazi.VersionEqual},
+		{"Equal", cazi.Version{"a": 1, "b": 2}, cazi.Version{"a": 1, "b": 2}, cazi.VersionEqual},
+		{"Zero entries are absent", cazi.Version{"a": 1, "b": 0}, cazi.Version{"a": 1}, cazi.VersionEqual},
+		{"Before", cazi.Version{"a": 1}, cazi.Version{"a": 1, "b": 1}, cazi.VersionBefore},
+		{"After", cazi.Version{"a": 2, "b": 1}, cazi.Version{"a": 1, "b": 1}, cazi.VersionAfter},
+		{"Concurrent", cazi.Version{"a": 2}, cazi.Version{"a": 1, "b": 1}, cazi.VersionConcurrent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.v.Compare(tt.other); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestVersionIncrement(t *testing.T) {
+	v := cazi.Version{"a": 1}
+	next := v.Increment("b")
+
+	if v["b"] != 0 {
+		t.Errorf("expected Increment not to modify the original, got %v", v)
+	}
+	if next.Compare(v) != cazi.VersionAfter {
+		t.Errorf("expected incremented version to be after the original, got %v", next)
+	}
+	if next["a"] != 1 || next["b"] != 1 {
+		t.Errorf("expected {a:1 b:1}, got %v", next)
+	}
+}
@@ -0,0 +1,42 @@
+package cazi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Watcher is an optional interface for implementations that can stream changes to authorization data,
+// so callers can keep caches and search indexes up to date.
+// Use [Watch] to call it.
+type Watcher interface {
+	// Watch calls onChange for each change after req.StartAfter, in the order the changes became visible,
+	// until ctx is done or onChange returns an error. It returns ctx.Err() or the error from onChange.
+	//
+	// Changes are reported as [OperationTouch] (the relationship now exists)
+	// or [OperationDelete] (it no longer exists).
+	// Passing a change's ChangedAt as StartAfter resumes the stream after that change.
+	Watch(ctx context.Context, req WatchRequest, onChange func(ctx context.Context, change Change) error) error
+}
+
+// WatchRequest captures the inputs to a watch.
+type WatchRequest struct {
+	ObjectTypes []string         `json:"object_types,omitempty"` // optional; only changes to objects of these types
+	StartAfter  ConsistencyToken `json:"start_after,omitempty"`  // optional; if empty, only changes made after the watch starts
+}
+
+// Change is a set of updates that became visible together.
+type Change struct {
+	Updates   []RelationshipUpdate `json:"updates"`
+	ChangedAt ConsistencyToken     `json:"changed_at"` // token at which the change is visible
+}
+
+// Watch streams changes to authorization data.
+// If authz does not implement [Watcher], an error wrapping [errors.ErrUnsupported] is returned.
+func Watch(ctx context.Context, authz Interface, req WatchRequest, onChange func(ctx context.Context, change Change) error) error {
+	watcher, ok := authz.(Watcher)
+	if !ok {
+		return fmt.Errorf("%T does not support Watch: %w", authz, errors.ErrUnsupported)
+	}
+	return watcher.Watch(ctx, req, onChange)
+}
@@ -0,0 +1,19 @@
+package cazi_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+)
+
+func TestWatchUnsupported(t *testing.T) {
+	err := cazi.Watch(context.Background(), &countingAuthz{}, cazi.WatchRequest{}, func(context.Context, cazi.Change) error {
+		t.Error("unexpected change")
+		return nil
+	})
+	if !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("expected errors.ErrUnsupported, got %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package cazigrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	caziv1 "github.com/alechenninger/cazi/gen/go/cazi/v1"
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Client implements cazi.Interface by calling a remote CommonAuthorizationInterface gRPC service.
+//
+// Deadlines, cancellation and OpenTelemetry trace context are propagated from the ctx passed to each call.
+// Consistency tokens are passed through as opaque bytes.
+type Client struct {
+	client caziv1.CommonAuthorizationInterfaceClient
+}
+
+var (
+	_ cazi.Interface = (*Client)(nil)
+	_ cazi.Watcher   = (*Client)(nil)
+)
+
+// NewClient creates a client using the given connection (e.g. from grpc.NewClient).
+// The caller owns the connection and is responsible for closing it.
+func NewClient(conn grpc.ClientConnInterface) *Client {
+	return &Client{client: caziv1.NewCommonAuthorizationInterfaceClient(conn)}
+}
+
+// Check implements cazi.Interface.
+func (c *Client) Check(ctx context.Context, req cazi.CheckRequest) (cazi.CheckResponse, error) {
+	in, err := CheckRequestToProto(req)
+	if err != nil {
+		return cazi.CheckResponse{}, fmt.Errorf("invalid check request: %w", err)
+	}
+
+	out, err := c.client.Check(injectTraceContext(ctx), in)
+	if err != nil {
+		return cazi.CheckResponse{}, fromStatus(err)
+	}
+	return CheckResponseFromProto(out), nil
+}
+
+// ListObjects implements cazi.Interface.
+func (c *Client) ListObjects(ctx context.Context, req cazi.ListObjectsRequest) (cazi.ListObjectsResponse, error) {
+	in, err := ListObjectsRequestToProto(req)
+	if err != nil {
+		return cazi.ListObjectsResponse{}, fmt.Errorf("invalid list objects request: %w", err)
+	}
+
+	out, err := c.client.ListObjects(injectTraceContext(ctx), in)
+	if err != nil {
+		return cazi.ListObjectsResponse{}, fromStatus(err)
+	}
+	return ListObjectsResponseFromProto(out), nil
+}
+
+// Watch implements cazi.Watcher.
+// If the server does not support watching, the returned error matches errors.ErrUnsupported.
+func (c *Client) Watch(ctx context.Context, req cazi.WatchRequest, onChange func(ctx context.Context, change cazi.Change) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := c.client.Watch(injectTraceContext(ctx), WatchRequestToProto(req))
+	if err != nil {
+		return fromStatus(err)
+	}
+	for {
+		out, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fromStatus(err)
+		}
+		change, err := ChangeFromProto(out)
+		if err != nil {
+			return fmt.Errorf("invalid change: %w", err)
+		}
+		if err := onChange(ctx, change); err != nil {
+			return err
+		}
+	}
+}
+
+// Error is returned by Client when the server responds with a non-OK status.
+// Use errors.As to inspect the code.
+// Canceled and DeadlineExceeded errors also match context.Canceled and context.DeadlineExceeded with errors.Is,
+// and Unimplemented errors match errors.ErrUnsupported.
+// Errors of a known kind match it with errors.Is, e.g. cazi.ErrUnsupportedVerb or cazi.ErrUnavailable.
+type Error struct {
+	Code    codes.Code
+	Message string
+	Kind    *cazi.Error // the kind of error reported by the server, if known
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("cazi rpc error: code = %s desc = %s", e.Code, e.Message)
+}
+
+// GRPCStatus returns the status the error was created from,
+// so that status.FromError and status.Code keep working.
+func (e *Error) GRPCStatus() *status.Status {
+	st := status.New(e.Code, e.Message)
+	if e.Kind != nil {
+		st = withKind(st, e.Kind)
+	}
+	return st
+}
+
+// Is reports whether the error matches target.
+func (e *Error) Is(target error) bool {
+	switch target {
+	case context.Canceled:
+		return e.Code == codes.Canceled
+	case context.DeadlineExceeded:
+		return e.Code == codes.DeadlineExceeded
+	case errors.ErrUnsupported:
+		return e.Code == codes.Unimplemented
+	}
+	return false
+}
+
+// Unwrap returns the kind of the error, if known.
+func (e *Error) Unwrap() error {
+	if e.Kind == nil {
+		return nil
+	}
+	return e.Kind
+}
+
+// fromStatus converts a gRPC error to an *Error.
+func fromStatus(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	return &Error{Code: st.Code(), Message: st.Message(), Kind: kindOf(st)}
+}
@@ -0,0 +1,176 @@
+package cazigrpc_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"github.com/alechenninger/cazi/pkg/cazigrpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// blockingAuthz blocks until the request context is done and reports whether it had a deadline.
+type blockingAuthz struct {
+	hadDeadline chan bool
+}
+
+func (b *blockingAuthz) Check(ctx context.Context, req cazi.CheckRequest) (cazi.CheckResponse, error) {
+	_, ok := ctx.Deadline()
+	b.hadDeadline <- ok
+	<-ctx.Done()
+	return cazi.CheckResponse{}, ctx.Err()
+}
+
+func (b *blockingAuthz) ListObjects(ctx context.Context, req cazi.ListObjectsRequest) (cazi.ListObjectsResponse, error) {
+	_, ok := ctx.Deadline()
+	b.hadDeadline <- ok
+	<-ctx.Done()
+	return cazi.ListObjectsResponse{}, ctx.Err()
+}
+
+func TestClientCheck(t *testing.T) {
+	token := cazi.ConsistencyToken{0x00, 0xfe, 0xff, 0x7f}
+	want := cazi.CheckResponse{
+		Decision:  cazi.DecisionConditional,
+		Condition: cazi.Expression{Language: "cel", Expression: "widget.owner_id == 'alice'"},
+		Context: cazi.AuthorizationContext{
+			RequesterContext: cazi.Claims{"sub": "alice"},
+		},
+		ConsistencyToken: token,
+	}
+	authz := &stubAuthz{checkResp: want}
+	client := cazigrpc.NewClient(startServer(t, authz))
+
+	req := cazi.CheckRequest{
+		Subject:        cazi.Subject{Assertion: cazi.ResourceReference{Type: "user", ID: "alice"}},
+		Verb:           "read",
+		Object:         cazi.Object{Assertion: cazi.ResourceReference{Type: "widget", ID: "w1"}},
+		AtLeastAsFresh: token,
+	}
+	got, err := client.Check(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(authz.checkReq, req) {
+		t.Errorf("expected server to receive %+v, got %+v", req, authz.checkReq)
+	}
+	if !bytes.Equal(authz.checkReq.AtLeastAsFresh, token) {
+		t.Errorf("expected AtLeastAsFresh bytes %x, got %x", token, authz.checkReq.AtLeastAsFresh)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestClientListObjects(t *testing.T) {
+	want := cazi.ListObjectsResponse{
+		Decision:         cazi.DecisionConditional,
+		Condition:        cazi.Expression{Language: "cel", Expression: "widget.owner_id == 'alice'"},
+		ConsistencyToken: cazi.ConsistencyToken("rev-2"),
+	}
+	authz := &stubAuthz{listResp: want}
+	client := cazigrpc.NewClient(startServer(t, authz))
+
+	req := cazi.ListObjectsRequest{
+		Subject:    cazi.Subject{Assertion: cazi.Claims{"sub": "alice"}},
+		Verb:       "read",
+		ObjectType: "widget",
+		Filter:     cazi.Expression{Language: "cel", Expression: "widget.name != ''"},
+	}
+	got, err := client.ListObjects(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(authz.listReq, req) {
+		t.Errorf("expected server to receive %+v, got %+v", req, authz.listReq)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestClientPropagatesDeadline(t *testing.T) {
+	authz := &blockingAuthz{hadDeadline: make(chan bool, 1)}
+	client := cazigrpc.NewClient(startServer(t, authz))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.Check(ctx, cazi.CheckRequest{Verb: "read"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if !<-authz.hadDeadline {
+		t.Error("expected server context to carry the client deadline")
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	authz := &stubAuthz{err: status.Error(codes.Unavailable, "pdp down")}
+	client := cazigrpc.NewClient(startServer(t, authz))
+
+	_, err := client.Check(context.Background(), cazi.CheckRequest{Verb: "read"})
+
+	var rpcErr *cazigrpc.Error
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("expected *cazigrpc.Error, got %T: %v", err, err)
+	}
+	if rpcErr.Code != codes.Unavailable {
+		t.Errorf("expected code Unavailable, got %v", rpcErr.Code)
+	}
+	if rpcErr.Message != "pdp down" {
+		t.Errorf("expected message 'pdp down', got '%s'", rpcErr.Message)
+	}
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("expected status.Code to report Unavailable, got %v", status.Code(err))
+	}
+}
+
+func TestClientErrorKinds(t *testing.T) {
+	t.Run("Kinds", func(t *testing.T) {
+		for _, kind := range []*cazi.Error{
+			cazi.ErrUnsupportedVerb,
+			cazi.ErrTokenTooNew,
+			cazi.ErrUnavailable,
+			cazi.ErrPreconditionFailed,
+			cazi.ErrRelationshipExists,
+			cazi.ErrVersionConflict,
+		} {
+			authz := &stubAuthz{err: fmt.Errorf("%w: details", kind)}
+			client := cazigrpc.NewClient(startServer(t, authz))
+
+			_, err := client.Check(context.Background(), cazi.CheckRequest{Verb: "read"})
+			if !errors.Is(err, kind) {
+				t.Errorf("expected %v, got %v", kind, err)
+			}
+		}
+	})
+
+	t.Run("Refined kinds match their parent", func(t *testing.T) {
+		authz := &stubAuthz{err: fmt.Errorf("%w: delete", cazi.ErrUnsupportedVerb)}
+		client := cazigrpc.NewClient(startServer(t, authz))
+
+		_, err := client.Check(context.Background(), cazi.CheckRequest{Verb: "delete"})
+		if !errors.Is(err, cazi.ErrInvalidArgument) {
+			t.Errorf("expected cazi.ErrInvalidArgument, got %v", err)
+		}
+	})
+
+	t.Run("Kind inferred from code", func(t *testing.T) {
+		authz := &stubAuthz{err: status.Error(codes.InvalidArgument, "bad request")}
+		client := cazigrpc.NewClient(startServer(t, authz))
+
+		_, err := client.Check(context.Background(), cazi.CheckRequest{Verb: "read"})
+		if !errors.Is(err, cazi.ErrInvalidArgument) || errors.Is(err, cazi.ErrUnsupportedVerb) {
+			t.Errorf("expected only cazi.ErrInvalidArgument, got %v", err)
+		}
+	})
+}
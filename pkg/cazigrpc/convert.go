@@ -0,0 +1,536 @@
+package cazigrpc
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	caziv1 "github.com/alechenninger/cazi/gen/go/cazi/v1"
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Conversions between cazi types and their protobuf representations.
+//
+// Claims are carried as google.protobuf.Struct, which has JSON semantics:
+// numbers come back as float64, lists as []any and objects as map[string]any,
+// exactly as if the claims had been decoded with encoding/json.
+
+// CheckRequestToProto converts a cazi.CheckRequest to its protobuf form.
+func CheckRequestToProto(req cazi.CheckRequest) (*caziv1.CheckRequest, error) {
+	subject, err := SubjectToProto(req.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("subject: %w", err)
+	}
+	object, err := AssertionToProto(req.Object.Assertion)
+	if err != nil {
+		return nil, fmt.Errorf("object: %w", err)
+	}
+	return &caziv1.CheckRequest{
+		Subject:        subject,
+		Verb:           req.Verb,
+		Object:         &caziv1.Object{Assertion: object},
+		AtLeastAsFresh: ConsistencyTokenToProto(req.AtLeastAsFresh),
+		Consistency:    ConsistencyToProto(req.Consistency),
+		Explain:        req.Explain,
+	}, nil
+}
+
+// CheckRequestFromProto converts a protobuf CheckRequest to a cazi.CheckRequest.
+func CheckRequestFromProto(req *caziv1.CheckRequest) (cazi.CheckRequest, error) {
+	subject, err := SubjectFromProto(req.GetSubject())
+	if err != nil {
+		return cazi.CheckRequest{}, fmt.Errorf("subject: %w", err)
+	}
+	object, err := AssertionFromProto(req.GetObject().GetAssertion())
+	if err != nil {
+		return cazi.CheckRequest{}, fmt.Errorf("object: %w", err)
+	}
+	return cazi.CheckRequest{
+		Subject:        subject,
+		Verb:           req.GetVerb(),
+		Object:         cazi.Object{Assertion: object},
+		AtLeastAsFresh: ConsistencyTokenFromProto(req.GetAtLeastAsFresh()),
+		Consistency:    ConsistencyFromProto(req.GetConsistency()),
+		Explain:        req.GetExplain(),
+	}, nil
+}
+
+// CheckResponseToProto converts a cazi.CheckResponse to its protobuf form.
+func CheckResponseToProto(resp cazi.CheckResponse) (*caziv1.CheckResponse, error) {
+	authzCtx, err := AuthorizationContextToProto(resp.Context)
+	if err != nil {
+		return nil, fmt.Errorf("context: %w", err)
+	}
+	return &caziv1.CheckResponse{
+		Decision:         DecisionKindToProto(resp.Decision),
+		Condition:        ExpressionToProto(resp.Condition),
+		Context:          authzCtx,
+		ConsistencyToken: ConsistencyTokenToProto(resp.ConsistencyToken),
+		Explanation:      ExplanationToProto(resp.Explanation),
+	}, nil
+}
+
+// CheckResponseFromProto converts a protobuf CheckResponse to a cazi.CheckResponse.
+func CheckResponseFromProto(resp *caziv1.CheckResponse) cazi.CheckResponse {
+	return cazi.CheckResponse{
+		Decision:         DecisionKindFromProto(resp.GetDecision()),
+		Condition:        ExpressionFromProto(resp.GetCondition()),
+		Context:          AuthorizationContextFromProto(resp.GetContext()),
+		ConsistencyToken: ConsistencyTokenFromProto(resp.GetConsistencyToken()),
+		Explanation:      ExplanationFromProto(resp.GetExplanation()),
+	}
+}
+
+// ExplanationToProto converts a cazi.Explanation to its protobuf form. A nil explanation is converted to nil.
+func ExplanationToProto(e *cazi.Explanation) *caziv1.Explanation {
+	if e == nil {
+		return nil
+	}
+	out := &caziv1.Explanation{
+		Description: e.Description,
+		Decision:    DecisionKindToProto(e.Decision),
+		Condition:   ExpressionToProto(e.Condition),
+	}
+	if e.Relationship != nil {
+		out.Relationship = RelationshipToProto(*e.Relationship)
+	}
+	for _, step := range e.Steps {
+		out.Steps = append(out.Steps, ExplanationToProto(&step))
+	}
+	return out
+}
+
+// ExplanationFromProto converts a protobuf Explanation to a cazi.Explanation. A nil explanation is converted to nil.
+func ExplanationFromProto(e *caziv1.Explanation) *cazi.Explanation {
+	if e == nil {
+		return nil
+	}
+	out := &cazi.Explanation{
+		Description: e.GetDescription(),
+		Decision:    DecisionKindFromProto(e.GetDecision()),
+		Condition:   ExpressionFromProto(e.GetCondition()),
+	}
+	if e.GetRelationship() != nil {
+		r := RelationshipFromProto(e.GetRelationship())
+		out.Relationship = &r
+	}
+	for _, step := range e.GetSteps() {
+		out.Steps = append(out.Steps, *ExplanationFromProto(step))
+	}
+	return out
+}
+
+// ListObjectsRequestToProto converts a cazi.ListObjectsRequest to its protobuf form.
+func ListObjectsRequestToProto(req cazi.ListObjectsRequest) (*caziv1.ListObjectsRequest, error) {
+	subject, err := SubjectToProto(req.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("subject: %w", err)
+	}
+	return &caziv1.ListObjectsRequest{
+		Subject:        subject,
+		Verb:           req.Verb,
+		ObjectType:     req.ObjectType,
+		Filter:         ExpressionToProto(req.Filter),
+		AtLeastAsFresh: ConsistencyTokenToProto(req.AtLeastAsFresh),
+		Consistency:    ConsistencyToProto(req.Consistency),
+	}, nil
+}
+
+// ListObjectsRequestFromProto converts a protobuf ListObjectsRequest to a cazi.ListObjectsRequest.
+func ListObjectsRequestFromProto(req *caziv1.ListObjectsRequest) (cazi.ListObjectsRequest, error) {
+	subject, err := SubjectFromProto(req.GetSubject())
+	if err != nil {
+		return cazi.ListObjectsRequest{}, fmt.Errorf("subject: %w", err)
+	}
+	return cazi.ListObjectsRequest{
+		Subject:        subject,
+		Verb:           req.GetVerb(),
+		ObjectType:     req.GetObjectType(),
+		Filter:         ExpressionFromProto(req.GetFilter()),
+		AtLeastAsFresh: ConsistencyTokenFromProto(req.GetAtLeastAsFresh()),
+		Consistency:    ConsistencyFromProto(req.GetConsistency()),
+	}, nil
+}
+
+// ListObjectsResponseToProto converts a cazi.ListObjectsResponse to its protobuf form.
+func ListObjectsResponseToProto(resp cazi.ListObjectsResponse) (*caziv1.ListObjectsResponse, error) {
+	authzCtx, err := AuthorizationContextToProto(resp.Context)
+	if err != nil {
+		return nil, fmt.Errorf("context: %w", err)
+	}
+	return &caziv1.ListObjectsResponse{
+		Decision:         DecisionKindToProto(resp.Decision),
+		Condition:        ExpressionToProto(resp.Condition),
+		Context:          authzCtx,
+		ConsistencyToken: ConsistencyTokenToProto(resp.ConsistencyToken),
+	}, nil
+}
+
+// ListObjectsResponseFromProto converts a protobuf ListObjectsResponse to a cazi.ListObjectsResponse.
+func ListObjectsResponseFromProto(resp *caziv1.ListObjectsResponse) cazi.ListObjectsResponse {
+	return cazi.ListObjectsResponse{
+		Decision:         DecisionKindFromProto(resp.GetDecision()),
+		Condition:        ExpressionFromProto(resp.GetCondition()),
+		Context:          AuthorizationContextFromProto(resp.GetContext()),
+		ConsistencyToken: ConsistencyTokenFromProto(resp.GetConsistencyToken()),
+	}
+}
+
+// WatchRequestToProto converts a cazi.WatchRequest to its protobuf form.
+func WatchRequestToProto(req cazi.WatchRequest) *caziv1.WatchRequest {
+	return &caziv1.WatchRequest{
+		ObjectTypes: req.ObjectTypes,
+		StartAfter:  ConsistencyTokenToProto(req.StartAfter),
+	}
+}
+
+// WatchRequestFromProto converts a protobuf WatchRequest to a cazi.WatchRequest.
+func WatchRequestFromProto(req *caziv1.WatchRequest) cazi.WatchRequest {
+	return cazi.WatchRequest{
+		ObjectTypes: req.GetObjectTypes(),
+		StartAfter:  ConsistencyTokenFromProto(req.GetStartAfter()),
+	}
+}
+
+// ChangeToProto converts a cazi.Change to a protobuf WatchResponse.
+func ChangeToProto(c cazi.Change) (*caziv1.WatchResponse, error) {
+	updates := make([]*caziv1.RelationshipUpdate, len(c.Updates))
+	for i, u := range c.Updates {
+		op, err := RelationshipOperationToProto(u.Operation)
+		if err != nil {
+			return nil, err
+		}
+		updates[i] = &caziv1.RelationshipUpdate{Operation: op, Relationship: RelationshipToProto(u.Relationship)}
+	}
+	return &caziv1.WatchResponse{Updates: updates, ChangedAt: ConsistencyTokenToProto(c.ChangedAt)}, nil
+}
+
+// ChangeFromProto converts a protobuf WatchResponse to a cazi.Change.
+func ChangeFromProto(resp *caziv1.WatchResponse) (cazi.Change, error) {
+	updates := make([]cazi.RelationshipUpdate, len(resp.GetUpdates()))
+	for i, u := range resp.GetUpdates() {
+		op, err := RelationshipOperationFromProto(u.GetOperation())
+		if err != nil {
+			return cazi.Change{}, err
+		}
+		updates[i] = cazi.RelationshipUpdate{Operation: op, Relationship: RelationshipFromProto(u.GetRelationship())}
+	}
+	return cazi.Change{Updates: updates, ChangedAt: ConsistencyTokenFromProto(resp.GetChangedAt())}, nil
+}
+
+// RelationshipToProto converts a cazi.Relationship to its protobuf form.
+func RelationshipToProto(r cazi.Relationship) *caziv1.Relationship {
+	return &caziv1.Relationship{
+		Object:          &caziv1.ResourceReference{Type: r.Object.Type, Id: r.Object.ID},
+		Relation:        r.Relation,
+		Subject:         &caziv1.ResourceReference{Type: r.Subject.Type, Id: r.Subject.ID},
+		SubjectRelation: r.SubjectRelation,
+	}
+}
+
+// RelationshipFromProto converts a protobuf Relationship to a cazi.Relationship.
+func RelationshipFromProto(r *caziv1.Relationship) cazi.Relationship {
+	return cazi.Relationship{
+		Object:          cazi.ResourceReference{Type: r.GetObject().GetType(), ID: r.GetObject().GetId()},
+		Relation:        r.GetRelation(),
+		Subject:         cazi.ResourceReference{Type: r.GetSubject().GetType(), ID: r.GetSubject().GetId()},
+		SubjectRelation: r.GetSubjectRelation(),
+	}
+}
+
+// RelationshipOperationToProto converts a cazi.RelationshipOperation to its protobuf enum.
+func RelationshipOperationToProto(op cazi.RelationshipOperation) (caziv1.RelationshipOperation, error) {
+	switch op {
+	case cazi.OperationTouch:
+		return caziv1.RelationshipOperation_RELATIONSHIP_OPERATION_TOUCH, nil
+	case cazi.OperationCreate:
+		return caziv1.RelationshipOperation_RELATIONSHIP_OPERATION_CREATE, nil
+	case cazi.OperationDelete:
+		return caziv1.RelationshipOperation_RELATIONSHIP_OPERATION_DELETE, nil
+	default:
+		return caziv1.RelationshipOperation_RELATIONSHIP_OPERATION_UNSPECIFIED, fmt.Errorf("unknown relationship operation %d", op)
+	}
+}
+
+// RelationshipOperationFromProto converts a protobuf RelationshipOperation to a cazi.RelationshipOperation.
+// Unlike the Go constants, the protobuf enum has no default operation, so UNSPECIFIED is an error.
+func RelationshipOperationFromProto(op caziv1.RelationshipOperation) (cazi.RelationshipOperation, error) {
+	switch op {
+	case caziv1.RelationshipOperation_RELATIONSHIP_OPERATION_TOUCH:
+		return cazi.OperationTouch, nil
+	case caziv1.RelationshipOperation_RELATIONSHIP_OPERATION_CREATE:
+		return cazi.OperationCreate, nil
+	case caziv1.RelationshipOperation_RELATIONSHIP_OPERATION_DELETE:
+		return cazi.OperationDelete, nil
+	default:
+		return 0, fmt.Errorf("unknown relationship operation %s", op)
+	}
+}
+
+// SubjectToProto converts a cazi.Subject to its protobuf form.
+func SubjectToProto(s cazi.Subject) (*caziv1.Subject, error) {
+	assertion, err := AssertionToProto(s.Assertion)
+	if err != nil {
+		return nil, err
+	}
+	return &caziv1.Subject{Assertion: assertion, Relation: s.Relation}, nil
+}
+
+// SubjectFromProto converts a protobuf Subject to a cazi.Subject.
+func SubjectFromProto(s *caziv1.Subject) (cazi.Subject, error) {
+	assertion, err := AssertionFromProto(s.GetAssertion())
+	if err != nil {
+		return cazi.Subject{}, err
+	}
+	return cazi.Subject{Assertion: assertion, Relation: s.GetRelation()}, nil
+}
+
+// AssertionToProto converts a cazi.Assertion to its protobuf one-of.
+// A nil assertion is converted to nil.
+func AssertionToProto(a cazi.Assertion) (*caziv1.Assertion, error) {
+	switch a := a.(type) {
+	case nil:
+		return nil, nil
+	case cazi.Claims:
+		s, err := ClaimsToStruct(a)
+		if err != nil {
+			return nil, err
+		}
+		return &caziv1.Assertion{Assertion: &caziv1.Assertion_Claims{Claims: s}}, nil
+	case cazi.OpaqueToken:
+		return &caziv1.Assertion{Assertion: &caziv1.Assertion_OpaqueToken{
+			OpaqueToken: &caziv1.OpaqueToken{Type: a.Type, Raw: a.Raw},
+		}}, nil
+	case cazi.ResourceReference:
+		return &caziv1.Assertion{Assertion: &caziv1.Assertion_ResourceReference{
+			ResourceReference: &caziv1.ResourceReference{Type: a.Type, Id: a.ID},
+		}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported assertion type %T", a)
+	}
+}
+
+// AssertionFromProto converts a protobuf Assertion to a cazi.Assertion.
+// A nil or empty assertion is converted to nil.
+func AssertionFromProto(a *caziv1.Assertion) (cazi.Assertion, error) {
+	switch a := a.GetAssertion().(type) {
+	case nil:
+		return nil, nil
+	case *caziv1.Assertion_Claims:
+		return ClaimsFromStruct(a.Claims), nil
+	case *caziv1.Assertion_OpaqueToken:
+		return cazi.OpaqueToken{Type: a.OpaqueToken.GetType(), Raw: a.OpaqueToken.GetRaw()}, nil
+	case *caziv1.Assertion_ResourceReference:
+		return cazi.ResourceReference{Type: a.ResourceReference.GetType(), ID: a.ResourceReference.GetId()}, nil
+	default:
+		return nil, fmt.Errorf("unsupported assertion type %T", a)
+	}
+}
+
+// DecisionKindToProto converts a cazi.DecisionKind to its protobuf enum.
+func DecisionKindToProto(d cazi.DecisionKind) caziv1.DecisionKind {
+	switch d {
+	case cazi.DecisionAllow:
+		return caziv1.DecisionKind_DECISION_KIND_ALLOW
+	case cazi.DecisionDeny:
+		return caziv1.DecisionKind_DECISION_KIND_DENY
+	case cazi.DecisionConditional:
+		return caziv1.DecisionKind_DECISION_KIND_CONDITIONAL
+	default:
+		return caziv1.DecisionKind_DECISION_KIND_UNSPECIFIED
+	}
+}
+
+// DecisionKindFromProto converts a protobuf DecisionKind to a cazi.DecisionKind.
+func DecisionKindFromProto(d caziv1.DecisionKind) cazi.DecisionKind {
+	switch d {
+	case caziv1.DecisionKind_DECISION_KIND_ALLOW:
+		return cazi.DecisionAllow
+	case caziv1.DecisionKind_DECISION_KIND_DENY:
+		return cazi.DecisionDeny
+	case caziv1.DecisionKind_DECISION_KIND_CONDITIONAL:
+		return cazi.DecisionConditional
+	default:
+		return cazi.DecisionUnknown
+	}
+}
+
+// ExpressionToProto converts a cazi.Expression to its protobuf form.
+// An unset expression (Language == "" and Expression == "") is converted to nil.
+func ExpressionToProto(e cazi.Expression) *caziv1.Expression {
+	if e == (cazi.Expression{}) {
+		return nil
+	}
+	return &caziv1.Expression{Language: e.Language, Expression: e.Expression}
+}
+
+// ExpressionFromProto converts a protobuf Expression to a cazi.Expression.
+func ExpressionFromProto(e *caziv1.Expression) cazi.Expression {
+	return cazi.Expression{Language: e.GetLanguage(), Expression: e.GetExpression()}
+}
+
+// AuthorizationContextToProto converts a cazi.AuthorizationContext to its protobuf form.
+func AuthorizationContextToProto(c cazi.AuthorizationContext) (*caziv1.AuthorizationContext, error) {
+	if c.RequesterContext == nil && c.TransactionContext == nil {
+		return nil, nil
+	}
+	requester, err := ClaimsToStruct(c.RequesterContext)
+	if err != nil {
+		return nil, fmt.Errorf("requester context: %w", err)
+	}
+	transaction, err := ClaimsToStruct(c.TransactionContext)
+	if err != nil {
+		return nil, fmt.Errorf("transaction context: %w", err)
+	}
+	return &caziv1.AuthorizationContext{
+		RequesterContext:   requester,
+		TransactionContext: transaction,
+	}, nil
+}
+
+// AuthorizationContextFromProto converts a protobuf AuthorizationContext to a cazi.AuthorizationContext.
+func AuthorizationContextFromProto(c *caziv1.AuthorizationContext) cazi.AuthorizationContext {
+	return cazi.AuthorizationContext{
+		RequesterContext:   ClaimsFromStruct(c.GetRequesterContext()),
+		TransactionContext: ClaimsFromStruct(c.GetTransactionContext()),
+	}
+}
+
+// ConsistencyToProto converts a cazi.Consistency to its protobuf form.
+// An unset requirement is converted to nil.
+func ConsistencyToProto(c cazi.Consistency) *caziv1.Consistency {
+	if c.Mode == cazi.ConsistencyUnspecified && len(c.Token) == 0 {
+		return nil
+	}
+	return &caziv1.Consistency{
+		Mode:  caziv1.ConsistencyMode(c.Mode),
+		Token: ConsistencyTokenToProto(c.Token),
+	}
+}
+
+// ConsistencyFromProto converts a protobuf Consistency to a cazi.Consistency.
+func ConsistencyFromProto(c *caziv1.Consistency) cazi.Consistency {
+	return cazi.Consistency{
+		Mode:  cazi.ConsistencyMode(c.GetMode()),
+		Token: ConsistencyTokenFromProto(c.GetToken()),
+	}
+}
+
+// ConsistencyTokenToProto wraps a cazi.ConsistencyToken. The bytes are not copied or interpreted.
+// An empty token is converted to nil.
+func ConsistencyTokenToProto(t cazi.ConsistencyToken) *caziv1.ConsistencyToken {
+	if len(t) == 0 {
+		return nil
+	}
+	return &caziv1.ConsistencyToken{Token: t}
+}
+
+// ConsistencyTokenFromProto unwraps a protobuf ConsistencyToken. The bytes are not copied or interpreted.
+func ConsistencyTokenFromProto(t *caziv1.ConsistencyToken) cazi.ConsistencyToken {
+	if len(t.GetToken()) == 0 {
+		return nil
+	}
+	return cazi.ConsistencyToken(t.GetToken())
+}
+
+// ClaimsToStruct converts claims to a google.protobuf.Struct.
+//
+// Any JSON-compatible Go value is accepted, including typed slices and maps
+// (e.g. []string as produced by claims.Roles) and json.Number.
+// Byte slices are encoded as base64 strings, matching encoding/json.
+// Nil claims are converted to nil.
+func ClaimsToStruct(c cazi.Claims) (*structpb.Struct, error) {
+	if c == nil {
+		return nil, nil
+	}
+	fields := make(map[string]*structpb.Value, len(c))
+	for k, v := range c {
+		pv, err := toValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("claim %q: %w", k, err)
+		}
+		fields[k] = pv
+	}
+	return &structpb.Struct{Fields: fields}, nil
+}
+
+// ClaimsFromStruct converts a google.protobuf.Struct to claims.
+// A nil struct is converted to nil claims.
+func ClaimsFromStruct(s *structpb.Struct) cazi.Claims {
+	if s == nil {
+		return nil
+	}
+	return cazi.Claims(s.AsMap())
+}
+
+func toValue(v any) (*structpb.Value, error) {
+	switch v := v.(type) {
+	case nil:
+		return structpb.NewNullValue(), nil
+	case *structpb.Value:
+		return v, nil
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return nil, err
+		}
+		return structpb.NewNumberValue(f), nil
+	case []byte:
+		return structpb.NewStringValue(base64.StdEncoding.EncodeToString(v)), nil
+	case cazi.Claims:
+		return toValue(map[string]any(v))
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return structpb.NewBoolValue(rv.Bool()), nil
+	case reflect.String:
+		return structpb.NewStringValue(rv.String()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return structpb.NewNumberValue(float64(rv.Int())), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return structpb.NewNumberValue(float64(rv.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		return structpb.NewNumberValue(rv.Float()), nil
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return structpb.NewNullValue(), nil
+		}
+		return toValue(rv.Elem().Interface())
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return structpb.NewNullValue(), nil
+		}
+		values := make([]*structpb.Value, rv.Len())
+		for i := range values {
+			pv, err := toValue(rv.Index(i).Interface())
+			if err != nil {
+				return nil, fmt.Errorf("index %d: %w", i, err)
+			}
+			values[i] = pv
+		}
+		return structpb.NewListValue(&structpb.ListValue{Values: values}), nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type %s", rv.Type().Key())
+		}
+		if rv.IsNil() {
+			return structpb.NewNullValue(), nil
+		}
+		fields := make(map[string]*structpb.Value, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			pv, err := toValue(iter.Value().Interface())
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", iter.Key().String(), err)
+			}
+			fields[iter.Key().String()] = pv
+		}
+		return structpb.NewStructValue(&structpb.Struct{Fields: fields}), nil
+	default:
+		return nil, fmt.Errorf("unsupported claim value type %T", v)
+	}
+}
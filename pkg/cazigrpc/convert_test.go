@@ -0,0 +1,197 @@
+package cazigrpc_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	caziv1 "github.com/alechenninger/cazi/gen/go/cazi/v1"
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"github.com/alechenninger/cazi/pkg/cazigrpc"
+	"github.com/alechenninger/cazi/pkg/claims"
+)
+
+func TestDecisionKindNumericValuesMatch(t *testing.T) {
+	kinds := []cazi.DecisionKind{
+		cazi.DecisionUnknown,
+		cazi.DecisionAllow,
+		cazi.DecisionDeny,
+		cazi.DecisionConditional,
+	}
+	for _, kind := range kinds {
+		converted := cazigrpc.DecisionKindToProto(kind)
+		if int32(converted) != int32(kind) {
+			t.Errorf("expected %v to have numeric value %d, got %d", kind, kind, converted)
+		}
+		if back := cazigrpc.DecisionKindFromProto(converted); back != kind {
+			t.Errorf("expected round trip of %v, got %v", kind, back)
+		}
+	}
+	if len(caziv1.DecisionKind_name) != len(kinds) {
+		t.Errorf("expected %d proto decision kinds, got %d", len(kinds), len(caziv1.DecisionKind_name))
+	}
+}
+
+func TestConsistencyModeNumericValuesMatch(t *testing.T) {
+	modes := []cazi.ConsistencyMode{
+		cazi.ConsistencyUnspecified,
+		cazi.ConsistencyMinimizeLatency,
+		cazi.ConsistencyAtLeastAsFresh,
+		cazi.ConsistencyAtExactSnapshot,
+		cazi.ConsistencyFullyConsistent,
+	}
+	for _, mode := range modes {
+		converted := cazigrpc.ConsistencyToProto(cazi.Consistency{Mode: mode, Token: cazi.ConsistencyToken("t")})
+		if want := "CONSISTENCY_MODE_" + strings.ToUpper(mode.String()); converted.GetMode().String() != want {
+			t.Errorf("expected %v to convert to %s, got %s", mode, want, converted.GetMode())
+		}
+		if back := cazigrpc.ConsistencyFromProto(converted); back.Mode != mode || string(back.Token) != "t" {
+			t.Errorf("expected round trip of %v, got %+v", mode, back)
+		}
+	}
+	if len(caziv1.ConsistencyMode_name) != len(modes) {
+		t.Errorf("expected %d proto consistency modes, got %d", len(modes), len(caziv1.ConsistencyMode_name))
+	}
+}
+
+func TestClaimsRoundTrip(t *testing.T) {
+	t.Run("JSON-like values round trip unchanged", func(t *testing.T) {
+		c := cazi.Claims{
+			"sub":    "user-1",
+			"admin":  true,
+			"age":    float64(42),
+			"nil":    nil,
+			"groups": []any{"a", "b"},
+			"address": map[string]any{
+				"city": "Boston",
+				"geo":  []any{float64(1.5), float64(-2)},
+			},
+		}
+
+		s, err := cazigrpc.ClaimsToStruct(c)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got := cazigrpc.ClaimsFromStruct(s)
+
+		if !reflect.DeepEqual(got, c) {
+			t.Errorf("expected %v, got %v", c, got)
+		}
+	})
+
+	t.Run("Typed Go values are normalized to JSON types", func(t *testing.T) {
+		c := make(cazi.Claims)
+		claims.Roles.Set(c, []string{"admin", "viewer"})
+		c["count"] = 3
+		c["nested"] = cazi.Claims{"ids": []int{1, 2}}
+
+		s, err := cazigrpc.ClaimsToStruct(c)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got := cazigrpc.ClaimsFromStruct(s)
+
+		want := cazi.Claims{
+			"roles":  []any{"admin", "viewer"},
+			"count":  float64(3),
+			"nested": map[string]any{"ids": []any{float64(1), float64(2)}},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("Nil claims stay nil", func(t *testing.T) {
+		s, err := cazigrpc.ClaimsToStruct(nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := cazigrpc.ClaimsFromStruct(s); got != nil {
+			t.Errorf("expected nil claims, got %v", got)
+		}
+	})
+
+	t.Run("Unsupported values are rejected", func(t *testing.T) {
+		_, err := cazigrpc.ClaimsToStruct(cazi.Claims{"fn": func() {}})
+		if err == nil {
+			t.Fatal("expected error for unsupported claim value")
+		}
+	})
+}
+
+func TestCheckRequestRoundTrip(t *testing.T) {
+	assertions := map[string]cazi.Assertion{
+		"ResourceReference": cazi.ResourceReference{Type: "user", ID: "alice"},
+		"OpaqueToken":       cazi.OpaqueToken{Type: "jwt", Raw: []byte("a.b.c")},
+		"Claims":            cazi.Claims{"sub": "alice"},
+		"nil":               nil,
+	}
+
+	for name, assertion := range assertions {
+		t.Run(name, func(t *testing.T) {
+			req := cazi.CheckRequest{
+				Subject:        cazi.Subject{Assertion: assertion, Relation: "member"},
+				Verb:           "read",
+				Object:         cazi.Object{Assertion: cazi.ResourceReference{Type: "widget", ID: "w1"}},
+				AtLeastAsFresh: cazi.ConsistencyToken{0x00, 0xff, 0x10},
+				Explain:        true,
+			}
+
+			pb, err := cazigrpc.CheckRequestToProto(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, err := cazigrpc.CheckRequestFromProto(pb)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, req) {
+				t.Errorf("expected %+v, got %+v", req, got)
+			}
+		})
+	}
+}
+
+func TestExplanationRoundTrip(t *testing.T) {
+	rel := cazi.Relationship{
+		Object:   cazi.ResourceReference{Type: "widget", ID: "w1"},
+		Relation: "read",
+		Subject:  cazi.ResourceReference{Type: "user", ID: "alice"},
+	}
+	resp := cazi.CheckResponse{
+		Decision: cazi.DecisionConditional,
+		Explanation: &cazi.Explanation{
+			Description: "user:alice read widget:w1",
+			Decision:    cazi.DecisionConditional,
+			Steps: []cazi.Explanation{
+				{Description: "relationship " + rel.String(), Decision: cazi.DecisionDeny, Relationship: &rel},
+				{
+					Description: "rule: owners can read",
+					Decision:    cazi.DecisionConditional,
+					Condition:   cazi.Expression{Language: "cel", Expression: "widget.owner_id == 'alice'"},
+				},
+			},
+		},
+	}
+
+	pb, err := cazigrpc.CheckResponseToProto(resp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := cazigrpc.CheckResponseFromProto(pb)
+
+	if !reflect.DeepEqual(got.Explanation, resp.Explanation) {
+		t.Errorf("expected %+v, got %+v", resp.Explanation, got.Explanation)
+	}
+
+	t.Run("No explanation", func(t *testing.T) {
+		pb, err := cazigrpc.CheckResponseToProto(cazi.CheckResponse{Decision: cazi.DecisionAllow})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := cazigrpc.CheckResponseFromProto(pb); got.Explanation != nil {
+			t.Errorf("expected no explanation, got %+v", got.Explanation)
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package cazigrpc
+
+import (
+	"strings"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorDomain is the domain of the google.rpc.ErrorInfo detail that carries the kind of a [cazi.Error] in a status.
+// The reason is the kind's code in upper case, e.g. UNSUPPORTED_VERB.
+const ErrorDomain = "cazi"
+
+// codeFor returns the gRPC code for a kind of cazi error.
+func codeFor(kind *cazi.Error) codes.Code {
+	switch {
+	case kind == cazi.ErrTokenTooNew, kind == cazi.ErrPreconditionFailed:
+		return codes.FailedPrecondition
+	case kind == cazi.ErrRelationshipExists:
+		return codes.AlreadyExists
+	case kind == cazi.ErrVersionConflict:
+		return codes.Aborted
+	case kind == cazi.ErrUnavailable:
+		return codes.Unavailable
+	default:
+		// Every other kind is an invalid argument.
+		return codes.InvalidArgument
+	}
+}
+
+// withKind returns st with an ErrorInfo detail identifying kind.
+func withKind(st *status.Status, kind *cazi.Error) *status.Status {
+	detailed, err := st.WithDetails(&errdetails.ErrorInfo{
+		Domain: ErrorDomain,
+		Reason: strings.ToUpper(kind.Code),
+	})
+	if err != nil {
+		return st
+	}
+	return detailed
+}
+
+// kindOf returns the kind of cazi error a status represents.
+// Statuses from servers that don't attach an ErrorInfo detail are classified by code where it is unambiguous.
+func kindOf(st *status.Status) *cazi.Error {
+	for _, detail := range st.Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+		if !ok || info.GetDomain() != ErrorDomain {
+			continue
+		}
+		if kind, ok := cazi.ErrorForCode(strings.ToLower(info.GetReason())); ok {
+			return kind
+		}
+	}
+
+	switch st.Code() {
+	case codes.InvalidArgument:
+		return cazi.ErrInvalidArgument
+	case codes.Unavailable:
+		return cazi.ErrUnavailable
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,51 @@
+package cazigrpc_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"github.com/alechenninger/cazi/pkg/cazigrpc"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// spanAuthz records the span context of the last request.
+type spanAuthz struct {
+	stubAuthz
+	spanContext trace.SpanContext
+}
+
+func (s *spanAuthz) Check(ctx context.Context, req cazi.CheckRequest) (cazi.CheckResponse, error) {
+	s.spanContext = trace.SpanContextFromContext(ctx)
+	return s.stubAuthz.Check(ctx, req)
+}
+
+func TestTraceContextPropagation(t *testing.T) {
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+
+	authz := &spanAuthz{stubAuthz: stubAuthz{checkResp: cazi.CheckResponse{Decision: cazi.DecisionAllow}}}
+	client := cazigrpc.NewClient(startServer(t, authz))
+
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		SpanID:     trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), parent)
+
+	if _, err := client.Check(ctx, cazi.CheckRequest{
+		Subject: cazi.Subject{Assertion: cazi.ResourceReference{Type: "user", ID: "alice"}},
+		Verb:    "read",
+		Object:  cazi.Object{Assertion: cazi.ResourceReference{Type: "widget", ID: "w1"}},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := authz.spanContext; got.TraceID() != parent.TraceID() || got.SpanID() != parent.SpanID() || !got.IsRemote() {
+		t.Errorf("expected remote span context %v, got %v", parent, got)
+	}
+}
@@ -0,0 +1,109 @@
+// Package cazigrpc binds cazi.Interface to the CommonAuthorizationInterface gRPC service
+// defined in proto/cazi/v1/cazi.proto.
+package cazigrpc
+
+import (
+	"context"
+	"errors"
+
+	caziv1 "github.com/alechenninger/cazi/gen/go/cazi/v1"
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server exposes any cazi.Interface implementation as a CommonAuthorizationInterface gRPC service.
+//
+// OpenTelemetry trace context in request metadata is extracted into the ctx passed to the implementation.
+type Server struct {
+	caziv1.UnimplementedCommonAuthorizationInterfaceServer
+
+	authz cazi.Interface
+}
+
+// NewServer creates a gRPC server adapter for the given implementation.
+func NewServer(authz cazi.Interface) *Server {
+	return &Server{authz: authz}
+}
+
+// Register registers the server with a gRPC service registrar (e.g. *grpc.Server).
+func (s *Server) Register(r grpc.ServiceRegistrar) {
+	caziv1.RegisterCommonAuthorizationInterfaceServer(r, s)
+}
+
+// Check implements the Check RPC.
+func (s *Server) Check(ctx context.Context, in *caziv1.CheckRequest) (*caziv1.CheckResponse, error) {
+	req, err := CheckRequestFromProto(in)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid check request: %v", err)
+	}
+
+	resp, err := s.authz.Check(extractTraceContext(ctx), req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	out, err := CheckResponseToProto(resp)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "invalid check response: %v", err)
+	}
+	return out, nil
+}
+
+// ListObjects implements the ListObjects RPC.
+func (s *Server) ListObjects(ctx context.Context, in *caziv1.ListObjectsRequest) (*caziv1.ListObjectsResponse, error) {
+	req, err := ListObjectsRequestFromProto(in)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid list objects request: %v", err)
+	}
+
+	resp, err := s.authz.ListObjects(extractTraceContext(ctx), req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	out, err := ListObjectsResponseToProto(resp)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "invalid list objects response: %v", err)
+	}
+	return out, nil
+}
+
+// Watch implements the Watch RPC.
+// It returns UNIMPLEMENTED if the implementation does not implement cazi.Watcher.
+func (s *Server) Watch(in *caziv1.WatchRequest, stream grpc.ServerStreamingServer[caziv1.WatchResponse]) error {
+	err := cazi.Watch(extractTraceContext(stream.Context()), s.authz, WatchRequestFromProto(in), func(ctx context.Context, change cazi.Change) error {
+		out, err := ChangeToProto(change)
+		if err != nil {
+			return status.Errorf(codes.Internal, "invalid change: %v", err)
+		}
+		return stream.Send(out)
+	})
+	if err != nil {
+		return toStatus(err)
+	}
+	return nil
+}
+
+// toStatus maps an error returned by a cazi.Interface implementation to a gRPC status error.
+// Errors that already carry a gRPC status are passed through unchanged.
+// Errors wrapping a cazi.Error carry its kind in an ErrorInfo detail (see [ErrorDomain]),
+// so that the Client can restore it.
+func toStatus(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, errors.ErrUnsupported):
+		return status.Error(codes.Unimplemented, err.Error())
+	}
+	if kind, ok := cazi.ErrorKind(err); ok {
+		return withKind(status.New(codeFor(kind), err.Error()), kind).Err()
+	}
+	return status.Error(codes.Unknown, err.Error())
+}
@@ -0,0 +1,166 @@
+package cazigrpc_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	caziv1 "github.com/alechenninger/cazi/gen/go/cazi/v1"
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"github.com/alechenninger/cazi/pkg/cazigrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// stubAuthz is a cazi.Interface returning canned responses.
+type stubAuthz struct {
+	checkReq  cazi.CheckRequest
+	checkResp cazi.CheckResponse
+	listReq   cazi.ListObjectsRequest
+	listResp  cazi.ListObjectsResponse
+	err       error
+}
+
+func (s *stubAuthz) Check(ctx context.Context, req cazi.CheckRequest) (cazi.CheckResponse, error) {
+	s.checkReq = req
+	return s.checkResp, s.err
+}
+
+func (s *stubAuthz) ListObjects(ctx context.Context, req cazi.ListObjectsRequest) (cazi.ListObjectsResponse, error) {
+	s.listReq = req
+	return s.listResp, s.err
+}
+
+// startServer serves authz over an in-process connection and returns a connection to it.
+func startServer(t *testing.T, authz cazi.Interface, opts ...grpc.ServerOption) *grpc.ClientConn {
+	t.Helper()
+
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer(opts...)
+	cazigrpc.NewServer(authz).Register(srv)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to dial bufconn: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestServerCheck(t *testing.T) {
+	authz := &stubAuthz{
+		checkResp: cazi.CheckResponse{
+			Decision:  cazi.DecisionConditional,
+			Condition: cazi.Expression{Language: "cel", Expression: "widget.owner_id == 'alice'"},
+			Context: cazi.AuthorizationContext{
+				RequesterContext: cazi.Claims{"sub": "alice"},
+			},
+			ConsistencyToken: cazi.ConsistencyToken("rev-1"),
+		},
+	}
+	client := caziv1.NewCommonAuthorizationInterfaceClient(startServer(t, authz))
+
+	resp, err := client.Check(context.Background(), &caziv1.CheckRequest{
+		Subject: &caziv1.Subject{Assertion: &caziv1.Assertion{
+			Assertion: &caziv1.Assertion_ResourceReference{
+				ResourceReference: &caziv1.ResourceReference{Type: "user", Id: "alice"},
+			},
+		}},
+		Verb: "read",
+		Object: &caziv1.Object{Assertion: &caziv1.Assertion{
+			Assertion: &caziv1.Assertion_ResourceReference{
+				ResourceReference: &caziv1.ResourceReference{Type: "widget", Id: "w1"},
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := cazi.ResourceReference{Type: "user", ID: "alice"}
+	if authz.checkReq.Subject.Assertion != want {
+		t.Errorf("expected subject %v, got %v", want, authz.checkReq.Subject.Assertion)
+	}
+	if resp.GetDecision() != caziv1.DecisionKind_DECISION_KIND_CONDITIONAL {
+		t.Errorf("expected conditional decision, got %v", resp.GetDecision())
+	}
+	if resp.GetCondition().GetExpression() != "widget.owner_id == 'alice'" {
+		t.Errorf("unexpected condition: %v", resp.GetCondition())
+	}
+	if resp.GetContext().GetRequesterContext().GetFields()["sub"].GetStringValue() != "alice" {
+		t.Errorf("unexpected requester context: %v", resp.GetContext())
+	}
+	if string(resp.GetConsistencyToken().GetToken()) != "rev-1" {
+		t.Errorf("unexpected consistency token: %v", resp.GetConsistencyToken())
+	}
+}
+
+func TestServerListObjects(t *testing.T) {
+	authz := &stubAuthz{
+		listResp: cazi.ListObjectsResponse{
+			Decision:  cazi.DecisionConditional,
+			Condition: cazi.Expression{Language: "cel", Expression: "widget.owner_id == 'alice'"},
+		},
+	}
+	client := caziv1.NewCommonAuthorizationInterfaceClient(startServer(t, authz))
+
+	resp, err := client.ListObjects(context.Background(), &caziv1.ListObjectsRequest{
+		Verb:       "read",
+		ObjectType: "widget",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if authz.listReq.ObjectType != "widget" {
+		t.Errorf("expected object type 'widget', got '%s'", authz.listReq.ObjectType)
+	}
+	if resp.GetDecision() != caziv1.DecisionKind_DECISION_KIND_CONDITIONAL {
+		t.Errorf("expected conditional decision, got %v", resp.GetDecision())
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{"context canceled", context.Canceled, codes.Canceled},
+		{"deadline exceeded", context.DeadlineExceeded, codes.DeadlineExceeded},
+		{"status passthrough", status.Error(codes.PermissionDenied, "nope"), codes.PermissionDenied},
+		{"unsupported", errors.ErrUnsupported, codes.Unimplemented},
+		{"invalid argument", fmt.Errorf("%w: bad token", cazi.ErrInvalidArgument), codes.InvalidArgument},
+		{"unsupported verb", fmt.Errorf("%w: delete", cazi.ErrUnsupportedVerb), codes.InvalidArgument},
+		{"token too new", fmt.Errorf("%w: revision 2", cazi.ErrTokenTooNew), codes.FailedPrecondition},
+		{"unavailable", fmt.Errorf("%w: database down", cazi.ErrUnavailable), codes.Unavailable},
+		{"precondition failed", fmt.Errorf("%w: missing owner", cazi.ErrPreconditionFailed), codes.FailedPrecondition},
+		{"relationship exists", fmt.Errorf("%w: widget:w1#owner@user:alice", cazi.ErrRelationshipExists), codes.AlreadyExists},
+		{"version conflict", fmt.Errorf("%w: at version 2", cazi.ErrVersionConflict), codes.Aborted},
+		{"other error", errors.New("boom"), codes.Unknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authz := &stubAuthz{err: tt.err}
+			client := caziv1.NewCommonAuthorizationInterfaceClient(startServer(t, authz))
+
+			_, err := client.Check(context.Background(), &caziv1.CheckRequest{})
+			if status.Code(err) != tt.code {
+				t.Errorf("expected code %v, got %v", tt.code, status.Code(err))
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package cazigrpc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alechenninger/cazi/implementations/memory"
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"github.com/alechenninger/cazi/pkg/cazigrpc"
+)
+
+func ownerUpdate(op cazi.RelationshipOperation, widget, user string) cazi.RelationshipUpdate {
+	return cazi.RelationshipUpdate{
+		Operation: op,
+		Relationship: cazi.Relationship{
+			Object:   cazi.ResourceReference{Type: "widget", ID: widget},
+			Relation: "owner",
+			Subject:  cazi.ResourceReference{Type: "user", ID: user},
+		},
+	}
+}
+
+func TestWatch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	store := memory.New()
+	client := cazigrpc.NewClient(startServer(t, store))
+
+	first, err := store.WriteRelationships(ctx, cazi.WriteRelationshipsRequest{
+		Updates: []cazi.RelationshipUpdate{ownerUpdate(cazi.OperationTouch, "w1", "alice")},
+	})
+	if err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	second, err := store.WriteRelationships(ctx, cazi.WriteRelationshipsRequest{
+		Updates: []cazi.RelationshipUpdate{
+			ownerUpdate(cazi.OperationDelete, "w1", "alice"),
+			ownerUpdate(cazi.OperationTouch, "w1", "bob"),
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+
+	var got cazi.Change
+	stop := errors.New("stop")
+	err = client.Watch(ctx, cazi.WatchRequest{StartAfter: first.WrittenAt}, func(ctx context.Context, change cazi.Change) error {
+		got = change
+		return stop
+	})
+	if !errors.Is(err, stop) {
+		t.Fatalf("expected watch to stop, got %v", err)
+	}
+
+	if string(got.ChangedAt) != string(second.WrittenAt) {
+		t.Errorf("expected change at %v, got %v", second.WrittenAt, got.ChangedAt)
+	}
+	want := []cazi.RelationshipUpdate{
+		ownerUpdate(cazi.OperationDelete, "w1", "alice"),
+		ownerUpdate(cazi.OperationTouch, "w1", "bob"),
+	}
+	if len(got.Updates) != len(want) {
+		t.Fatalf("expected %d updates, got %v", len(want), got.Updates)
+	}
+	for i := range want {
+		if got.Updates[i] != want[i] {
+			t.Errorf("update %d: expected %v, got %v", i, want[i], got.Updates[i])
+		}
+	}
+}
+
+func TestWatchUnsupported(t *testing.T) {
+	client := cazigrpc.NewClient(startServer(t, &stubAuthz{}))
+
+	err := client.Watch(context.Background(), cazi.WatchRequest{}, func(context.Context, cazi.Change) error {
+		t.Error("unexpected change")
+		return nil
+	})
+	if !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("expected errors.ErrUnsupported, got %v", err)
+	}
+}
@@ -0,0 +1,261 @@
+package cazihttp_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"github.com/alechenninger/cazi/pkg/cazihttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// stubAuthz is a cazi.Interface returning canned responses.
+type stubAuthz struct {
+	checkReq  cazi.CheckRequest
+	checkResp cazi.CheckResponse
+	listReq   cazi.ListObjectsRequest
+	listResp  cazi.ListObjectsResponse
+	err       error
+}
+
+func (s *stubAuthz) Check(ctx context.Context, req cazi.CheckRequest) (cazi.CheckResponse, error) {
+	s.checkReq = req
+	return s.checkResp, s.err
+}
+
+func (s *stubAuthz) ListObjects(ctx context.Context, req cazi.ListObjectsRequest) (cazi.ListObjectsResponse, error) {
+	s.listReq = req
+	return s.listResp, s.err
+}
+
+func startServer(t *testing.T, authz cazi.Interface) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(cazihttp.NewHandler(authz))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClientCheck(t *testing.T) {
+	want := cazi.CheckResponse{
+		Decision:  cazi.DecisionConditional,
+		Condition: cazi.Expression{Language: "cel", Expression: "widget.owner_id == 'alice'"},
+		Context: cazi.AuthorizationContext{
+			RequesterContext: cazi.Claims{"sub": "alice"},
+		},
+		ConsistencyToken: cazi.ConsistencyToken{0x00, 0xff},
+	}
+	authz := &stubAuthz{checkResp: want}
+	client := cazihttp.NewClient(startServer(t, authz).URL, nil)
+
+	req := cazi.CheckRequest{
+		Subject:        cazi.Subject{Assertion: cazi.OpaqueToken{Type: "jwt", Raw: []byte("a.b.c")}},
+		Verb:           "read",
+		Object:         cazi.Object{Assertion: cazi.ResourceReference{Type: "widget", ID: "w1"}},
+		AtLeastAsFresh: cazi.ConsistencyToken{0x01},
+	}
+	got, err := client.Check(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(authz.checkReq, req) {
+		t.Errorf("expected server to receive %+v, got %+v", req, authz.checkReq)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestClientListObjects(t *testing.T) {
+	want := cazi.ListObjectsResponse{
+		Decision:  cazi.DecisionConditional,
+		Condition: cazi.Expression{Language: "cel", Expression: "widget.owner_id == 'alice'"},
+	}
+	authz := &stubAuthz{listResp: want}
+	client := cazihttp.NewClient(startServer(t, authz).URL+"/", nil)
+
+	req := cazi.ListObjectsRequest{
+		Subject:    cazi.Subject{Assertion: cazi.ResourceReference{Type: "user", ID: "alice"}},
+		Verb:       "read",
+		ObjectType: "widget",
+	}
+	got, err := client.ListObjects(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(authz.listReq, req) {
+		t.Errorf("expected server to receive %+v, got %+v", req, authz.listReq)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestHandlerAcceptsHandWrittenJSON(t *testing.T) {
+	authz := &stubAuthz{checkResp: cazi.CheckResponse{Decision: cazi.DecisionAllow}}
+	srv := startServer(t, authz)
+
+	body := `{
+		"subject": {"assertion": {"resource_reference": {"type": "user", "id": "alice"}}},
+		"verb": "create",
+		"object": {"assertion": {"resource_reference": {"type": "widget", "id": "w1"}}}
+	}`
+	resp, err := http.Post(srv.URL+cazihttp.CheckPath, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	if strings.TrimSpace(string(data)) != `{"decision":"allow"}` {
+		t.Errorf("unexpected response body: %s", data)
+	}
+	if authz.checkReq.Subject.Assertion != (cazi.ResourceReference{Type: "user", ID: "alice"}) {
+		t.Errorf("unexpected subject: %v", authz.checkReq.Subject.Assertion)
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	t.Run("Invalid body", func(t *testing.T) {
+		srv := startServer(t, &stubAuthz{})
+
+		resp, err := http.Post(srv.URL+cazihttp.CheckPath, "application/json",
+			strings.NewReader(`{"subject": {"assertion": {"certificate": {}}}}`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Wrong method", func(t *testing.T) {
+		srv := startServer(t, &stubAuthz{})
+
+		resp, err := http.Get(srv.URL + cazihttp.CheckPath)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			t.Errorf("expected status 405, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Implementation error", func(t *testing.T) {
+		authz := &stubAuthz{err: errors.New("boom")}
+		client := cazihttp.NewClient(startServer(t, authz).URL, nil)
+
+		_, err := client.Check(context.Background(), cazi.CheckRequest{Verb: "read"})
+
+		var httpErr *cazihttp.Error
+		if !errors.As(err, &httpErr) {
+			t.Fatalf("expected *cazihttp.Error, got %T: %v", err, err)
+		}
+		if httpErr.StatusCode != http.StatusInternalServerError {
+			t.Errorf("expected status 500, got %d", httpErr.StatusCode)
+		}
+		if httpErr.Message != "boom" {
+			t.Errorf("expected message 'boom', got '%s'", httpErr.Message)
+		}
+	})
+}
+
+func TestClientErrorKinds(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"Unsupported", fmt.Errorf("no watch: %w", errors.ErrUnsupported), http.StatusNotImplemented},
+		{"Unsupported verb", fmt.Errorf("%w: delete", cazi.ErrUnsupportedVerb), http.StatusBadRequest},
+		{"Token too new", fmt.Errorf("%w: revision 2", cazi.ErrTokenTooNew), http.StatusPreconditionFailed},
+		{"Unavailable", fmt.Errorf("%w: database down", cazi.ErrUnavailable), http.StatusServiceUnavailable},
+		{"Precondition failed", fmt.Errorf("%w: missing owner", cazi.ErrPreconditionFailed), http.StatusPreconditionFailed},
+		{"Relationship exists", fmt.Errorf("%w: widget:w1#owner@user:alice", cazi.ErrRelationshipExists), http.StatusConflict},
+		{"Version conflict", fmt.Errorf("%w: at version 2", cazi.ErrVersionConflict), http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := cazihttp.NewClient(startServer(t, &stubAuthz{err: tt.err}).URL, nil)
+
+			_, err := client.Check(context.Background(), cazi.CheckRequest{Verb: "read"})
+
+			var httpErr *cazihttp.Error
+			if !errors.As(err, &httpErr) {
+				t.Fatalf("expected *cazihttp.Error, got %T: %v", err, err)
+			}
+			if httpErr.StatusCode != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, httpErr.StatusCode)
+			}
+			if !errors.Is(err, errors.Unwrap(tt.err)) {
+				t.Errorf("expected %v, got %v", errors.Unwrap(tt.err), err)
+			}
+		})
+	}
+
+	t.Run("Refined kinds match their parent", func(t *testing.T) {
+		client := cazihttp.NewClient(startServer(t, &stubAuthz{err: cazi.ErrUnsupportedAssertion}).URL, nil)
+
+		_, err := client.Check(context.Background(), cazi.CheckRequest{Verb: "read"})
+		if !errors.Is(err, cazi.ErrInvalidArgument) {
+			t.Errorf("expected cazi.ErrInvalidArgument, got %v", err)
+		}
+	})
+}
+
+// spanAuthz records the span context of the last request.
+type spanAuthz struct {
+	stubAuthz
+	spanContext trace.SpanContext
+}
+
+func (s *spanAuthz) Check(ctx context.Context, req cazi.CheckRequest) (cazi.CheckResponse, error) {
+	s.spanContext = trace.SpanContextFromContext(ctx)
+	return s.stubAuthz.Check(ctx, req)
+}
+
+func TestTraceContextPropagation(t *testing.T) {
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+
+	authz := &spanAuthz{stubAuthz: stubAuthz{checkResp: cazi.CheckResponse{Decision: cazi.DecisionAllow}}}
+	client := cazihttp.NewClient(startServer(t, authz).URL, nil)
+
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		SpanID:     trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), parent)
+
+	if _, err := client.Check(ctx, cazi.CheckRequest{
+		Subject: cazi.Subject{Assertion: cazi.ResourceReference{Type: "user", ID: "alice"}},
+		Verb:    "read",
+		Object:  cazi.Object{Assertion: cazi.ResourceReference{Type: "widget", ID: "w1"}},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := authz.spanContext; got.TraceID() != parent.TraceID() || got.SpanID() != parent.SpanID() || !got.IsRemote() {
+		t.Errorf("expected remote span context %v, got %v", parent, got)
+	}
+}
@@ -0,0 +1,139 @@
+package cazihttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Client implements cazi.Interface by calling a remote CAZI HTTP server.
+//
+// OpenTelemetry trace context is propagated from the ctx passed to each call, using the global propagator.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+var _ cazi.Interface = (*Client)(nil)
+
+// NewClient creates a client for the server mounted at baseURL (e.g. "http://localhost:8081").
+// If httpClient is nil, http.DefaultClient is used.
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+// Check implements cazi.Interface.
+func (c *Client) Check(ctx context.Context, req cazi.CheckRequest) (cazi.CheckResponse, error) {
+	var resp cazi.CheckResponse
+	if err := c.do(ctx, CheckPath, req, &resp); err != nil {
+		return cazi.CheckResponse{}, err
+	}
+	return resp, nil
+}
+
+// ListObjects implements cazi.Interface.
+func (c *Client) ListObjects(ctx context.Context, req cazi.ListObjectsRequest) (cazi.ListObjectsResponse, error) {
+	var resp cazi.ListObjectsResponse
+	if err := c.do(ctx, ListObjectsPath, req, &resp); err != nil {
+		return cazi.ListObjectsResponse{}, err
+	}
+	return resp, nil
+}
+
+func (c *Client) do(ctx context.Context, path string, in, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(httpReq.Header))
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return readError(httpResp)
+	}
+
+	if err := json.NewDecoder(httpResp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// Error is returned by Client when the server responds with a non-2xx status.
+// Use errors.As to inspect the status code.
+// Not Implemented errors match errors.ErrUnsupported with errors.Is,
+// and errors of a known kind match it, e.g. cazi.ErrUnsupportedVerb or cazi.ErrUnavailable.
+type Error struct {
+	StatusCode int
+	Message    string
+	Kind       *cazi.Error // the kind of error reported by the server, if known
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("cazi http error: status = %d message = %s", e.StatusCode, e.Message)
+}
+
+// Is reports whether the error matches target.
+func (e *Error) Is(target error) bool {
+	return target == errors.ErrUnsupported && e.StatusCode == http.StatusNotImplemented
+}
+
+// Unwrap returns the kind of the error, if known.
+func (e *Error) Unwrap() error {
+	if e.Kind == nil {
+		return nil
+	}
+	return e.Kind
+}
+
+func readError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxRequestBytes))
+
+	var errResp ErrorResponse
+	if err := json.Unmarshal(data, &errResp); err != nil || errResp.Error == "" {
+		errResp.Error = strings.TrimSpace(string(data))
+	}
+	return &Error{StatusCode: resp.StatusCode, Message: errResp.Error, Kind: kindOf(resp.StatusCode, errResp.Code)}
+}
+
+// kindOf returns the kind of cazi error a response represents.
+// Responses from servers that don't report a code are classified by status where it is unambiguous.
+func kindOf(statusCode int, code string) *cazi.Error {
+	if kind, ok := cazi.ErrorForCode(code); ok {
+		return kind
+	}
+	switch statusCode {
+	case http.StatusBadRequest:
+		return cazi.ErrInvalidArgument
+	case http.StatusServiceUnavailable:
+		return cazi.ErrUnavailable
+	default:
+		return nil
+	}
+}
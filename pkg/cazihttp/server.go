@@ -0,0 +1,136 @@
+// Package cazihttp binds cazi.Interface to HTTP using the canonical JSON encoding of the cazi types.
+//
+// Requests are POSTed as JSON to [CheckPath] and [ListObjectsPath], relative to wherever the handler is mounted.
+// Errors are returned with a non-2xx status and an [ErrorResponse] body,
+// which identifies errors wrapping a [cazi.Error] by their kind's code so the [Client] can restore them.
+package cazihttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// CheckPath is the path of the Check operation.
+	CheckPath = "/v1/check"
+
+	// ListObjectsPath is the path of the ListObjects operation.
+	ListObjectsPath = "/v1/list-objects"
+)
+
+// maxRequestBytes bounds the size of request bodies accepted by the handler.
+const maxRequestBytes = 1 << 20
+
+// ErrorResponse is the body of a non-2xx response.
+type ErrorResponse struct {
+	Error string `json:"error"`
+	Code  string `json:"code,omitempty"` // the code of the cazi.Error kind, if any (e.g. "unsupported_verb")
+}
+
+// Handler exposes any cazi.Interface implementation over HTTP.
+type Handler struct {
+	authz cazi.Interface
+	mux   *http.ServeMux
+}
+
+// NewHandler creates an HTTP handler for the given implementation.
+func NewHandler(authz cazi.Interface) *Handler {
+	h := &Handler{authz: authz, mux: http.NewServeMux()}
+	h.mux.HandleFunc("POST "+CheckPath, h.check)
+	h.mux.HandleFunc("POST "+ListObjectsPath, h.listObjects)
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+//
+// OpenTelemetry trace context in the request headers is extracted using the global propagator,
+// unless the request context already has a span (e.g. from instrumentation middleware).
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !trace.SpanContextFromContext(r.Context()).IsValid() {
+		r = r.WithContext(otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header)))
+	}
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) check(w http.ResponseWriter, r *http.Request) {
+	var req cazi.CheckRequest
+	if err := decodeRequest(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, cazi.ErrInvalidArgument.Code, "invalid check request: "+err.Error())
+		return
+	}
+
+	resp, err := h.authz.Check(r.Context(), req)
+	if err != nil {
+		writeError(w, statusFor(err), errorCode(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) listObjects(w http.ResponseWriter, r *http.Request) {
+	var req cazi.ListObjectsRequest
+	if err := decodeRequest(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, cazi.ErrInvalidArgument.Code, "invalid list objects request: "+err.Error())
+		return
+	}
+
+	resp, err := h.authz.ListObjects(r.Context(), req)
+	if err != nil {
+		writeError(w, statusFor(err), errorCode(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func decodeRequest(w http.ResponseWriter, r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// statusFor maps an error returned by a cazi.Interface implementation to an HTTP status code.
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, errors.ErrUnsupported):
+		return http.StatusNotImplemented
+	case errors.Is(err, cazi.ErrInvalidArgument):
+		return http.StatusBadRequest
+	case errors.Is(err, cazi.ErrTokenTooNew), errors.Is(err, cazi.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, cazi.ErrRelationshipExists), errors.Is(err, cazi.ErrVersionConflict):
+		return http.StatusConflict
+	case errors.Is(err, cazi.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// errorCode returns the code of the kind of cazi.Error that err wraps, or "" if none.
+func errorCode(err error) string {
+	if kind, ok := cazi.ErrorKind(err); ok {
+		return kind.Code
+	}
+	return ""
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, ErrorResponse{Error: message, Code: code})
+}
@@ -0,0 +1,187 @@
+// Package caziotel instruments cazi.Interface implementations with OpenTelemetry traces and metrics.
+//
+// Check and ListObjects calls get a span and are recorded in these metrics:
+//
+//   - cazi.operation.duration: a histogram of call latency in seconds.
+//   - cazi.decisions: a counter of decisions by kind.
+//   - cazi.errors: a counter of failed calls by error type.
+//
+// Spans and metrics only carry low-cardinality, non-sensitive attributes:
+// the operation, verb, subject and object types, consistency mode, decision kind
+// and condition language. Resource IDs, claims and condition expressions are never recorded.
+//
+// Trace context is propagated across processes by the cazigrpc and cazihttp bindings
+// using the global propagator (see otel.SetTextMapPropagator).
+package caziotel
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope name of the tracer and meter.
+const ScopeName = "github.com/alechenninger/cazi/pkg/caziotel"
+
+// Attribute keys.
+const (
+	AttrOperation         = attribute.Key("cazi.operation")          // "check" or "list_objects"
+	AttrVerb              = attribute.Key("cazi.verb")               // the requested verb
+	AttrSubjectType       = attribute.Key("cazi.subject.type")       // type of a resource reference subject
+	AttrObjectType        = attribute.Key("cazi.object.type")        // type of a resource reference object, or the listed object type
+	AttrConsistencyMode   = attribute.Key("cazi.consistency.mode")   // the resolved consistency mode
+	AttrDecision          = attribute.Key("cazi.decision")           // allow, deny or conditional
+	AttrConditionLanguage = attribute.Key("cazi.condition.language") // language of a conditional response's expression
+	AttrErrorType         = attribute.Key("error.type")              // the kind of error (see errorType)
+)
+
+// Options configure instrumentation.
+type Options struct {
+	// TracerProvider creates the tracer. Defaults to otel.GetTracerProvider().
+	TracerProvider trace.TracerProvider
+
+	// MeterProvider creates the meter. Defaults to otel.GetMeterProvider().
+	MeterProvider metric.MeterProvider
+}
+
+// instruments holds the tracer and metric instruments shared by all calls.
+type instruments struct {
+	tracer    trace.Tracer
+	duration  metric.Float64Histogram
+	decisions metric.Int64Counter
+	errors    metric.Int64Counter
+}
+
+// Middleware returns cazi.Middleware that instruments Check and ListObjects.
+// Other operations are passed through.
+func Middleware(opts Options) (cazi.Middleware, error) {
+	if opts.TracerProvider == nil {
+		opts.TracerProvider = otel.GetTracerProvider()
+	}
+	if opts.MeterProvider == nil {
+		opts.MeterProvider = otel.GetMeterProvider()
+	}
+
+	meter := opts.MeterProvider.Meter(ScopeName)
+	inst := &instruments{tracer: opts.TracerProvider.Tracer(ScopeName)}
+	var err error
+	if inst.duration, err = meter.Float64Histogram("cazi.operation.duration",
+		metric.WithDescription("Duration of authorization operations."),
+		metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if inst.decisions, err = meter.Int64Counter("cazi.decisions",
+		metric.WithDescription("Authorization decisions by kind."),
+		metric.WithUnit("{decision}")); err != nil {
+		return nil, err
+	}
+	if inst.errors, err = meter.Int64Counter("cazi.errors",
+		metric.WithDescription("Failed authorization operations."),
+		metric.WithUnit("{error}")); err != nil {
+		return nil, err
+	}
+
+	return cazi.Intercept(cazi.Hooks{
+		Check: func(ctx context.Context, req cazi.CheckRequest, next cazi.CheckFunc) (cazi.CheckResponse, error) {
+			attrs := []attribute.KeyValue{AttrOperation.String("check"), AttrVerb.String(req.Verb)}
+			if ref, ok := req.Subject.Assertion.(cazi.ResourceReference); ok {
+				attrs = append(attrs, AttrSubjectType.String(ref.Type))
+			}
+			if ref, ok := req.Object.Assertion.(cazi.ResourceReference); ok {
+				attrs = append(attrs, AttrObjectType.String(ref.Type))
+			}
+			attrs = append(attrs, consistencyMode(req.Consistency, req.AtLeastAsFresh)...)
+
+			var resp cazi.CheckResponse
+			err := inst.record(ctx, "cazi.Check", attrs, func(ctx context.Context) (cazi.DecisionKind, cazi.Expression, error) {
+				var err error
+				resp, err = next(ctx, req)
+				return resp.Decision, resp.Condition, err
+			})
+			return resp, err
+		},
+		ListObjects: func(ctx context.Context, req cazi.ListObjectsRequest, next cazi.ListObjectsFunc) (cazi.ListObjectsResponse, error) {
+			attrs := []attribute.KeyValue{
+				AttrOperation.String("list_objects"),
+				AttrVerb.String(req.Verb),
+				AttrObjectType.String(req.ObjectType),
+			}
+			if ref, ok := req.Subject.Assertion.(cazi.ResourceReference); ok {
+				attrs = append(attrs, AttrSubjectType.String(ref.Type))
+			}
+			attrs = append(attrs, consistencyMode(req.Consistency, req.AtLeastAsFresh)...)
+
+			var resp cazi.ListObjectsResponse
+			err := inst.record(ctx, "cazi.ListObjects", attrs, func(ctx context.Context) (cazi.DecisionKind, cazi.Expression, error) {
+				var err error
+				resp, err = next(ctx, req)
+				return resp.Decision, resp.Condition, err
+			})
+			return resp, err
+		},
+	}), nil
+}
+
+// record calls fn in a span named name, and records its outcome in the span and metrics.
+func (i *instruments) record(ctx context.Context, name string, attrs []attribute.KeyValue, fn func(ctx context.Context) (cazi.DecisionKind, cazi.Expression, error)) error {
+	ctx, span := i.tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+	defer span.End()
+
+	start := time.Now()
+	decision, condition, err := fn(ctx)
+	elapsed := time.Since(start).Seconds()
+
+	if err != nil {
+		errAttr := AttrErrorType.String(errorType(err))
+		span.SetAttributes(errAttr)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		attrs = append(attrs, errAttr)
+		i.errors.Add(ctx, 1, metric.WithAttributes(attrs...))
+		i.duration.Record(ctx, elapsed, metric.WithAttributes(attrs...))
+		return err
+	}
+
+	outcome := []attribute.KeyValue{AttrDecision.String(decision.String())}
+	if decision == cazi.DecisionConditional && condition.Language != "" {
+		outcome = append(outcome, AttrConditionLanguage.String(condition.Language))
+	}
+	span.SetAttributes(outcome...)
+	attrs = append(attrs, outcome...)
+	i.decisions.Add(ctx, 1, metric.WithAttributes(attrs...))
+	i.duration.Record(ctx, elapsed, metric.WithAttributes(attrs...))
+	return nil
+}
+
+// consistencyMode returns the resolved consistency mode attribute, if the requirement is valid.
+func consistencyMode(c cazi.Consistency, atLeastAsFresh cazi.ConsistencyToken) []attribute.KeyValue {
+	resolved, err := c.Resolve(atLeastAsFresh)
+	if err != nil {
+		return nil
+	}
+	return []attribute.KeyValue{AttrConsistencyMode.String(resolved.Mode.String())}
+}
+
+// errorType classifies an error with a low-cardinality value, following the OpenTelemetry
+// semantic conventions for error.type. Errors wrapping a cazi.Error are classified by its code.
+func errorType(err error) string {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "deadline_exceeded"
+	case errors.Is(err, errors.ErrUnsupported):
+		return "unsupported"
+	}
+	if kind, ok := cazi.ErrorKind(err); ok {
+		return kind.Code
+	}
+	return "_OTHER"
+}
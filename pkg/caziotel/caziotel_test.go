@@ -0,0 +1,215 @@
+package caziotel_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"github.com/alechenninger/cazi/pkg/caziotel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// stubAuthz is a cazi.Interface returning canned responses.
+type stubAuthz struct {
+	checkResp cazi.CheckResponse
+	listResp  cazi.ListObjectsResponse
+	err       error
+}
+
+func (s *stubAuthz) Check(ctx context.Context, req cazi.CheckRequest) (cazi.CheckResponse, error) {
+	return s.checkResp, s.err
+}
+
+func (s *stubAuthz) ListObjects(ctx context.Context, req cazi.ListObjectsRequest) (cazi.ListObjectsResponse, error) {
+	return s.listResp, s.err
+}
+
+type telemetry struct {
+	spans  *tracetest.SpanRecorder
+	reader *sdkmetric.ManualReader
+}
+
+func instrument(t *testing.T, next cazi.Interface) (cazi.Interface, telemetry) {
+	t.Helper()
+	tel := telemetry{spans: tracetest.NewSpanRecorder(), reader: sdkmetric.NewManualReader()}
+	mw, err := caziotel.Middleware(caziotel.Options{
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(tel.spans)),
+		MeterProvider:  sdkmetric.NewMeterProvider(sdkmetric.WithReader(tel.reader)),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return mw(next), tel
+}
+
+// metrics collects the data points of each metric by name.
+func (tel telemetry) metrics(t *testing.T) map[string]metricdata.Aggregation {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := tel.reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("failed to collect metrics: %v", err)
+	}
+	metrics := make(map[string]metricdata.Aggregation)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+	return metrics
+}
+
+func checkRequest() cazi.CheckRequest {
+	return cazi.CheckRequest{
+		Subject: cazi.Subject{Assertion: cazi.Claims{"sub": "alice", "email": "alice@example.com"}},
+		Verb:    "read",
+		Object:  cazi.Object{Assertion: cazi.ResourceReference{Type: "widget", ID: "w1"}},
+	}
+}
+
+func TestCheck(t *testing.T) {
+	authz, tel := instrument(t, &stubAuthz{checkResp: cazi.CheckResponse{
+		Decision:  cazi.DecisionConditional,
+		Condition: cazi.Expression{Language: "cel", Expression: "widget.owner_id == 'alice'"},
+	}})
+
+	if _, err := authz.Check(context.Background(), checkRequest()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	spans := tel.spans.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "cazi.Check" {
+		t.Errorf("expected span cazi.Check, got %s", span.Name())
+	}
+
+	want := map[attribute.Key]string{
+		caziotel.AttrOperation:         "check",
+		caziotel.AttrVerb:              "read",
+		caziotel.AttrObjectType:        "widget",
+		caziotel.AttrConsistencyMode:   "minimize_latency",
+		caziotel.AttrDecision:          "conditional",
+		caziotel.AttrConditionLanguage: "cel",
+	}
+	got := make(map[attribute.Key]string)
+	for _, kv := range span.Attributes() {
+		got[kv.Key] = kv.Value.Emit()
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("expected %s=%q, got %q", k, v, got[k])
+		}
+	}
+
+	t.Run("Claims and expressions are not recorded", func(t *testing.T) {
+		for k, v := range got {
+			if v == "alice" || v == "alice@example.com" || v == "w1" || v == "widget.owner_id == 'alice'" {
+				t.Errorf("expected no sensitive values, got %s=%q", k, v)
+			}
+		}
+		if _, ok := got[caziotel.AttrSubjectType]; ok {
+			t.Errorf("expected no subject type for claims, got %q", got[caziotel.AttrSubjectType])
+		}
+	})
+
+	t.Run("Metrics", func(t *testing.T) {
+		metrics := tel.metrics(t)
+
+		decisions, ok := metrics["cazi.decisions"].(metricdata.Sum[int64])
+		if !ok || len(decisions.DataPoints) != 1 {
+			t.Fatalf("expected 1 decision data point, got %+v", metrics["cazi.decisions"])
+		}
+		dp := decisions.DataPoints[0]
+		if dp.Value != 1 {
+			t.Errorf("expected 1 decision, got %d", dp.Value)
+		}
+		if v, _ := dp.Attributes.Value(caziotel.AttrDecision); v.AsString() != "conditional" {
+			t.Errorf("expected conditional decision, got %q", v.AsString())
+		}
+
+		duration, ok := metrics["cazi.operation.duration"].(metricdata.Histogram[float64])
+		if !ok || len(duration.DataPoints) != 1 || duration.DataPoints[0].Count != 1 {
+			t.Errorf("expected 1 duration measurement, got %+v", metrics["cazi.operation.duration"])
+		}
+		if _, ok := metrics["cazi.errors"]; ok {
+			t.Errorf("expected no errors, got %+v", metrics["cazi.errors"])
+		}
+	})
+}
+
+func TestListObjects(t *testing.T) {
+	authz, tel := instrument(t, &stubAuthz{listResp: cazi.ListObjectsResponse{Decision: cazi.DecisionAllow}})
+
+	if _, err := authz.ListObjects(context.Background(), cazi.ListObjectsRequest{
+		Subject:     cazi.Subject{Assertion: cazi.ResourceReference{Type: "user", ID: "alice"}},
+		Verb:        "read",
+		ObjectType:  "widget",
+		Consistency: cazi.FullyConsistent(),
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	spans := tel.spans.Ended()
+	if len(spans) != 1 || spans[0].Name() != "cazi.ListObjects" {
+		t.Fatalf("expected 1 cazi.ListObjects span, got %v", spans)
+	}
+	got := make(map[attribute.Key]string)
+	for _, kv := range spans[0].Attributes() {
+		got[kv.Key] = kv.Value.Emit()
+	}
+	want := map[attribute.Key]string{
+		caziotel.AttrOperation:       "list_objects",
+		caziotel.AttrSubjectType:     "user",
+		caziotel.AttrObjectType:      "widget",
+		caziotel.AttrConsistencyMode: "fully_consistent",
+		caziotel.AttrDecision:        "allow",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("expected %s=%q, got %q", k, v, got[k])
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	authz, tel := instrument(t, &stubAuthz{err: context.DeadlineExceeded})
+
+	if _, err := authz.Check(context.Background(), checkRequest()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	span := tel.spans.Ended()[0]
+	if span.Status().Code != codes.Error {
+		t.Errorf("expected error status, got %v", span.Status())
+	}
+
+	errs, ok := tel.metrics(t)["cazi.errors"].(metricdata.Sum[int64])
+	if !ok || len(errs.DataPoints) != 1 {
+		t.Fatalf("expected 1 error data point, got %+v", errs)
+	}
+	if v, _ := errs.DataPoints[0].Attributes.Value(caziotel.AttrErrorType); v.AsString() != "deadline_exceeded" {
+		t.Errorf("expected deadline_exceeded, got %q", v.AsString())
+	}
+}
+
+func TestErrorKinds(t *testing.T) {
+	authz, tel := instrument(t, &stubAuthz{err: fmt.Errorf("%w: delete", cazi.ErrUnsupportedVerb)})
+
+	if _, err := authz.Check(context.Background(), checkRequest()); err == nil {
+		t.Fatal("expected error")
+	}
+
+	errs := tel.metrics(t)["cazi.errors"].(metricdata.Sum[int64])
+	if v, _ := errs.DataPoints[0].Attributes.Value(caziotel.AttrErrorType); v.AsString() != "unsupported_verb" {
+		t.Errorf("expected unsupported_verb, got %q", v.AsString())
+	}
+}
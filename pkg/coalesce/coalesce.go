@@ -0,0 +1,215 @@
+// Package coalesce provides a cazi.Interface decorator that coalesces identical in-flight requests
+// into a single call to the wrapped implementation.
+//
+// Requests are identical if their canonical JSON encodings are equal, including their consistency
+// requirements. The first caller's request starts the shared call; later identical requests wait
+// for its result instead of calling the wrapped implementation themselves.
+//
+// Each caller's context only governs that caller's wait: a caller whose context is done returns
+// ctx.Err() immediately, while the shared call continues for the remaining callers.
+// The shared call's context carries the first caller's values but not its deadline or cancellation,
+// and is canceled once every caller waiting for it has given up.
+package coalesce
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+)
+
+// Stats are cumulative coalescing statistics.
+type Stats struct {
+	Calls     uint64 // calls made to the wrapped implementation
+	Coalesced uint64 // requests that shared another request's call instead of making their own
+}
+
+// Coalescer is a cazi.Interface that coalesces identical in-flight requests.
+//
+// It also implements cazi.BatchChecker: identical items in a batch are checked once.
+// Responses are shared between callers, who must not modify them.
+type Coalescer struct {
+	next cazi.Interface
+
+	checks group[cazi.CheckResponse]
+	lists  group[cazi.ListObjectsResponse]
+
+	calls     atomic.Uint64
+	coalesced atomic.Uint64
+}
+
+var (
+	_ cazi.Interface    = (*Coalescer)(nil)
+	_ cazi.BatchChecker = (*Coalescer)(nil)
+)
+
+// New creates a Coalescer in front of next.
+func New(next cazi.Interface) *Coalescer {
+	return &Coalescer{next: next}
+}
+
+// Middleware returns cazi.Middleware that coalesces identical in-flight requests.
+// The optional interfaces of the wrapped implementation are preserved and passed through uncoalesced.
+func Middleware() cazi.Middleware {
+	return func(next cazi.Interface) cazi.Interface {
+		c := New(next)
+		return cazi.Intercept(cazi.Hooks{
+			Check: func(ctx context.Context, req cazi.CheckRequest, _ cazi.CheckFunc) (cazi.CheckResponse, error) {
+				return c.Check(ctx, req)
+			},
+			ListObjects: func(ctx context.Context, req cazi.ListObjectsRequest, _ cazi.ListObjectsFunc) (cazi.ListObjectsResponse, error) {
+				return c.ListObjects(ctx, req)
+			},
+			BatchCheck: func(ctx context.Context, req cazi.BatchCheckRequest, _ cazi.BatchCheckFunc) (cazi.BatchCheckResponse, error) {
+				return c.BatchCheck(ctx, req)
+			},
+		})(next)
+	}
+}
+
+// Check implements cazi.Interface.
+func (c *Coalescer) Check(ctx context.Context, req cazi.CheckRequest) (cazi.CheckResponse, error) {
+	key, err := requestKey(req)
+	if err != nil {
+		c.calls.Add(1)
+		return c.next.Check(ctx, req)
+	}
+	return c.checks.do(ctx, c, key, func(ctx context.Context) (cazi.CheckResponse, error) {
+		return c.next.Check(ctx, req)
+	})
+}
+
+// ListObjects implements cazi.Interface.
+func (c *Coalescer) ListObjects(ctx context.Context, req cazi.ListObjectsRequest) (cazi.ListObjectsResponse, error) {
+	key, err := requestKey(req)
+	if err != nil {
+		c.calls.Add(1)
+		return c.next.ListObjects(ctx, req)
+	}
+	return c.lists.do(ctx, c, key, func(ctx context.Context) (cazi.ListObjectsResponse, error) {
+		return c.next.ListObjects(ctx, req)
+	})
+}
+
+// BatchCheck implements cazi.BatchChecker.
+//
+// Identical items are checked once and their result is copied to each of them.
+// If the wrapped implementation implements cazi.BatchChecker, the distinct items are sent to it as one batch.
+// Otherwise, each distinct item is checked with Check, so it is also coalesced with other in-flight requests.
+func (c *Coalescer) BatchCheck(ctx context.Context, req cazi.BatchCheckRequest) (cazi.BatchCheckResponse, error) {
+	var distinct cazi.BatchCheckRequest
+	indexes := make([]int, len(req.Items)) // index into distinct.Items of each item
+	seen := make(map[[sha256.Size]byte]int)
+	for i, item := range req.Items {
+		key, err := requestKey(item)
+		if err == nil {
+			if j, ok := seen[key]; ok {
+				indexes[i] = j
+				c.coalesced.Add(1)
+				continue
+			}
+			seen[key] = len(distinct.Items)
+		}
+		indexes[i] = len(distinct.Items)
+		distinct.Items = append(distinct.Items, item)
+	}
+
+	var resp cazi.BatchCheckResponse
+	if batcher, ok := c.next.(cazi.BatchChecker); ok {
+		c.calls.Add(1)
+		var err error
+		if resp, err = batcher.BatchCheck(ctx, distinct); err != nil {
+			return cazi.BatchCheckResponse{}, err
+		}
+	} else {
+		resp = cazi.CheckEach(ctx, c, distinct, cazi.DefaultBatchConcurrency)
+	}
+
+	results := make([]cazi.BatchCheckResult, len(req.Items))
+	for i, j := range indexes {
+		results[i] = resp.Results[j]
+	}
+	return cazi.BatchCheckResponse{Results: results}, nil
+}
+
+// Stats returns the coalescing statistics.
+func (c *Coalescer) Stats() Stats {
+	return Stats{Calls: c.calls.Load(), Coalesced: c.coalesced.Load()}
+}
+
+// group coalesces calls with the same key.
+type group[R any] struct {
+	mu    sync.Mutex
+	calls map[[sha256.Size]byte]*call[R]
+}
+
+// call is an in-flight or completed shared call.
+type call[R any] struct {
+	done    chan struct{}
+	resp    R
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// do returns the result of fn, sharing a single in-flight call among callers with the same key.
+func (g *group[R]) do(ctx context.Context, c *Coalescer, key [sha256.Size]byte, fn func(ctx context.Context) (R, error)) (R, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[[sha256.Size]byte]*call[R])
+	}
+	cl, ok := g.calls[key]
+	if ok {
+		c.coalesced.Add(1)
+	} else {
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		cl = &call[R]{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = cl
+		c.calls.Add(1)
+
+		go func() {
+			defer cancel()
+			cl.resp, cl.err = fn(callCtx)
+
+			g.mu.Lock()
+			if g.calls[key] == cl {
+				delete(g.calls, key)
+			}
+			g.mu.Unlock()
+			close(cl.done)
+		}()
+	}
+	cl.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-cl.done:
+		return cl.resp, cl.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		cl.waiters--
+		if cl.waiters == 0 {
+			// Nobody is waiting any more. Later callers start a new call rather than joining a canceled one.
+			cl.cancel()
+			if g.calls[key] == cl {
+				delete(g.calls, key)
+			}
+		}
+		g.mu.Unlock()
+
+		var zero R
+		return zero, ctx.Err()
+	}
+}
+
+// requestKey hashes the canonical JSON encoding of a request.
+func requestKey(req any) ([sha256.Size]byte, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(data), nil
+}
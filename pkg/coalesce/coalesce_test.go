@@ -0,0 +1,376 @@
+package coalesce_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alechenninger/cazi/implementations/memory"
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"github.com/alechenninger/cazi/pkg/coalesce"
+)
+
+// blockingAuthz blocks each call until release is closed or the call's context is done.
+type blockingAuthz struct {
+	release  chan struct{}
+	started  chan context.Context // receives each call's context, if set
+	calls    atomic.Int64
+	canceled atomic.Int64
+}
+
+func newBlockingAuthz() *blockingAuthz {
+	return &blockingAuthz{release: make(chan struct{}), started: make(chan context.Context, 100)}
+}
+
+func (a *blockingAuthz) wait(ctx context.Context) error {
+	a.calls.Add(1)
+	a.started <- ctx
+	select {
+	case <-a.release:
+		return nil
+	case <-ctx.Done():
+		a.canceled.Add(1)
+		return ctx.Err()
+	}
+}
+
+func (a *blockingAuthz) Check(ctx context.Context, req cazi.CheckRequest) (cazi.CheckResponse, error) {
+	if err := a.wait(ctx); err != nil {
+		return cazi.CheckResponse{}, err
+	}
+	decision := cazi.DecisionDeny
+	if req.Subject.Assertion.(cazi.ResourceReference).ID == "alice" {
+		decision = cazi.DecisionAllow
+	}
+	return cazi.CheckResponse{Decision: decision}, nil
+}
+
+func (a *blockingAuthz) ListObjects(ctx context.Context, req cazi.ListObjectsRequest) (cazi.ListObjectsResponse, error) {
+	if err := a.wait(ctx); err != nil {
+		return cazi.ListObjectsResponse{}, err
+	}
+	return cazi.ListObjectsResponse{Decision: cazi.DecisionAllow}, nil
+}
+
+// batchingAuthz implements cazi.BatchChecker and records the batches it receives.
+type batchingAuthz struct {
+	*blockingAuthz
+	batches [][]cazi.CheckRequest
+}
+
+func (a *batchingAuthz) BatchCheck(ctx context.Context, req cazi.BatchCheckRequest) (cazi.BatchCheckResponse, error) {
+	a.batches = append(a.batches, req.Items)
+	return cazi.CheckEach(ctx, a.blockingAuthz, req, 1), nil
+}
+
+func request(user string) cazi.CheckRequest {
+	return cazi.CheckRequest{
+		Subject: cazi.Subject{Assertion: cazi.ResourceReference{Type: "user", ID: user}},
+		Verb:    "read",
+		Object:  cazi.Object{Assertion: cazi.ResourceReference{Type: "widget", ID: "w1"}},
+	}
+}
+
+// waitFor polls cond until it is true, failing the test after a second.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCheckCoalescesIdenticalRequests(t *testing.T) {
+	authz := newBlockingAuthz()
+	c := coalesce.New(authz)
+
+	const callers = 50
+	var wg sync.WaitGroup
+	results := make(chan cazi.CheckResponse, callers)
+	errs := make(chan error, callers)
+	for range callers {
+		wg.Go(func() {
+			resp, err := c.Check(context.Background(), request("alice"))
+			if err != nil {
+				errs <- err
+				return
+			}
+			results <- resp
+		})
+	}
+
+	waitFor(t, func() bool { return c.Stats().Coalesced == callers-1 })
+	close(authz.release)
+	wg.Wait()
+	close(errs)
+	close(results)
+
+	for err := range errs {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for resp := range results {
+		if resp.Decision != cazi.DecisionAllow {
+			t.Errorf("expected allow, got %v", resp.Decision)
+		}
+	}
+	if calls := authz.calls.Load(); calls != 1 {
+		t.Errorf("expected 1 backend call, got %d", calls)
+	}
+	if stats := c.Stats(); stats.Calls != 1 {
+		t.Errorf("expected 1 call in stats, got %+v", stats)
+	}
+
+	t.Run("Completed calls are not reused", func(t *testing.T) {
+		if _, err := c.Check(context.Background(), request("alice")); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if calls := authz.calls.Load(); calls != 2 {
+			t.Errorf("expected 2 backend calls, got %d", calls)
+		}
+	})
+}
+
+func TestCheckDoesNotCoalesceDifferentRequests(t *testing.T) {
+	authz := newBlockingAuthz()
+	close(authz.release)
+	c := coalesce.New(authz)
+
+	requests := []cazi.CheckRequest{
+		request("alice"),
+		request("bob"),
+		func() cazi.CheckRequest {
+			req := request("alice")
+			req.Consistency = cazi.FullyConsistent()
+			return req
+		}(),
+	}
+
+	var wg sync.WaitGroup
+	for _, req := range requests {
+		wg.Go(func() {
+			resp, err := c.Check(context.Background(), req)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			want := cazi.DecisionDeny
+			if req.Subject.Assertion.(cazi.ResourceReference).ID == "alice" {
+				want = cazi.DecisionAllow
+			}
+			if resp.Decision != want {
+				t.Errorf("expected %v, got %v", want, resp.Decision)
+			}
+		})
+	}
+	wg.Wait()
+
+	if calls := authz.calls.Load(); calls != int64(len(requests)) {
+		t.Errorf("expected %d backend calls, got %d", len(requests), calls)
+	}
+}
+
+func TestCheckCancellation(t *testing.T) {
+	t.Run("Canceled caller returns early while others receive the result", func(t *testing.T) {
+		authz := newBlockingAuthz()
+		c := coalesce.New(authz)
+
+		first, cancelFirst := context.WithCancel(context.Background())
+		firstErr := make(chan error, 1)
+		go func() {
+			_, err := c.Check(first, request("alice"))
+			firstErr <- err
+		}()
+		callCtx := <-authz.started
+
+		second := make(chan error, 1)
+		go func() {
+			resp, err := c.Check(context.Background(), request("alice"))
+			if err == nil && resp.Decision != cazi.DecisionAllow {
+				err = errors.New("expected allow")
+			}
+			second <- err
+		}()
+		waitFor(t, func() bool { return c.Stats().Coalesced == 1 })
+
+		cancelFirst()
+		if err := <-firstErr; !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+		if err := callCtx.Err(); err != nil {
+			t.Errorf("expected shared call to continue, got %v", err)
+		}
+
+		close(authz.release)
+		if err := <-second; err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if calls := authz.calls.Load(); calls != 1 {
+			t.Errorf("expected 1 backend call, got %d", calls)
+		}
+	})
+
+	t.Run("Shared call is canceled when every caller is", func(t *testing.T) {
+		authz := newBlockingAuthz()
+		c := coalesce.New(authz)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		var wg sync.WaitGroup
+		for range 3 {
+			wg.Go(func() {
+				if _, err := c.Check(ctx, request("alice")); !errors.Is(err, context.Canceled) {
+					t.Errorf("expected context.Canceled, got %v", err)
+				}
+			})
+		}
+		callCtx := <-authz.started
+		waitFor(t, func() bool { return c.Stats().Coalesced == 2 })
+
+		cancel()
+		wg.Wait()
+		<-callCtx.Done()
+		waitFor(t, func() bool { return authz.canceled.Load() == 1 })
+
+		t.Run("Later callers start a new call", func(t *testing.T) {
+			close(authz.release)
+			if _, err := c.Check(context.Background(), request("alice")); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if calls := authz.calls.Load(); calls != 2 {
+				t.Errorf("expected 2 backend calls, got %d", calls)
+			}
+		})
+	})
+
+	t.Run("Shared call keeps the first caller's values but not its deadline", func(t *testing.T) {
+		type key struct{}
+		authz := newBlockingAuthz()
+		close(authz.release)
+		c := coalesce.New(authz)
+
+		ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), key{}, "v"), time.Minute)
+		defer cancel()
+		if _, err := c.Check(ctx, request("alice")); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		callCtx := <-authz.started
+		if v := callCtx.Value(key{}); v != "v" {
+			t.Errorf("expected value v, got %v", v)
+		}
+		if _, ok := callCtx.Deadline(); ok {
+			t.Error("expected no deadline")
+		}
+	})
+}
+
+func TestListObjectsCoalescesIdenticalRequests(t *testing.T) {
+	authz := newBlockingAuthz()
+	c := coalesce.New(authz)
+
+	req := cazi.ListObjectsRequest{
+		Subject:    cazi.Subject{Assertion: cazi.ResourceReference{Type: "user", ID: "alice"}},
+		Verb:       "read",
+		ObjectType: "widget",
+	}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			if _, err := c.ListObjects(context.Background(), req); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+	waitFor(t, func() bool { return c.Stats().Coalesced == 9 })
+	close(authz.release)
+	wg.Wait()
+
+	if calls := authz.calls.Load(); calls != 1 {
+		t.Errorf("expected 1 backend call, got %d", calls)
+	}
+}
+
+func TestBatchCheckDedupesItems(t *testing.T) {
+	items := []cazi.CheckRequest{request("alice"), request("bob"), request("alice"), request("alice")}
+
+	assertResults := func(t *testing.T, resp cazi.BatchCheckResponse) {
+		t.Helper()
+		if len(resp.Results) != len(items) {
+			t.Fatalf("expected %d results, got %d", len(items), len(resp.Results))
+		}
+		for i, want := range []cazi.DecisionKind{cazi.DecisionAllow, cazi.DecisionDeny, cazi.DecisionAllow, cazi.DecisionAllow} {
+			if r := resp.Results[i]; r.Err != nil || r.Response.Decision != want {
+				t.Errorf("item %d: expected %v, got %+v", i, want, r)
+			}
+		}
+	}
+
+	t.Run("Distinct items are checked individually", func(t *testing.T) {
+		authz := newBlockingAuthz()
+		close(authz.release)
+		c := coalesce.New(authz)
+
+		resp, err := c.BatchCheck(context.Background(), cazi.BatchCheckRequest{Items: items})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assertResults(t, resp)
+		if calls := authz.calls.Load(); calls != 2 {
+			t.Errorf("expected 2 backend calls, got %d", calls)
+		}
+	})
+
+	t.Run("Distinct items are sent to a BatchChecker as one batch", func(t *testing.T) {
+		authz := &batchingAuthz{blockingAuthz: newBlockingAuthz()}
+		close(authz.release)
+		c := coalesce.New(authz)
+
+		resp, err := cazi.BatchCheck(context.Background(), c, cazi.BatchCheckRequest{Items: items})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assertResults(t, resp)
+		if len(authz.batches) != 1 || len(authz.batches[0]) != 2 {
+			t.Errorf("expected 1 batch of 2 items, got %v", authz.batches)
+		}
+	})
+}
+
+func TestMiddlewarePreservesOptionalInterfaces(t *testing.T) {
+	authz := cazi.Chain(coalesce.Middleware())(memory.New())
+	ctx := context.Background()
+
+	if _, ok := authz.(cazi.Watcher); !ok {
+		t.Error("expected cazi.Watcher")
+	}
+	writer, ok := authz.(cazi.RelationshipWriter)
+	if !ok {
+		t.Fatal("expected cazi.RelationshipWriter")
+	}
+
+	written, err := writer.WriteRelationships(ctx, cazi.WriteRelationshipsRequest{
+		Updates: []cazi.RelationshipUpdate{{Operation: cazi.OperationTouch, Relationship: cazi.Relationship{
+			Object:   cazi.ResourceReference{Type: "widget", ID: "w1"},
+			Relation: "read",
+			Subject:  cazi.ResourceReference{Type: "user", ID: "alice"},
+		}}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req := request("alice")
+	req.AtLeastAsFresh = written.WrittenAt
+	resp, err := authz.Check(ctx, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Decision != cazi.DecisionAllow {
+		t.Errorf("expected allow, got %v", resp.Decision)
+	}
+}
@@ -0,0 +1,267 @@
+// Package consistency gives structure to cazi.ConsistencyToken.
+//
+// Tokens produced by Encode are a versioned envelope identifying the backend that issued them,
+// its revision, and optionally a hybrid logical clock (HLC) timestamp.
+// Tokens from the same backend can be compared, so callers can tell which is fresher,
+// or combine the tokens from several writes with Max.
+//
+// Implementations are not required to use this envelope;
+// tokens that are not envelopes are reported as ErrInvalidToken.
+package consistency
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+)
+
+// version is the envelope format version, the first byte of every encoded token.
+const version = 1
+
+const flagTimestamp = 1 << 0
+
+var (
+	// ErrInvalidToken is returned when a token is not a valid envelope.
+	ErrInvalidToken = errors.New("invalid consistency token")
+
+	// ErrIncomparable is returned when comparing tokens issued by different backends.
+	ErrIncomparable = errors.New("consistency tokens are incomparable")
+)
+
+// Token is the decoded form of a consistency token envelope.
+type Token struct {
+	Backend   string    // identifies the store that issued the token (e.g. "spicedb:prod")
+	Revision  uint64    // the store's revision; higher is fresher
+	Timestamp Timestamp // optional HLC timestamp; the zero value is unset
+}
+
+// Timestamp is a hybrid logical clock timestamp.
+type Timestamp struct {
+	WallTime int64  // physical time in nanoseconds since the Unix epoch
+	Logical  uint32 // orders events with the same WallTime
+}
+
+// IsZero reports whether the timestamp is unset.
+func (t Timestamp) IsZero() bool {
+	return t == Timestamp{}
+}
+
+// Compare returns -1, 0 or +1 as t is before, equal to or after other.
+func (t Timestamp) Compare(other Timestamp) int {
+	switch {
+	case t.WallTime < other.WallTime:
+		return -1
+	case t.WallTime > other.WallTime:
+		return 1
+	case t.Logical < other.Logical:
+		return -1
+	case t.Logical > other.Logical:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Compare returns -1, 0 or +1 as t is less fresh than, as fresh as, or fresher than other.
+// Tokens are ordered by timestamp if both have one, otherwise by revision.
+// Returns ErrIncomparable if the tokens are from different backends.
+func (t Token) Compare(other Token) (int, error) {
+	if t.Backend != other.Backend {
+		return 0, fmt.Errorf("%w: backend %q and %q", ErrIncomparable, t.Backend, other.Backend)
+	}
+	if !t.Timestamp.IsZero() && !other.Timestamp.IsZero() {
+		return t.Timestamp.Compare(other.Timestamp), nil
+	}
+	switch {
+	case t.Revision < other.Revision:
+		return -1, nil
+	case t.Revision > other.Revision:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+// Encode encodes t as an envelope.
+func Encode(t Token) cazi.ConsistencyToken {
+	var flags byte
+	if !t.Timestamp.IsZero() {
+		flags |= flagTimestamp
+	}
+
+	buf := []byte{version, flags}
+	buf = binary.AppendUvarint(buf, uint64(len(t.Backend)))
+	buf = append(buf, t.Backend...)
+	buf = binary.AppendUvarint(buf, t.Revision)
+	if flags&flagTimestamp != 0 {
+		buf = binary.BigEndian.AppendUint64(buf, uint64(t.Timestamp.WallTime))
+		buf = binary.BigEndian.AppendUint32(buf, t.Timestamp.Logical)
+	}
+	return buf
+}
+
+// Decode decodes an envelope.
+// Returns an error wrapping ErrInvalidToken if token is not an envelope of a known version.
+func Decode(token cazi.ConsistencyToken) (Token, error) {
+	r := bytes.NewReader(token)
+	invalid := func(reason string) (Token, error) {
+		return Token{}, fmt.Errorf("%w: %s", ErrInvalidToken, reason)
+	}
+
+	v, err := r.ReadByte()
+	if err != nil {
+		return invalid("empty")
+	}
+	if v != version {
+		return invalid(fmt.Sprintf("unknown version %d", v))
+	}
+	flags, err := r.ReadByte()
+	if err != nil {
+		return invalid("missing flags")
+	}
+
+	n, err := binary.ReadUvarint(r)
+	if err != nil || n > uint64(r.Len()) {
+		return invalid("malformed backend")
+	}
+	backend := make([]byte, n)
+	_, _ = r.Read(backend)
+
+	var t Token
+	t.Backend = string(backend)
+	if t.Revision, err = binary.ReadUvarint(r); err != nil {
+		return invalid("malformed revision")
+	}
+	if flags&flagTimestamp != 0 {
+		var ts [12]byte
+		if n, _ := r.Read(ts[:]); n != len(ts) {
+			return invalid("malformed timestamp")
+		}
+		t.Timestamp.WallTime = int64(binary.BigEndian.Uint64(ts[:8]))
+		t.Timestamp.Logical = binary.BigEndian.Uint32(ts[8:])
+	}
+	if r.Len() != 0 {
+		return invalid("trailing bytes")
+	}
+	return t, nil
+}
+
+// Compare decodes and compares two tokens, returning -1, 0 or +1 as a is less fresh than,
+// as fresh as, or fresher than b.
+// An empty token is less fresh than any other token.
+func Compare(a, b cazi.ConsistencyToken) (int, error) {
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return 0, nil
+	case len(a) == 0:
+		return -1, nil
+	case len(b) == 0:
+		return 1, nil
+	}
+
+	ta, err := Decode(a)
+	if err != nil {
+		return 0, err
+	}
+	tb, err := Decode(b)
+	if err != nil {
+		return 0, err
+	}
+	return ta.Compare(tb)
+}
+
+// Max returns the freshest of tokens, ignoring empty tokens.
+// Returns nil if there are no non-empty tokens.
+// Every non-empty token must be an envelope, even if it is the only one.
+func Max(tokens ...cazi.ConsistencyToken) (cazi.ConsistencyToken, error) {
+	var freshest cazi.ConsistencyToken
+	var best Token
+	for _, t := range tokens {
+		if len(t) == 0 {
+			continue
+		}
+		decoded, err := Decode(t)
+		if err != nil {
+			return nil, err
+		}
+		if freshest != nil {
+			c, err := decoded.Compare(best)
+			if err != nil {
+				return nil, err
+			}
+			if c <= 0 {
+				continue
+			}
+		}
+		freshest, best = t, decoded
+	}
+	return freshest, nil
+}
+
+// Format encodes a token as unpadded base64url, safe for HTTP headers, cookies and URLs.
+func Format(token cazi.ConsistencyToken) string {
+	return base64.RawURLEncoding.EncodeToString(token)
+}
+
+// Parse decodes a token encoded with Format.
+// The token is not required to be an envelope.
+func Parse(s string) (cazi.ConsistencyToken, error) {
+	token, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	if len(token) == 0 {
+		return nil, nil
+	}
+	return token, nil
+}
+
+type trackerKey struct{}
+
+// tracker holds the freshest token observed during a request.
+type tracker struct {
+	mu    sync.Mutex
+	token cazi.ConsistencyToken
+}
+
+// WithTracker returns a context that records the freshest token passed to Observe,
+// e.g. for the duration of a request that makes several writes.
+func WithTracker(ctx context.Context) context.Context {
+	return context.WithValue(ctx, trackerKey{}, &tracker{})
+}
+
+// Observe records token in ctx's tracker if it is fresher than the tokens observed so far.
+// It does nothing if ctx has no tracker (see WithTracker).
+func Observe(ctx context.Context, token cazi.ConsistencyToken) error {
+	t, ok := ctx.Value(trackerKey{}).(*tracker)
+	if !ok {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	freshest, err := Max(t.token, token)
+	if err != nil {
+		return err
+	}
+	t.token = freshest
+	return nil
+}
+
+// Freshest returns the freshest token observed in ctx, or nil if none was observed.
+func Freshest(ctx context.Context) cazi.ConsistencyToken {
+	t, ok := ctx.Value(trackerKey{}).(*tracker)
+	if !ok {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.token
+}
@@ -0,0 +1,195 @@
+package consistency_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"github.com/alechenninger/cazi/pkg/consistency"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	tests := []struct {
+		name  string
+		token consistency.Token
+	}{
+		{"Revision only", consistency.Token{Backend: "memory", Revision: 42}},
+		{"Empty backend", consistency.Token{Revision: 1}},
+		{"With timestamp", consistency.Token{
+			Backend:   "spicedb:prod",
+			Revision:  1 << 40,
+			Timestamp: consistency.Timestamp{WallTime: 1_700_000_000_000_000_000, Logical: 7},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := consistency.Decode(consistency.Encode(tt.token))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.token {
+				t.Errorf("expected %+v, got %+v", tt.token, got)
+			}
+		})
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	valid := consistency.Encode(consistency.Token{Backend: "memory", Revision: 1})
+
+	tests := []struct {
+		name  string
+		token cazi.ConsistencyToken
+	}{
+		{"Empty", nil},
+		{"Unknown version", append(cazi.ConsistencyToken{99}, valid[1:]...)},
+		{"Truncated", valid[:len(valid)-1]},
+		{"Trailing bytes", append(append(cazi.ConsistencyToken{}, valid...), 0)},
+		{"Not an envelope", cazi.ConsistencyToken{0, 0, 0, 0, 0, 0, 0, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := consistency.Decode(tt.token); !errors.Is(err, consistency.ErrInvalidToken) {
+				t.Errorf("expected ErrInvalidToken, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	rev := func(backend string, r uint64) cazi.ConsistencyToken {
+		return consistency.Encode(consistency.Token{Backend: backend, Revision: r})
+	}
+	hlc := func(r uint64, wall int64, logical uint32) cazi.ConsistencyToken {
+		return consistency.Encode(consistency.Token{Backend: "a", Revision: r, Timestamp: consistency.Timestamp{WallTime: wall, Logical: logical}})
+	}
+
+	tests := []struct {
+		name string
+		a, b cazi.ConsistencyToken
+		want int
+	}{
+		{"Older revision", rev("a", 1), rev("a", 2), -1},
+		{"Same revision", rev("a", 2), rev("a", 2), 0},
+		{"Newer revision", rev("a", 3), rev("a", 2), 1},
+		{"Timestamps take precedence", hlc(5, 100, 0), hlc(1, 200, 0), -1},
+		{"Logical orders equal wall times", hlc(1, 100, 2), hlc(1, 100, 1), 1},
+		{"Revision if one has no timestamp", hlc(1, 100, 0), rev("a", 2), -1},
+		{"Empty is least fresh", nil, rev("a", 0), -1},
+		{"Both empty", nil, nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := consistency.Compare(tt.a, tt.b)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+
+	t.Run("Different backends", func(t *testing.T) {
+		if _, err := consistency.Compare(rev("a", 1), rev("b", 1)); !errors.Is(err, consistency.ErrIncomparable) {
+			t.Errorf("expected ErrIncomparable, got %v", err)
+		}
+	})
+}
+
+func TestMax(t *testing.T) {
+	t1 := consistency.Encode(consistency.Token{Backend: "a", Revision: 1})
+	t3 := consistency.Encode(consistency.Token{Backend: "a", Revision: 3})
+	t2 := consistency.Encode(consistency.Token{Backend: "a", Revision: 2})
+
+	got, err := consistency.Max(t1, nil, t3, t2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(got) != string(t3) {
+		t.Errorf("expected revision 3, got %v", got)
+	}
+
+	t.Run("No tokens", func(t *testing.T) {
+		got, err := consistency.Max(nil, nil)
+		if err != nil || got != nil {
+			t.Errorf("expected nil, got %v (err %v)", got, err)
+		}
+	})
+
+	t.Run("Invalid token", func(t *testing.T) {
+		for _, tokens := range [][]cazi.ConsistencyToken{
+			{cazi.ConsistencyToken("rev-1")},
+			{nil, cazi.ConsistencyToken("rev-1")},
+			{t1, cazi.ConsistencyToken("rev-1")},
+		} {
+			if _, err := consistency.Max(tokens...); !errors.Is(err, consistency.ErrInvalidToken) {
+				t.Errorf("expected ErrInvalidToken for %v, got %v", tokens, err)
+			}
+		}
+	})
+}
+
+func TestFormatParse(t *testing.T) {
+	token := consistency.Encode(consistency.Token{Backend: "memory", Revision: 1 << 20})
+
+	s := consistency.Format(token)
+	for _, c := range s {
+		if c == '+' || c == '/' || c == '=' {
+			t.Fatalf("expected base64url without padding, got %q", s)
+		}
+	}
+
+	got, err := consistency.Parse(s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(got) != string(token) {
+		t.Errorf("expected %v, got %v", token, got)
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		if _, err := consistency.Parse("not base64!"); !errors.Is(err, consistency.ErrInvalidToken) {
+			t.Errorf("expected ErrInvalidToken, got %v", err)
+		}
+	})
+}
+
+func TestTracker(t *testing.T) {
+	ctx := consistency.WithTracker(context.Background())
+
+	var wg sync.WaitGroup
+	for r := uint64(1); r <= 10; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := consistency.Observe(ctx, consistency.Encode(consistency.Token{Backend: "a", Revision: r})); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	got, err := consistency.Decode(consistency.Freshest(ctx))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Revision != 10 {
+		t.Errorf("expected revision 10, got %d", got.Revision)
+	}
+
+	t.Run("No tracker", func(t *testing.T) {
+		ctx := context.Background()
+		if err := consistency.Observe(ctx, consistency.Encode(consistency.Token{Revision: 1})); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if got := consistency.Freshest(ctx); got != nil {
+			t.Errorf("expected nil, got %v", got)
+		}
+	})
+}
@@ -0,0 +1,340 @@
+// Package cel implements the Common Expression Language (https://cel.dev) for package expr.
+//
+// A [Language] is built from a [Schema] declaring the variables expressions may reference,
+// such as the resource a filter applies to, so references to anything else fail to compile with expr.ErrUnknownVariable.
+// Compiled programs are cached by expression text,
+// and evaluation is bounded by a cost limit.
+//
+// Languages need a schema, so they aren't registered automatically; register one with an expr.Registry:
+//
+//	lang, err := cel.New(cel.Options{Schema: cel.Schema{
+//		Resources: []cel.Resource{{Name: "widget", Fields: []string{"id", "owner_id"}}},
+//	}})
+//	...
+//	expr.Register(cel.Name, lang)
+package cel
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/alechenninger/cazi/pkg/expr"
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/ast"
+	"github.com/google/cel-go/interpreter"
+)
+
+// Name is the Expression.Language of CEL expressions.
+const Name = "cel"
+
+// Default options.
+const (
+	DefaultCostLimit   = 1_000_000
+	DefaultMaxPrograms = 1000
+)
+
+// ErrCostLimitExceeded is returned when evaluating an expression costs more than the limit.
+var ErrCostLimitExceeded = fmt.Errorf("%w: cost limit exceeded", expr.ErrInvalidExpression)
+
+// Resource declares a variable holding a resource, as a map from field names to values.
+type Resource struct {
+	// Name of the variable, e.g. "widget".
+	Name string
+
+	// Fields expressions may reference, e.g. "owner_id".
+	Fields []string
+}
+
+// Schema declares the variables expressions may reference.
+type Schema struct {
+	// Resources are variables holding resources whose fields are known.
+	Resources []Resource
+
+	// Variables are other variables and their types, e.g. "user": cel.MapType(cel.StringType, cel.DynType).
+	Variables map[string]*cel.Type
+}
+
+// Options configure a Language.
+type Options struct {
+	// Schema declares the variables expressions may reference.
+	Schema Schema
+
+	// EnvOptions are additional options for the CEL environment,
+	// e.g. a type provider needed to convert expressions to another form.
+	EnvOptions []cel.EnvOption
+
+	// CostLimit bounds the cost of evaluating an expression. Defaults to DefaultCostLimit.
+	CostLimit uint64
+
+	// MaxPrograms bounds the number of cached programs. The least recently used are evicted first.
+	// Defaults to DefaultMaxPrograms.
+	MaxPrograms int
+}
+
+// Language compiles CEL expressions. It implements expr.Language.
+type Language struct {
+	env       *cel.Env
+	declared  map[string]bool     // names of all declared variables
+	resources map[string][]string // fields by resource name
+	opts      Options
+
+	mu       sync.Mutex
+	programs map[string]*list.Element
+	lru      *list.List // of *Program; front is most recently used
+}
+
+// New creates a language for expressions using the variables declared by opts.Schema.
+func New(opts Options) (*Language, error) {
+	if opts.CostLimit == 0 {
+		opts.CostLimit = DefaultCostLimit
+	}
+	if opts.MaxPrograms <= 0 {
+		opts.MaxPrograms = DefaultMaxPrograms
+	}
+
+	declared := make(map[string]bool)
+	resources := make(map[string][]string, len(opts.Schema.Resources))
+	envOpts := slices.Clone(opts.EnvOptions)
+	for _, r := range opts.Schema.Resources {
+		declared[r.Name] = true
+		resources[r.Name] = r.Fields
+		envOpts = append(envOpts, cel.Variable(r.Name, cel.MapType(cel.StringType, cel.DynType)))
+	}
+	for name, t := range opts.Schema.Variables {
+		declared[name] = true
+		envOpts = append(envOpts, cel.Variable(name, t))
+	}
+	env, err := cel.NewEnv(envOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CEL environment: %w", err)
+	}
+
+	return &Language{
+		env:       env,
+		declared:  declared,
+		resources: resources,
+		opts:      opts,
+		programs:  make(map[string]*list.Element),
+		lru:       list.New(),
+	}, nil
+}
+
+// Compile implements expr.Language.
+func (l *Language) Compile(expression string) (expr.Program, error) {
+	return l.Program(expression)
+}
+
+// Program compiles expression, or returns the cached program compiled from the same text.
+// Errors wrap expr.ErrInvalidExpression, or expr.ErrUnknownVariable for references to undeclared variables or fields.
+func (l *Language) Program(expression string) (*Program, error) {
+	l.mu.Lock()
+	if e, ok := l.programs[expression]; ok {
+		l.lru.MoveToFront(e)
+		l.mu.Unlock()
+		return e.Value.(*Program), nil
+	}
+	l.mu.Unlock()
+
+	// Compile without holding the lock; concurrent compiles of the same text are harmless.
+	p, err := l.compile(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if e, ok := l.programs[expression]; ok {
+		return e.Value.(*Program), nil
+	}
+	l.programs[expression] = l.lru.PushFront(p)
+	if l.lru.Len() > l.opts.MaxPrograms {
+		oldest := l.lru.Back()
+		l.lru.Remove(oldest)
+		delete(l.programs, oldest.Value.(*Program).expression)
+	}
+	return p, nil
+}
+
+func (l *Language) compile(expression string) (*Program, error) {
+	parsed, issues := l.env.Parse(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("%w: %w", expr.ErrInvalidExpression, issues.Err())
+	}
+	// Check undeclared references before the checker, which reports them only as text.
+	if name, ok := l.undeclared(parsed.NativeRep().Expr(), nil); ok {
+		return nil, fmt.Errorf("%w %s", expr.ErrUnknownVariable, name)
+	}
+	checked, issues := l.env.Check(parsed)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("%w: %w", expr.ErrInvalidExpression, issues.Err())
+	}
+
+	variables, err := l.references(checked.NativeRep())
+	if err != nil {
+		return nil, err
+	}
+
+	program, err := l.env.Program(checked,
+		cel.CostLimit(l.opts.CostLimit),
+		cel.InterruptCheckFrequency(100),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", expr.ErrInvalidExpression, err)
+	}
+	return &Program{expression: expression, ast: checked, program: program, variables: variables}, nil
+}
+
+// undeclared returns the first variable e references that is neither declared
+// nor bound by an enclosing comprehension (in bound).
+func (l *Language) undeclared(e ast.Expr, bound map[string]bool) (string, bool) {
+	switch e.Kind() {
+	case ast.IdentKind:
+		if name := e.AsIdent(); !l.declared[name] && !bound[name] {
+			return name, true
+		}
+	case ast.SelectKind:
+		// a.b.c may reference a variable with a qualified name, e.g. "a.b".
+		var fields []string
+		operand := e
+		for operand.Kind() == ast.SelectKind {
+			fields = append(fields, operand.AsSelect().FieldName())
+			operand = operand.AsSelect().Operand()
+		}
+		if operand.Kind() == ast.IdentKind && !bound[operand.AsIdent()] {
+			name := operand.AsIdent()
+			for _, field := range slices.Backward(fields) {
+				name += "." + field
+				if l.declared[name] {
+					return "", false
+				}
+			}
+		}
+		return l.undeclared(operand, bound)
+	case ast.CallKind:
+		call := e.AsCall()
+		exprs := call.Args()
+		if call.IsMemberFunction() {
+			exprs = append([]ast.Expr{call.Target()}, exprs...)
+		}
+		return l.undeclaredIn(exprs, bound)
+	case ast.ListKind:
+		return l.undeclaredIn(e.AsList().Elements(), bound)
+	case ast.MapKind:
+		var exprs []ast.Expr
+		for _, entry := range e.AsMap().Entries() {
+			exprs = append(exprs, entry.AsMapEntry().Key(), entry.AsMapEntry().Value())
+		}
+		return l.undeclaredIn(exprs, bound)
+	case ast.StructKind:
+		var exprs []ast.Expr
+		for _, field := range e.AsStruct().Fields() {
+			exprs = append(exprs, field.AsStructField().Value())
+		}
+		return l.undeclaredIn(exprs, bound)
+	case ast.ComprehensionKind:
+		c := e.AsComprehension()
+		if name, ok := l.undeclaredIn([]ast.Expr{c.IterRange(), c.AccuInit()}, bound); ok {
+			return name, true
+		}
+		inner := maps.Clone(bound)
+		if inner == nil {
+			inner = make(map[string]bool)
+		}
+		inner[c.IterVar()] = true
+		if c.HasIterVar2() {
+			inner[c.IterVar2()] = true
+		}
+		inner[c.AccuVar()] = true
+		return l.undeclaredIn([]ast.Expr{c.LoopCondition(), c.LoopStep(), c.Result()}, inner)
+	}
+	return "", false
+}
+
+func (l *Language) undeclaredIn(exprs []ast.Expr, bound map[string]bool) (string, bool) {
+	for _, e := range exprs {
+		if name, ok := l.undeclared(e, bound); ok {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// references returns the variables a checked expression references, sorted,
+// rejecting references to fields of resources that aren't declared.
+func (l *Language) references(a *ast.AST) ([]string, error) {
+	refs := a.ReferenceMap()
+	variables := make(map[string]bool)
+	for _, ref := range refs {
+		// Comprehension variables are referenced too, but aren't declared.
+		if l.declared[ref.Name] {
+			variables[ref.Name] = true
+		}
+	}
+
+	var err error
+	ast.PreOrderVisit(a.Expr(), ast.NewExprVisitor(func(e ast.Expr) {
+		if err != nil || e.Kind() != ast.SelectKind {
+			return
+		}
+		sel := e.AsSelect()
+		operand := sel.Operand()
+		if operand.Kind() != ast.IdentKind {
+			return
+		}
+		ref, ok := refs[operand.ID()]
+		if !ok {
+			return
+		}
+		fields, ok := l.resources[ref.Name]
+		if ok && !slices.Contains(fields, sel.FieldName()) {
+			err = fmt.Errorf("%w %s.%s", expr.ErrUnknownVariable, ref.Name, sel.FieldName())
+		}
+	}))
+	if err != nil {
+		return nil, err
+	}
+	return slices.Sorted(maps.Keys(variables)), nil
+}
+
+// Program is a compiled CEL expression. It implements expr.Program.
+type Program struct {
+	expression string
+	ast        *cel.Ast
+	program    cel.Program
+	variables  []string
+}
+
+// AST returns the checked expression, e.g. to convert it to SQL.
+func (p *Program) AST() *cel.Ast {
+	return p.ast
+}
+
+// Variables implements expr.Program.
+func (p *Program) Variables() []string {
+	return slices.Clone(p.variables)
+}
+
+// Evaluate implements expr.Program.
+// Evaluation stops with [ErrCostLimitExceeded] if it exceeds the language's cost limit,
+// or with ctx's error if ctx is done.
+func (p *Program) Evaluate(ctx context.Context, vars map[string]any) (any, error) {
+	out, _, err := p.program.ContextEval(ctx, vars)
+	if err != nil {
+		var cancelled interpreter.EvalCancelledError
+		if errors.As(err, &cancelled) {
+			if cancelled.Cause == interpreter.CostLimitExceeded {
+				return nil, ErrCostLimitExceeded
+			}
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+		}
+		return nil, fmt.Errorf("%w: %w", expr.ErrInvalidExpression, err)
+	}
+	return out.Value(), nil
+}
@@ -0,0 +1,178 @@
+package cel_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"github.com/alechenninger/cazi/pkg/expr"
+	"github.com/alechenninger/cazi/pkg/expr/cel"
+	celgo "github.com/google/cel-go/cel"
+)
+
+type widget struct {
+	ID      string   `expr:"id"`
+	OwnerID string   `expr:"owner_id"`
+	Tags    []string `expr:"tags"`
+}
+
+func language(t *testing.T, opts cel.Options) *cel.Language {
+	t.Helper()
+	opts.Schema = cel.Schema{
+		Resources: []cel.Resource{{Name: "widget", Fields: []string{"id", "owner_id", "tags"}}},
+		Variables: map[string]*celgo.Type{"user": celgo.MapType(celgo.StringType, celgo.DynType)},
+	}
+	l, err := cel.New(opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return l
+}
+
+func TestEvaluate(t *testing.T) {
+	var r expr.Registry
+	r.Register(cel.Name, language(t, cel.Options{}))
+
+	p, err := r.Compile(cazi.Expression{Language: cel.Name, Expression: "widget.owner_id == user.id"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		input any
+		want  bool
+	}{
+		{"Struct", struct {
+			Widget widget         `expr:"widget"`
+			User   map[string]any `expr:"user"`
+		}{widget{ID: "widget-1", OwnerID: "alice"}, map[string]any{"id": "alice"}}, true},
+		{"Map of structs", map[string]any{"widget": &widget{ID: "widget-1", OwnerID: "alice"}, "user": map[string]any{"id": "bob"}}, false},
+		{"Maps", map[string]any{"widget": map[string]any{"owner_id": "alice"}, "user": cazi.Claims{"id": "alice"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expr.Match(context.Background(), p, tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	t.Run("Missing field", func(t *testing.T) {
+		_, err := expr.Match(context.Background(), p, map[string]any{"widget": map[string]any{}, "user": map[string]any{"id": "alice"}})
+		if !errors.Is(err, expr.ErrInvalidExpression) {
+			t.Errorf("expected expr.ErrInvalidExpression, got %v", err)
+		}
+	})
+}
+
+func TestCompile(t *testing.T) {
+	l := language(t, cel.Options{})
+
+	t.Run("Variables", func(t *testing.T) {
+		p, err := l.Compile(`widget.owner_id == user.id || widget.tags.exists(t, t == "public")`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if vars := p.Variables(); !slices.Equal(vars, []string{"user", "widget"}) {
+			t.Errorf("expected [user widget], got %v", vars)
+		}
+	})
+
+	t.Run("Qualified variable", func(t *testing.T) {
+		l, err := cel.New(cel.Options{Schema: cel.Schema{
+			Variables: map[string]*celgo.Type{"request.auth": celgo.MapType(celgo.StringType, celgo.StringType)},
+		}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := l.Compile(`request.auth.sub == "alice"`); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if _, err := l.Compile(`request.path == "/"`); !errors.Is(err, expr.ErrUnknownVariable) {
+			t.Errorf("expected expr.ErrUnknownVariable, got %v", err)
+		}
+	})
+
+	tests := []struct {
+		name       string
+		expression string
+		err        error
+	}{
+		{"Unknown variable", `owner == "alice"`, expr.ErrUnknownVariable},
+		{"Unknown field", `widget.colour == "red"`, expr.ErrUnknownVariable},
+		{"Unknown field in presence test", `has(widget.colour)`, expr.ErrUnknownVariable},
+		{"Unknown variable in comprehension", `widget.tags.exists(t, t == owner)`, expr.ErrUnknownVariable},
+		{"Unknown variable in list", `widget.owner_id in [owner]`, expr.ErrUnknownVariable},
+		{"Comprehension variable out of scope", `widget.tags.exists(t, t == "a") && t == "b"`, expr.ErrUnknownVariable},
+		{"Syntax error", `widget.owner_id ==`, expr.ErrInvalidExpression},
+		{"Type error", `widget.owner_id + 1 == user`, expr.ErrInvalidExpression},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := l.Compile(tt.expression)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("expected %v, got %v", tt.err, err)
+			}
+			if !errors.Is(err, cazi.ErrInvalidArgument) {
+				t.Errorf("expected cazi.ErrInvalidArgument, got %v", err)
+			}
+		})
+	}
+}
+
+func TestProgramCache(t *testing.T) {
+	l := language(t, cel.Options{MaxPrograms: 2})
+	compile := func(expression string) *cel.Program {
+		t.Helper()
+		p, err := l.Program(expression)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return p
+	}
+
+	first := compile(`widget.id == "1"`)
+	if compile(`widget.id == "1"`) != first {
+		t.Error("expected the cached program")
+	}
+
+	compile(`widget.id == "2"`)
+	compile(`widget.id == "3"`) // evicts the least recently used
+	if compile(`widget.id == "1"`) == first {
+		t.Error("expected the program to be evicted")
+	}
+}
+
+func TestCostLimit(t *testing.T) {
+	l := language(t, cel.Options{CostLimit: 100})
+	p, err := l.Compile(`widget.tags.all(t, t.size() > 0)`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tags := make([]string, 1000)
+	for i := range tags {
+		tags[i] = "tag"
+	}
+	_, err = expr.Match(context.Background(), p, map[string]any{"widget": widget{Tags: tags}})
+	if !errors.Is(err, cel.ErrCostLimitExceeded) {
+		t.Errorf("expected ErrCostLimitExceeded, got %v", err)
+	}
+
+	t.Run("Within limit", func(t *testing.T) {
+		matches, err := expr.Match(context.Background(), p, map[string]any{"widget": widget{Tags: tags[:3]}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !matches {
+			t.Error("expected match")
+		}
+	})
+}
@@ -0,0 +1,227 @@
+// Package expr evaluates cazi.Expression values in any registered expression language,
+// so applications don't need to couple to a specific one.
+//
+// Languages register a [Language] under the name used in Expression.Language,
+// usually from an init function of a package imported for its side effects, like database/sql drivers.
+// Expressions are then compiled with [Compile] and evaluated against variables given as a map or struct.
+package expr
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+)
+
+var (
+	// ErrInvalidExpression is returned when an expression can't be compiled or evaluated,
+	// e.g. because of a syntax error or a value of the wrong type.
+	ErrInvalidExpression = fmt.Errorf("%w: invalid expression", cazi.ErrInvalidArgument)
+
+	// ErrNotBoolean is returned by [Match] when an expression doesn't evaluate to a boolean.
+	ErrNotBoolean = fmt.Errorf("%w: expected a boolean", ErrInvalidExpression)
+
+	// ErrUnknownVariable is returned when compiling an expression that references a variable,
+	// or a field of one, that languages with a declared schema don't know about.
+	ErrUnknownVariable = fmt.Errorf("%w: unknown variable", ErrInvalidExpression)
+)
+
+// Language compiles expressions in one expression language.
+type Language interface {
+	// Compile parses and checks an expression.
+	// Errors for expressions that aren't valid wrap ErrInvalidExpression.
+	// Languages that know which variables exist reject references to others with ErrUnknownVariable,
+	// so callers can tell an expression about data they don't have from a malformed one.
+	Compile(expression string) (Program, error)
+}
+
+// Program is a compiled expression. It is safe for concurrent use.
+type Program interface {
+	// Evaluate evaluates the expression with the given variables.
+	// Values are as returned by [Vars]: structs are converted to maps.
+	Evaluate(ctx context.Context, vars map[string]any) (any, error)
+
+	// Variables returns the names of the variables the expression references, sorted.
+	Variables() []string
+}
+
+// Registry maps language names to languages. The zero value is an empty registry ready to use.
+type Registry struct {
+	mu        sync.RWMutex
+	languages map[string]Language
+}
+
+// Register adds a language under name.
+// It panics if name is empty or already registered, like database/sql.Register.
+func (r *Registry) Register(name string, l Language) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if name == "" || l == nil {
+		panic("expr: Register requires a name and a language")
+	}
+	if _, ok := r.languages[name]; ok {
+		panic(fmt.Sprintf("expr: language %q registered twice", name))
+	}
+	if r.languages == nil {
+		r.languages = make(map[string]Language)
+	}
+	r.languages[name] = l
+}
+
+// Lookup returns the language registered under name.
+// Unregistered languages are rejected with an error wrapping cazi.ErrUnsupportedLanguage.
+func (r *Registry) Lookup(name string) (Language, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	l, ok := r.languages[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q (supported: %s)", cazi.ErrUnsupportedLanguage, name, strings.Join(r.names(), ", "))
+	}
+	return l, nil
+}
+
+// Languages returns the names of the registered languages, sorted.
+func (r *Registry) Languages() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.names()
+}
+
+func (r *Registry) names() []string {
+	return slices.Sorted(maps.Keys(r.languages))
+}
+
+// Compile compiles e with the language named by its Language.
+func (r *Registry) Compile(e cazi.Expression) (Program, error) {
+	l, err := r.Lookup(e.Language)
+	if err != nil {
+		return nil, err
+	}
+	return l.Compile(e.Expression)
+}
+
+// Default is the registry used by the package-level functions.
+var Default = &Registry{}
+
+// Register adds a language to the [Default] registry.
+func Register(name string, l Language) { Default.Register(name, l) }
+
+// Lookup returns a language from the [Default] registry.
+func Lookup(name string) (Language, error) { return Default.Lookup(name) }
+
+// Languages returns the names of the languages in the [Default] registry.
+func Languages() []string { return Default.Languages() }
+
+// Compile compiles e with a language from the [Default] registry.
+func Compile(e cazi.Expression) (Program, error) { return Default.Compile(e) }
+
+// Evaluate evaluates p with the variables in input, a map or struct (see [Vars]).
+func Evaluate(ctx context.Context, p Program, input any) (any, error) {
+	vars, err := Vars(input)
+	if err != nil {
+		return nil, err
+	}
+	return p.Evaluate(ctx, vars)
+}
+
+// Match evaluates p, which must evaluate to a boolean, with the variables in input.
+// This is how conditions and filters from CheckResponse and ListObjectsResponse are applied.
+func Match(ctx context.Context, p Program, input any) (bool, error) {
+	result, err := Evaluate(ctx, p, input)
+	if err != nil {
+		return false, err
+	}
+	matches, ok := result.(bool)
+	if !ok {
+		return false, fmt.Errorf("%w, got %T", ErrNotBoolean, result)
+	}
+	return matches, nil
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// Vars converts input to variables for evaluation.
+//
+// input is a map with string keys, or a struct (or pointer to one) whose fields are the variables.
+// Structs, at any depth, are converted to maps keyed by field name,
+// or by the name in the field's expr or json tag; fields tagged "-" and unexported fields are skipped.
+// Slices are converted to []any, and maps with string keys to map[string]any.
+// A nil input has no variables.
+func Vars(input any) (map[string]any, error) {
+	if input == nil {
+		return map[string]any{}, nil
+	}
+	vars, ok := convert(reflect.ValueOf(input)).(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: variables must be a map or struct, got %T", cazi.ErrInvalidArgument, input)
+	}
+	return vars, nil
+}
+
+// convert converts v as described by Vars.
+func convert(v reflect.Value) any {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Type() == timeType {
+		return v.Interface()
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return convert(v.Elem())
+	case reflect.Struct:
+		m := make(map[string]any, v.NumField())
+		for i := range v.NumField() {
+			if name := fieldName(v.Type().Field(i)); name != "" {
+				m[name] = convert(v.Field(i))
+			}
+		}
+		return m
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return v.Interface()
+		}
+		m := make(map[string]any, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			m[iter.Key().String()] = convert(iter.Value())
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface() // bytes
+		}
+		s := make([]any, v.Len())
+		for i := range s {
+			s[i] = convert(v.Index(i))
+		}
+		return s
+	default:
+		return v.Interface()
+	}
+}
+
+// fieldName returns the variable name of f, or "" if it is skipped.
+func fieldName(f reflect.StructField) string {
+	if !f.IsExported() {
+		return ""
+	}
+	for _, key := range []string{"expr", "json"} {
+		name, _, _ := strings.Cut(f.Tag.Get(key), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return f.Name
+}
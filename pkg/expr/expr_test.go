@@ -0,0 +1,206 @@
+package expr_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"github.com/alechenninger/cazi/pkg/expr"
+)
+
+// pathLanguage evaluates expressions that are a dotted path to a value, e.g. "widget.owner_id".
+// If variables is set, paths must start with one of them.
+type pathLanguage struct {
+	variables []string
+}
+
+func (l pathLanguage) Compile(expression string) (expr.Program, error) {
+	if expression == "" {
+		return nil, fmt.Errorf("%w: empty path", expr.ErrInvalidExpression)
+	}
+	p := pathProgram(strings.Split(expression, "."))
+	if l.variables != nil && !slices.Contains(l.variables, p[0]) {
+		return nil, fmt.Errorf("%w %s", expr.ErrUnknownVariable, p[0])
+	}
+	return p, nil
+}
+
+type pathProgram []string
+
+func (p pathProgram) Evaluate(ctx context.Context, vars map[string]any) (any, error) {
+	var value any = vars
+	for _, name := range p {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is not a map", expr.ErrInvalidExpression, name)
+		}
+		value = m[name]
+	}
+	return value, nil
+}
+
+func (p pathProgram) Variables() []string {
+	return p[:1]
+}
+
+func TestRegistry(t *testing.T) {
+	var r expr.Registry
+	r.Register("path", pathLanguage{})
+
+	t.Run("Compile", func(t *testing.T) {
+		p, err := r.Compile(cazi.Expression{Language: "path", Expression: "widget.owner_id"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if vars := p.Variables(); !slices.Equal(vars, []string{"widget"}) {
+			t.Errorf("expected [widget], got %v", vars)
+		}
+	})
+
+	t.Run("Invalid expression", func(t *testing.T) {
+		_, err := r.Compile(cazi.Expression{Language: "path"})
+		if !errors.Is(err, expr.ErrInvalidExpression) {
+			t.Errorf("expected ErrInvalidExpression, got %v", err)
+		}
+		if !errors.Is(err, cazi.ErrInvalidArgument) {
+			t.Errorf("expected cazi.ErrInvalidArgument, got %v", err)
+		}
+	})
+
+	t.Run("Unknown variable", func(t *testing.T) {
+		var r expr.Registry
+		r.Register("path", pathLanguage{variables: []string{"widget"}})
+
+		_, err := r.Compile(cazi.Expression{Language: "path", Expression: "gadget.owner_id"})
+		if !errors.Is(err, expr.ErrUnknownVariable) {
+			t.Errorf("expected ErrUnknownVariable, got %v", err)
+		}
+		if !errors.Is(err, expr.ErrInvalidExpression) {
+			t.Errorf("expected ErrInvalidExpression, got %v", err)
+		}
+	})
+
+	t.Run("Unsupported language", func(t *testing.T) {
+		_, err := r.Compile(cazi.Expression{Language: "rego", Expression: "input.widget"})
+		if !errors.Is(err, cazi.ErrUnsupportedLanguage) {
+			t.Errorf("expected cazi.ErrUnsupportedLanguage, got %v", err)
+		}
+	})
+
+	t.Run("Languages", func(t *testing.T) {
+		if got := r.Languages(); !slices.Equal(got, []string{"path"}) {
+			t.Errorf("expected [path], got %v", got)
+		}
+	})
+
+	t.Run("Duplicate registration panics", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic")
+			}
+		}()
+		r.Register("path", pathLanguage{})
+	})
+}
+
+type widget struct {
+	ID      string
+	OwnerID string   `expr:"owner_id"`
+	Tags    []string `json:"tags,omitempty"`
+	Secret  string   `json:"-"`
+	Created time.Time
+	Parent  *widget
+	private string
+}
+
+func TestMatch(t *testing.T) {
+	p, err := pathLanguage{}.Compile("widget.public")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Run("Boolean", func(t *testing.T) {
+		matches, err := expr.Match(context.Background(), p, map[string]any{"widget": map[string]any{"public": true}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !matches {
+			t.Error("expected match")
+		}
+	})
+
+	t.Run("Not boolean", func(t *testing.T) {
+		_, err := expr.Match(context.Background(), p, map[string]any{"widget": map[string]any{"public": "yes"}})
+		if !errors.Is(err, expr.ErrNotBoolean) {
+			t.Errorf("expected ErrNotBoolean, got %v", err)
+		}
+	})
+}
+
+func TestVars(t *testing.T) {
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	w := widget{
+		ID:      "widget-1",
+		OwnerID: "alice",
+		Tags:    []string{"blue"},
+		Secret:  "hunter2",
+		Created: created,
+		Parent:  &widget{ID: "widget-0"},
+		private: "hidden",
+	}
+
+	t.Run("Struct", func(t *testing.T) {
+		vars, err := expr.Vars(struct{ Widget widget }{w})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := map[string]any{
+			"Widget": map[string]any{
+				"ID":       "widget-1",
+				"owner_id": "alice",
+				"tags":     []any{"blue"},
+				"Created":  created,
+				"Parent": map[string]any{
+					"ID":       "widget-0",
+					"owner_id": "",
+					"tags":     []any{},
+					"Created":  time.Time{},
+					"Parent":   nil,
+				},
+			},
+		}
+		if !reflect.DeepEqual(vars, want) {
+			t.Errorf("expected %v, got %v", want, vars)
+		}
+	})
+
+	t.Run("Map of structs", func(t *testing.T) {
+		vars, err := expr.Vars(map[string]any{"widget": &w})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		p, _ := pathLanguage{}.Compile("widget.owner_id")
+		if got, _ := p.Evaluate(context.Background(), vars); got != "alice" {
+			t.Errorf("expected 'alice', got %v", got)
+		}
+	})
+
+	t.Run("Nil", func(t *testing.T) {
+		vars, err := expr.Vars(nil)
+		if err != nil || len(vars) != 0 {
+			t.Errorf("expected no variables, got %v, %v", vars, err)
+		}
+	})
+
+	t.Run("Not a map or struct", func(t *testing.T) {
+		if _, err := expr.Vars("widget"); !errors.Is(err, cazi.ErrInvalidArgument) {
+			t.Errorf("expected cazi.ErrInvalidArgument, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,344 @@
+// Package outbox implements [cazi.FastStore] with a transactional outbox in a database/sql database.
+//
+// Application data and an outbox entry are written in the same transaction, so an entry exists
+// if and only if the application's write committed. A relay then delivers outbox entries to the
+// authorization store in order, at least once, and records what the authorization store returned,
+// which is replayed to the handler registered with Receive.
+//
+// Because delivery is at least once, Deliver functions should be idempotent
+// (e.g. use cazi.OperationTouch rather than cazi.OperationCreate).
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+)
+
+// DefaultTable is the outbox table name used when Config.Table is empty.
+const DefaultTable = "cazi_outbox"
+
+// Dialect adapts the outbox's SQL to a database.
+type Dialect struct {
+	// Placeholder returns the bind parameter placeholder for the nth (1-based) parameter.
+	Placeholder func(n int) string
+
+	// CreateTable is a statement creating the outbox table if it doesn't exist.
+	// %s is replaced with the table name.
+	CreateTable string
+}
+
+var (
+	// SQLite is the dialect for SQLite.
+	SQLite = Dialect{
+		Placeholder: func(int) string { return "?" },
+		CreateTable: `CREATE TABLE IF NOT EXISTS %s (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			payload BLOB NOT NULL,
+			result BLOB,
+			written_at BLOB,
+			delivered BOOLEAN NOT NULL DEFAULT FALSE,
+			received BOOLEAN NOT NULL DEFAULT FALSE
+		)`,
+	}
+
+	// Postgres is the dialect for PostgreSQL.
+	Postgres = Dialect{
+		Placeholder: func(n int) string { return fmt.Sprintf("$%d", n) },
+		CreateTable: `CREATE TABLE IF NOT EXISTS %s (
+			id BIGSERIAL PRIMARY KEY,
+			payload BYTEA NOT NULL,
+			result BYTEA,
+			written_at BYTEA,
+			delivered BOOLEAN NOT NULL DEFAULT FALSE,
+			received BOOLEAN NOT NULL DEFAULT FALSE
+		)`,
+	}
+)
+
+type txKey struct{}
+
+// Tx returns the transaction started by a Commit function returned from [Commit].
+// Application Write functions use it to write their own data in the same transaction as the outbox entry.
+func Tx(ctx context.Context) (*sql.Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(*sql.Tx)
+	return tx, ok
+}
+
+// Commit returns a [cazi.FastReplication] Commit function that runs fn in a transaction on db.
+// The transaction is available to fn through [Tx].
+func Commit[T any](db *sql.DB) func(ctx context.Context, fn func(ctx context.Context) (T, error)) error {
+	return func(ctx context.Context, fn func(ctx context.Context) (T, error)) error {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+
+		if _, err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				return errors.Join(err, fmt.Errorf("failed to roll back transaction: %w", rbErr))
+			}
+			return err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return nil
+	}
+}
+
+// Config configures a Store.
+type Config[T any, U any] struct {
+	// DB is the application's database, which holds the outbox table.
+	DB *sql.DB
+
+	// Dialect adapts SQL to the database (e.g. SQLite or Postgres).
+	Dialect Dialect
+
+	// Table is the outbox table name. Defaults to DefaultTable.
+	Table string
+
+	// Deliver writes an outbox entry's data to the authorization store,
+	// returning what the authorization store wrote and the token it was written at.
+	Deliver func(ctx context.Context, data T) (cazi.AuthorizationData[U], error)
+}
+
+// Store implements cazi.FastStore using a transactional outbox.
+// T is the data written by the application; U is the data returned by the authorization store.
+// T and U are stored as JSON.
+type Store[T any, U any] struct {
+	db      *sql.DB
+	dialect Dialect
+	table   string
+	deliver func(ctx context.Context, data T) (cazi.AuthorizationData[U], error)
+
+	mu       sync.Mutex
+	protocol *cazi.FastReplication[T, U]
+	onData   func(ctx context.Context, data cazi.AuthorizationData[U]) error
+
+	relayMu sync.Mutex
+}
+
+var _ cazi.FastStore[any, any] = (*Store[any, any])(nil)
+
+// New creates a Store, creating the outbox table if it doesn't exist.
+func New[T any, U any](ctx context.Context, cfg Config[T, U]) (*Store[T, U], error) {
+	if cfg.DB == nil {
+		return nil, errors.New("outbox: DB is required")
+	}
+	if cfg.Dialect.Placeholder == nil {
+		return nil, errors.New("outbox: Dialect is required")
+	}
+	if cfg.Deliver == nil {
+		return nil, errors.New("outbox: Deliver is required")
+	}
+	table := cfg.Table
+	if table == "" {
+		table = DefaultTable
+	}
+
+	if _, err := cfg.DB.ExecContext(ctx, fmt.Sprintf(cfg.Dialect.CreateTable, table)); err != nil {
+		return nil, fmt.Errorf("outbox: failed to create table: %w", err)
+	}
+
+	return &Store[T, U]{
+		db:      cfg.DB,
+		dialect: cfg.Dialect,
+		table:   table,
+		deliver: cfg.Deliver,
+	}, nil
+}
+
+// WithFastReplication implements cazi.FastStore.
+// The protocol's Commit function must make its transaction available through [Tx];
+// use [Commit] to create one.
+func (s *Store[T, U]) WithFastReplication(protocol cazi.FastReplication[T, U]) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.protocol = &protocol
+}
+
+// Receive implements cazi.FastStore.
+//
+// onData is called by Relay, in outbox order, for each entry delivered to the authorization store
+// that has not yet been received, including entries delivered before Receive was called.
+// If onData returns an error, the entry is retried on the next Relay.
+func (s *Store[T, U]) Receive(onData func(ctx context.Context, data cazi.AuthorizationData[U]) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.onData != nil {
+		return errors.New("outbox: a receiver is already registered")
+	}
+	s.onData = onData
+	return nil
+}
+
+// Write runs the replication protocol: the application's Write function and the outbox entry
+// are committed together in one transaction.
+func (s *Store[T, U]) Write(ctx context.Context) (T, error) {
+	s.mu.Lock()
+	protocol := s.protocol
+	s.mu.Unlock()
+
+	var result T
+	if protocol == nil {
+		return result, errors.New("outbox: no replication protocol configured (see WithFastReplication)")
+	}
+
+	err := protocol.Commit(ctx, func(ctx context.Context) (T, error) {
+		data, err := protocol.Write(ctx)
+		if err != nil {
+			return data, err
+		}
+
+		tx, ok := Tx(ctx)
+		if !ok {
+			return data, errors.New("outbox: Commit did not provide a transaction (see outbox.Commit)")
+		}
+
+		payload, err := json.Marshal(data)
+		if err != nil {
+			return data, fmt.Errorf("outbox: failed to encode entry: %w", err)
+		}
+		query := fmt.Sprintf("INSERT INTO %s (payload) VALUES (%s)", s.table, s.dialect.Placeholder(1))
+		if _, err := tx.ExecContext(ctx, query, payload); err != nil {
+			return data, fmt.Errorf("outbox: failed to write entry: %w", err)
+		}
+
+		result = data
+		return data, nil
+	})
+	return result, err
+}
+
+// Relay delivers pending outbox entries to the authorization store in order,
+// then passes delivered entries to the receiver, if one is registered.
+// It stops at the first entry that fails, so later entries are never delivered before earlier ones.
+// Returns the number of entries delivered.
+func (s *Store[T, U]) Relay(ctx context.Context) (int, error) {
+	s.relayMu.Lock()
+	defer s.relayMu.Unlock()
+
+	delivered, err := s.deliverPending(ctx)
+	if err != nil {
+		return delivered, err
+	}
+	return delivered, s.receiveDelivered(ctx)
+}
+
+// Run calls Relay every interval until ctx is done or Relay fails.
+func (s *Store[T, U]) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Relay(ctx); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+type entry struct {
+	id        int64
+	payload   []byte
+	writtenAt []byte
+}
+
+func (s *Store[T, U]) deliverPending(ctx context.Context) (int, error) {
+	pending, err := s.query(ctx, fmt.Sprintf(
+		"SELECT id, payload, written_at FROM %s WHERE delivered = FALSE ORDER BY id", s.table))
+	if err != nil {
+		return 0, err
+	}
+
+	update := fmt.Sprintf("UPDATE %s SET delivered = TRUE, result = %s, written_at = %s WHERE id = %s",
+		s.table, s.dialect.Placeholder(1), s.dialect.Placeholder(2), s.dialect.Placeholder(3))
+
+	for i, e := range pending {
+		var data T
+		if err := json.Unmarshal(e.payload, &data); err != nil {
+			return i, fmt.Errorf("outbox: failed to decode entry %d: %w", e.id, err)
+		}
+
+		written, err := s.deliver(ctx, data)
+		if err != nil {
+			return i, fmt.Errorf("outbox: failed to deliver entry %d: %w", e.id, err)
+		}
+
+		result, err := json.Marshal(written.Data)
+		if err != nil {
+			return i, fmt.Errorf("outbox: failed to encode result of entry %d: %w", e.id, err)
+		}
+		if _, err := s.db.ExecContext(ctx, update, result, []byte(written.WrittenAt), e.id); err != nil {
+			return i, fmt.Errorf("outbox: failed to mark entry %d delivered: %w", e.id, err)
+		}
+	}
+	return len(pending), nil
+}
+
+func (s *Store[T, U]) receiveDelivered(ctx context.Context) error {
+	s.mu.Lock()
+	onData := s.onData
+	s.mu.Unlock()
+	if onData == nil {
+		return nil
+	}
+
+	delivered, err := s.query(ctx, fmt.Sprintf(
+		"SELECT id, result, written_at FROM %s WHERE delivered = TRUE AND received = FALSE ORDER BY id", s.table))
+	if err != nil {
+		return err
+	}
+
+	update := fmt.Sprintf("UPDATE %s SET received = TRUE WHERE id = %s", s.table, s.dialect.Placeholder(1))
+
+	for _, e := range delivered {
+		var data U
+		if err := json.Unmarshal(e.payload, &data); err != nil {
+			return fmt.Errorf("outbox: failed to decode result of entry %d: %w", e.id, err)
+		}
+
+		if err := onData(ctx, cazi.AuthorizationData[U]{WrittenAt: e.writtenAt, Data: data}); err != nil {
+			return fmt.Errorf("outbox: receiver failed for entry %d: %w", e.id, err)
+		}
+		if _, err := s.db.ExecContext(ctx, update, e.id); err != nil {
+			return fmt.Errorf("outbox: failed to mark entry %d received: %w", e.id, err)
+		}
+	}
+	return nil
+}
+
+// query reads all matching entries before returning, so that callers can write while iterating
+// even with a single database connection.
+func (s *Store[T, U]) query(ctx context.Context, query string) ([]entry, error) {
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("outbox: failed to query entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []entry
+	for rows.Next() {
+		var e entry
+		if err := rows.Scan(&e.id, &e.payload, &e.writtenAt); err != nil {
+			return nil, fmt.Errorf("outbox: failed to scan entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("outbox: failed to read entries: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,310 @@
+package outbox_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/alechenninger/cazi/implementations/memory"
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"github.com/alechenninger/cazi/pkg/outbox"
+)
+
+type widget struct {
+	ID    string `json:"id"`
+	Owner string `json:"owner"`
+}
+
+func openDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	// Each connection to :memory: is a separate database.
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := db.Exec("CREATE TABLE widgets (id TEXT PRIMARY KEY, owner TEXT NOT NULL)"); err != nil {
+		t.Fatalf("failed to create widgets table: %v", err)
+	}
+	return db
+}
+
+// deliverOwner writes the widget's owner relationship to authz.
+func deliverOwner(authz cazi.RelationshipWriter) func(context.Context, widget) (cazi.AuthorizationData[cazi.Relationship], error) {
+	return func(ctx context.Context, w widget) (cazi.AuthorizationData[cazi.Relationship], error) {
+		rel := cazi.Relationship{
+			Object:   cazi.ResourceReference{Type: "widget", ID: w.ID},
+			Relation: "owner",
+			Subject:  cazi.ResourceReference{Type: "user", ID: w.Owner},
+		}
+		resp, err := authz.WriteRelationships(ctx, cazi.WriteRelationshipsRequest{
+			Updates: []cazi.RelationshipUpdate{{Operation: cazi.OperationTouch, Relationship: rel}},
+		})
+		if err != nil {
+			return cazi.AuthorizationData[cazi.Relationship]{}, err
+		}
+		return cazi.AuthorizationData[cazi.Relationship]{WrittenAt: resp.WrittenAt, Data: rel}, nil
+	}
+}
+
+func newStore(t *testing.T, db *sql.DB, deliver func(context.Context, widget) (cazi.AuthorizationData[cazi.Relationship], error)) *outbox.Store[widget, cazi.Relationship] {
+	t.Helper()
+	store, err := outbox.New(context.Background(), outbox.Config[widget, cazi.Relationship]{
+		DB:      db,
+		Dialect: outbox.SQLite,
+		Deliver: deliver,
+	})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	return store
+}
+
+// insertWidget returns a FastReplication Write function inserting w with the outbox transaction.
+func insertWidget(w widget) func(ctx context.Context) (widget, error) {
+	return func(ctx context.Context) (widget, error) {
+		tx, ok := outbox.Tx(ctx)
+		if !ok {
+			return widget{}, errors.New("no transaction")
+		}
+		_, err := tx.ExecContext(ctx, "INSERT INTO widgets (id, owner) VALUES (?, ?)", w.ID, w.Owner)
+		return w, err
+	}
+}
+
+func countRows(t *testing.T, db *sql.DB, table string) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s", table)).Scan(&n); err != nil {
+		t.Fatalf("failed to count %s: %v", table, err)
+	}
+	return n
+}
+
+func TestWriteAndRelay(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	authz := memory.New()
+	store := newStore(t, db, deliverOwner(authz))
+
+	w := widget{ID: "w1", Owner: "alice"}
+	store.WithFastReplication(cazi.FastReplication[widget, cazi.Relationship]{
+		Commit: outbox.Commit[widget](db),
+		Write:  insertWidget(w),
+	})
+
+	got, err := store.Write(ctx)
+	if err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	if got != w {
+		t.Errorf("expected %v, got %v", w, got)
+	}
+	if n := countRows(t, db, "widgets"); n != 1 {
+		t.Errorf("expected 1 widget, got %d", n)
+	}
+
+	var received []cazi.AuthorizationData[cazi.Relationship]
+	if err := store.Receive(func(ctx context.Context, data cazi.AuthorizationData[cazi.Relationship]) error {
+		received = append(received, data)
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected receive error: %v", err)
+	}
+
+	delivered, err := store.Relay(ctx)
+	if err != nil {
+		t.Fatalf("unexpected relay error: %v", err)
+	}
+	if delivered != 1 {
+		t.Errorf("expected 1 delivered entry, got %d", delivered)
+	}
+
+	if len(received) != 1 {
+		t.Fatalf("expected 1 received entry, got %d", len(received))
+	}
+	if received[0].Data.Subject.ID != "alice" {
+		t.Errorf("expected owner 'alice', got '%s'", received[0].Data.Subject.ID)
+	}
+
+	resp, err := authz.Check(ctx, cazi.CheckRequest{
+		Subject:        cazi.Subject{Assertion: cazi.ResourceReference{Type: "user", ID: "alice"}},
+		Verb:           "owner",
+		Object:         cazi.Object{Assertion: cazi.ResourceReference{Type: "widget", ID: "w1"}},
+		AtLeastAsFresh: received[0].WrittenAt,
+	})
+	if err != nil {
+		t.Fatalf("unexpected check error: %v", err)
+	}
+	if resp.Decision != cazi.DecisionAllow {
+		t.Errorf("expected allow, got %v", resp.Decision)
+	}
+
+	t.Run("Relay is idempotent", func(t *testing.T) {
+		delivered, err := store.Relay(ctx)
+		if err != nil {
+			t.Fatalf("unexpected relay error: %v", err)
+		}
+		if delivered != 0 {
+			t.Errorf("expected 0 delivered entries, got %d", delivered)
+		}
+		if len(received) != 1 {
+			t.Errorf("expected entry to be received once, got %d", len(received))
+		}
+	})
+}
+
+func TestWriteRollsBack(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	store := newStore(t, db, deliverOwner(memory.New()))
+
+	writeErr := errors.New("write failed")
+	store.WithFastReplication(cazi.FastReplication[widget, cazi.Relationship]{
+		Commit: outbox.Commit[widget](db),
+		Write: func(ctx context.Context) (widget, error) {
+			if _, err := insertWidget(widget{ID: "w1", Owner: "alice"})(ctx); err != nil {
+				return widget{}, err
+			}
+			return widget{}, writeErr
+		},
+	})
+
+	if _, err := store.Write(ctx); !errors.Is(err, writeErr) {
+		t.Fatalf("expected write error, got %v", err)
+	}
+	if n := countRows(t, db, "widgets"); n != 0 {
+		t.Errorf("expected no widgets, got %d", n)
+	}
+	if n := countRows(t, db, outbox.DefaultTable); n != 0 {
+		t.Errorf("expected no outbox entries, got %d", n)
+	}
+}
+
+func TestRelayStopsAtFailure(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	authz := memory.New()
+
+	fail := true
+	deliver := deliverOwner(authz)
+	var attempts []string
+	store := newStore(t, db, func(ctx context.Context, w widget) (cazi.AuthorizationData[cazi.Relationship], error) {
+		attempts = append(attempts, w.ID)
+		if fail && w.ID == "w1" {
+			return cazi.AuthorizationData[cazi.Relationship]{}, errors.New("unavailable")
+		}
+		return deliver(ctx, w)
+	})
+
+	for _, w := range []widget{{ID: "w1", Owner: "alice"}, {ID: "w2", Owner: "bob"}} {
+		store.WithFastReplication(cazi.FastReplication[widget, cazi.Relationship]{
+			Commit: outbox.Commit[widget](db),
+			Write:  insertWidget(w),
+		})
+		if _, err := store.Write(ctx); err != nil {
+			t.Fatalf("unexpected write error: %v", err)
+		}
+	}
+
+	delivered, err := store.Relay(ctx)
+	if err == nil {
+		t.Fatal("expected relay error")
+	}
+	if delivered != 0 {
+		t.Errorf("expected 0 delivered entries, got %d", delivered)
+	}
+	if len(attempts) != 1 {
+		t.Errorf("expected later entries not to be attempted, got attempts %v", attempts)
+	}
+
+	fail = false
+	delivered, err = store.Relay(ctx)
+	if err != nil {
+		t.Fatalf("unexpected relay error: %v", err)
+	}
+	if delivered != 2 {
+		t.Errorf("expected 2 delivered entries, got %d", delivered)
+	}
+}
+
+func TestReceiveReplaysEarlierDeliveries(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	store := newStore(t, db, deliverOwner(memory.New()))
+
+	store.WithFastReplication(cazi.FastReplication[widget, cazi.Relationship]{
+		Commit: outbox.Commit[widget](db),
+		Write:  insertWidget(widget{ID: "w1", Owner: "alice"}),
+	})
+	if _, err := store.Write(ctx); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	if _, err := store.Relay(ctx); err != nil {
+		t.Fatalf("unexpected relay error: %v", err)
+	}
+
+	var received int
+	failReceive := true
+	if err := store.Receive(func(ctx context.Context, data cazi.AuthorizationData[cazi.Relationship]) error {
+		if failReceive {
+			return errors.New("receiver failed")
+		}
+		if len(data.WrittenAt) == 0 {
+			t.Error("expected WrittenAt token")
+		}
+		received++
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected receive error: %v", err)
+	}
+
+	if _, err := store.Relay(ctx); err == nil {
+		t.Fatal("expected receiver error")
+	}
+
+	failReceive = false
+	if _, err := store.Relay(ctx); err != nil {
+		t.Fatalf("unexpected relay error: %v", err)
+	}
+	if received != 1 {
+		t.Errorf("expected 1 received entry, got %d", received)
+	}
+
+	t.Run("Only one receiver", func(t *testing.T) {
+		err := store.Receive(func(context.Context, cazi.AuthorizationData[cazi.Relationship]) error { return nil })
+		if err == nil {
+			t.Error("expected error registering a second receiver")
+		}
+	})
+}
+
+func TestWriteRequiresTransaction(t *testing.T) {
+	db := openDB(t)
+	store := newStore(t, db, deliverOwner(memory.New()))
+
+	t.Run("No protocol", func(t *testing.T) {
+		if _, err := store.Write(context.Background()); err == nil {
+			t.Error("expected error without a replication protocol")
+		}
+	})
+
+	t.Run("Commit without transaction", func(t *testing.T) {
+		store.WithFastReplication(cazi.FastReplication[widget, cazi.Relationship]{
+			Commit: func(ctx context.Context, fn func(ctx context.Context) (widget, error)) error {
+				_, err := fn(ctx)
+				return err
+			},
+			Write: func(ctx context.Context) (widget, error) { return widget{ID: "w1"}, nil },
+		})
+		if _, err := store.Write(context.Background()); err == nil {
+			t.Error("expected error when Commit provides no transaction")
+		}
+	})
+}
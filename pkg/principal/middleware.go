@@ -0,0 +1,105 @@
+package principal
+
+import (
+	"context"
+	"maps"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+)
+
+// Middleware returns cazi.Middleware that resolves the subject of each request with r.
+//
+// The subject assertion is replaced with the principal's reference before the request is passed on,
+// so the wrapped implementation only sees canonical subjects.
+// The principal is also available to it with [FromContext], except for items of a batch passed to a cazi.BatchChecker.
+// The principal's claims are added to the RequesterContext of each response if they are verified
+// (see Principal.Verified), under any claims the implementation returned itself.
+// Unverified claims are only available to the wrapped implementation, so callers can't inject claims
+// into the RequesterContext, and from there into tokens minted from it.
+//
+// Requests whose subject can't be resolved fail with the resolver's error without being passed on;
+// in a batch, only the items that can't be resolved fail.
+// Place this middleware before caching or coalescing, so they see canonical subjects.
+func Middleware(r Resolver) cazi.Middleware {
+	return func(next cazi.Interface) cazi.Interface {
+		hooks := resolvingHooks(r)
+		if _, ok := next.(cazi.BatchChecker); !ok {
+			// Batches fall back to the Check hook, which resolves each item.
+			hooks.BatchCheck = nil
+		}
+		return cazi.Intercept(hooks)(next)
+	}
+}
+
+// resolvingHooks resolve the subject of each request with r.
+func resolvingHooks(r Resolver) cazi.Hooks {
+	return cazi.Hooks{
+		Check: func(ctx context.Context, req cazi.CheckRequest, next cazi.CheckFunc) (cazi.CheckResponse, error) {
+			p, err := r.Resolve(ctx, req.Subject.Assertion)
+			if err != nil {
+				return cazi.CheckResponse{}, err
+			}
+			req.Subject.Assertion = p.Reference
+			resp, err := next(NewContext(ctx, p), req)
+			if err != nil {
+				return resp, err
+			}
+			resp.Context.RequesterContext = withClaims(p, resp.Context.RequesterContext)
+			return resp, nil
+		},
+		ListObjects: func(ctx context.Context, req cazi.ListObjectsRequest, next cazi.ListObjectsFunc) (cazi.ListObjectsResponse, error) {
+			p, err := r.Resolve(ctx, req.Subject.Assertion)
+			if err != nil {
+				return cazi.ListObjectsResponse{}, err
+			}
+			req.Subject.Assertion = p.Reference
+			resp, err := next(NewContext(ctx, p), req)
+			if err != nil {
+				return resp, err
+			}
+			resp.Context.RequesterContext = withClaims(p, resp.Context.RequesterContext)
+			return resp, nil
+		},
+		BatchCheck: func(ctx context.Context, req cazi.BatchCheckRequest, next cazi.BatchCheckFunc) (cazi.BatchCheckResponse, error) {
+			results := make([]cazi.BatchCheckResult, len(req.Items))
+			var resolved cazi.BatchCheckRequest
+			var principals []Principal
+			var indexes []int // of resolved items in req.Items
+			for i, item := range req.Items {
+				p, err := r.Resolve(ctx, item.Subject.Assertion)
+				if err != nil {
+					results[i].Err = err
+					continue
+				}
+				item.Subject.Assertion = p.Reference
+				resolved.Items = append(resolved.Items, item)
+				principals = append(principals, p)
+				indexes = append(indexes, i)
+			}
+
+			if len(resolved.Items) > 0 {
+				resp, err := next(ctx, resolved)
+				if err != nil {
+					return cazi.BatchCheckResponse{}, err
+				}
+				for j, result := range resp.Results {
+					if result.Err == nil {
+						result.Response.Context.RequesterContext = withClaims(principals[j], result.Response.Context.RequesterContext)
+					}
+					results[indexes[j]] = result
+				}
+			}
+			return cazi.BatchCheckResponse{Results: results}, nil
+		},
+	}
+}
+
+// withClaims returns the principal's verified claims overlaid with rc.
+func withClaims(p Principal, rc cazi.Claims) cazi.Claims {
+	if !p.Verified || len(p.Claims) == 0 {
+		return rc
+	}
+	merged := maps.Clone(p.Claims)
+	maps.Copy(merged, rc)
+	return merged
+}
@@ -0,0 +1,193 @@
+package principal_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"github.com/alechenninger/cazi/pkg/principal"
+)
+
+// refAuthz allows only user:alice, rejecting any subject that isn't a ResourceReference.
+// It records the principal it finds in the context of each Check.
+type refAuthz struct {
+	mu         sync.Mutex
+	principals []principal.Principal
+}
+
+func (a *refAuthz) Check(ctx context.Context, req cazi.CheckRequest) (cazi.CheckResponse, error) {
+	ref, ok := req.Subject.Assertion.(cazi.ResourceReference)
+	if !ok {
+		return cazi.CheckResponse{}, fmt.Errorf("%w: %T", cazi.ErrUnsupportedAssertion, req.Subject.Assertion)
+	}
+	if p, ok := principal.FromContext(ctx); ok {
+		a.mu.Lock()
+		a.principals = append(a.principals, p)
+		a.mu.Unlock()
+	}
+	resp := cazi.CheckResponse{
+		Decision: cazi.DecisionDeny,
+		Context:  cazi.AuthorizationContext{RequesterContext: cazi.Claims{"tenant": "acme"}},
+	}
+	if ref == (cazi.ResourceReference{Type: "user", ID: "alice"}) {
+		resp.Decision = cazi.DecisionAllow
+	}
+	return resp, nil
+}
+
+func (a *refAuthz) ListObjects(ctx context.Context, req cazi.ListObjectsRequest) (cazi.ListObjectsResponse, error) {
+	if _, ok := req.Subject.Assertion.(cazi.ResourceReference); !ok {
+		return cazi.ListObjectsResponse{}, fmt.Errorf("%w: %T", cazi.ErrUnsupportedAssertion, req.Subject.Assertion)
+	}
+	return cazi.ListObjectsResponse{}, nil
+}
+
+// batchingAuthz implements cazi.BatchChecker, recording the size of each batch.
+type batchingAuthz struct {
+	refAuthz
+	batches []int
+}
+
+func (a *batchingAuthz) BatchCheck(ctx context.Context, req cazi.BatchCheckRequest) (cazi.BatchCheckResponse, error) {
+	a.batches = append(a.batches, len(req.Items))
+	results := make([]cazi.BatchCheckResult, len(req.Items))
+	for i, item := range req.Items {
+		results[i].Response, results[i].Err = a.Check(ctx, item)
+	}
+	return cazi.BatchCheckResponse{Results: results}, nil
+}
+
+func check(subject cazi.Assertion) cazi.CheckRequest {
+	return cazi.CheckRequest{
+		Subject: cazi.Subject{Assertion: subject},
+		Verb:    "read",
+		Object:  cazi.Object{Assertion: cazi.ResourceReference{Type: "widget", ID: "1"}},
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	r := resolver(t)
+
+	t.Run("Check", func(t *testing.T) {
+		next := &refAuthz{}
+		authz := principal.Middleware(r)(next)
+
+		resp, err := authz.Check(context.Background(), check(token(t, map[string]any{"sub": "alice", "email": "alice@example.com"})))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.Decision != cazi.DecisionAllow {
+			t.Errorf("expected allow, got %v", resp.Decision)
+		}
+		rc := resp.Context.RequesterContext
+		if rc["email"] != "alice@example.com" || rc["tenant"] != "acme" {
+			t.Errorf("expected principal and implementation claims, got %v", rc)
+		}
+		if len(next.principals) != 1 || next.principals[0].Reference.ID != "alice" {
+			t.Errorf("expected the principal in the context, got %v", next.principals)
+		}
+	})
+
+	t.Run("Unverified claims are not added", func(t *testing.T) {
+		next := &refAuthz{}
+		authz := principal.Middleware(r)(next)
+
+		resp, err := authz.Check(context.Background(), check(cazi.Claims{"sub": "alice", "email": "alice@example.com"}))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.Decision != cazi.DecisionAllow {
+			t.Errorf("expected allow, got %v", resp.Decision)
+		}
+		if rc := resp.Context.RequesterContext; rc["email"] != nil || rc["tenant"] != "acme" {
+			t.Errorf("expected only implementation claims, got %v", rc)
+		}
+		if len(next.principals) != 1 || next.principals[0].Verified || next.principals[0].Claims["email"] != "alice@example.com" {
+			t.Errorf("expected the unverified principal in the context, got %v", next.principals)
+		}
+	})
+
+	t.Run("Unresolved subjects are not passed on", func(t *testing.T) {
+		next := &refAuthz{}
+		authz := principal.Middleware(r)(next)
+
+		_, err := authz.Check(context.Background(), check(cazi.Claims{"email": "alice@example.com"}))
+		if !errors.Is(err, cazi.ErrInvalidArgument) {
+			t.Errorf("expected cazi.ErrInvalidArgument, got %v", err)
+		}
+		if len(next.principals) != 0 {
+			t.Errorf("expected no calls, got %d", len(next.principals))
+		}
+	})
+
+	t.Run("ListObjects", func(t *testing.T) {
+		authz := principal.Middleware(r)(&refAuthz{})
+
+		resp, err := authz.ListObjects(context.Background(), cazi.ListObjectsRequest{
+			Subject:    cazi.Subject{Assertion: cazi.ResourceReference{Type: "user", ID: "alice"}},
+			Verb:       "read",
+			ObjectType: "widget",
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if rc := resp.Context.RequesterContext; rc["sub"] != "alice" {
+			t.Errorf("expected sub in requester context, got %v", rc)
+		}
+	})
+}
+
+func TestMiddlewareBatchCheck(t *testing.T) {
+	r := resolver(t)
+	req := cazi.BatchCheckRequest{Items: []cazi.CheckRequest{
+		check(cazi.Claims{"sub": "alice"}),
+		check(cazi.Claims{"email": "nobody@example.com"}),
+		check(cazi.ResourceReference{Type: "user", ID: "bob"}),
+	}}
+
+	verify := func(t *testing.T, resp cazi.BatchCheckResponse) {
+		t.Helper()
+		if len(resp.Results) != 3 {
+			t.Fatalf("expected 3 results, got %d", len(resp.Results))
+		}
+		if got := resp.Results[0]; got.Err != nil || got.Response.Decision != cazi.DecisionAllow {
+			t.Errorf("expected allow, got %+v", got)
+		}
+		if got := resp.Results[1]; !errors.Is(got.Err, cazi.ErrInvalidArgument) {
+			t.Errorf("expected cazi.ErrInvalidArgument, got %v", got.Err)
+		}
+		if got := resp.Results[2]; got.Err != nil || got.Response.Decision != cazi.DecisionDeny {
+			t.Errorf("expected deny, got %+v", got)
+		}
+		if rc := resp.Results[2].Response.Context.RequesterContext; rc["sub"] != "bob" || rc["tenant"] != "acme" {
+			t.Errorf("expected principal and implementation claims, got %v", rc)
+		}
+	}
+
+	t.Run("Batch checker", func(t *testing.T) {
+		next := &batchingAuthz{}
+		resp, err := cazi.BatchCheck(context.Background(), principal.Middleware(r)(next), req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		verify(t, resp)
+		if len(next.batches) != 1 || next.batches[0] != 2 {
+			t.Errorf("expected one batch of the 2 resolved items, got %v", next.batches)
+		}
+	})
+
+	t.Run("Fallback", func(t *testing.T) {
+		next := &refAuthz{}
+		resp, err := cazi.BatchCheck(context.Background(), principal.Middleware(r)(next), req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		verify(t, resp)
+		if len(next.principals) != 2 {
+			t.Errorf("expected the principal in the context of 2 checks, got %d", len(next.principals))
+		}
+	})
+}
@@ -0,0 +1,163 @@
+// Package principal resolves subject assertions into a canonical principal,
+// so implementations only need to understand resource references.
+//
+// A [Resolver] turns an assertion, such as claims or a JWT, into a [Principal]:
+// a ResourceReference identifying the subject plus claims about it.
+// Resolvers for each kind of assertion are combined with [Chain],
+// and [Middleware] applies them in front of any cazi.Interface.
+package principal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"github.com/alechenninger/cazi/pkg/claims"
+	"github.com/alechenninger/cazi/pkg/tokens"
+)
+
+// Principal is the canonical identity of a subject.
+type Principal struct {
+	Reference cazi.ResourceReference // e.g. user:alice
+	Claims    cazi.Claims            // claims about the subject, e.g. from a verified token; may be nil
+
+	// Verified reports whether Claims were established by the resolver, e.g. by verifying a token,
+	// rather than asserted by the caller. Only verified claims are added to responses by [Middleware].
+	Verified bool
+}
+
+// Resolver resolves a subject assertion to a principal.
+type Resolver interface {
+	// Resolve returns the principal identified by a.
+	// If the resolver doesn't handle this kind of assertion, it returns an error wrapping cazi.ErrUnsupportedAssertion.
+	Resolve(ctx context.Context, a cazi.Assertion) (Principal, error)
+}
+
+// ResolverFunc adapts a function to a Resolver.
+type ResolverFunc func(ctx context.Context, a cazi.Assertion) (Principal, error)
+
+// Resolve implements Resolver.
+func (f ResolverFunc) Resolve(ctx context.Context, a cazi.Assertion) (Principal, error) {
+	return f(ctx, a)
+}
+
+// Chain returns a resolver that tries each resolver in order,
+// moving on to the next only if one doesn't handle the assertion.
+// Any other error is returned immediately.
+func Chain(resolvers ...Resolver) Resolver {
+	return ResolverFunc(func(ctx context.Context, a cazi.Assertion) (Principal, error) {
+		for _, r := range resolvers {
+			p, err := r.Resolve(ctx, a)
+			if errors.Is(err, cazi.ErrUnsupportedAssertion) {
+				continue
+			}
+			return p, err
+		}
+		return Principal{}, fmt.Errorf("%w: no resolver for %T", cazi.ErrUnsupportedAssertion, a)
+	})
+}
+
+// References returns a resolver for ResourceReference assertions, which are already canonical.
+// If types are given, references of other types are rejected with an error wrapping cazi.ErrUnsupportedResourceType.
+// The principal's claims contain only its sub, and are verified since they only restate the reference.
+func References(types ...string) Resolver {
+	return ResolverFunc(func(ctx context.Context, a cazi.Assertion) (Principal, error) {
+		ref, ok := a.(cazi.ResourceReference)
+		if !ok {
+			return Principal{}, fmt.Errorf("%w: expected a ResourceReference, got %T", cazi.ErrUnsupportedAssertion, a)
+		}
+		if len(types) > 0 && !slices.Contains(types, ref.Type) {
+			return Principal{}, fmt.Errorf("%w: subject type %s", cazi.ErrUnsupportedResourceType, ref.Type)
+		}
+		c := make(cazi.Claims)
+		claims.Sub.Set(c, ref.ID)
+		return Principal{Reference: ref, Claims: c, Verified: true}, nil
+	})
+}
+
+// Mapping describes how claims identify a principal.
+type Mapping struct {
+	// Type is the resource type of principals, e.g. "user".
+	Type string
+
+	// ID is the claim holding the principal's ID. Defaults to claims.Sub.
+	ID cazi.Claim[string]
+}
+
+// principal returns the principal identified by c.
+func (m Mapping) principal(c cazi.Claims) (Principal, error) {
+	id := m.ID
+	if id.Get == nil {
+		id = claims.Sub
+	}
+	value, ok := id.Get(c)
+	if !ok || value == "" {
+		return Principal{}, fmt.Errorf("%w: claims do not identify the subject", cazi.ErrInvalidArgument)
+	}
+	return Principal{Reference: cazi.ResourceReference{Type: m.Type, ID: value}, Claims: c}, nil
+}
+
+// FromClaims returns a resolver for Claims assertions, identifying the principal with m.
+// The principal's claims are a copy of the assertion. They are asserted by the caller, so they aren't verified.
+func FromClaims(m Mapping) Resolver {
+	return ResolverFunc(func(ctx context.Context, a cazi.Assertion) (Principal, error) {
+		c, ok := a.(cazi.Claims)
+		if !ok {
+			return Principal{}, fmt.Errorf("%w: expected Claims, got %T", cazi.ErrUnsupportedAssertion, a)
+		}
+		return m.principal(maps.Clone(c))
+	})
+}
+
+// tokenClaims are registered JWT claims that describe the token rather than the principal.
+var tokenClaims = []string{"iss", "aud", "exp", "nbf", "iat", "jti"}
+
+// FromTokens returns a resolver for JWT assertions, verified with v and identifying the principal with m.
+// The principal's claims are the token's claims, without those that describe the token itself (iss, aud, exp, nbf, iat and jti).
+// Tokens that fail verification are rejected with an error wrapping cazi.ErrInvalidArgument;
+// the claims of tokens that pass are verified.
+func FromTokens(v *tokens.Verifier, m Mapping) Resolver {
+	return ResolverFunc(func(ctx context.Context, a cazi.Assertion) (Principal, error) {
+		c, err := v.VerifyAssertion(ctx, a)
+		if errors.Is(err, tokens.ErrInvalidToken) {
+			return Principal{}, fmt.Errorf("%w: %w", cazi.ErrInvalidArgument, err)
+		}
+		if err != nil {
+			return Principal{}, err
+		}
+		for _, k := range tokenClaims {
+			delete(c, k)
+		}
+		p, err := m.principal(c)
+		p.Verified = err == nil
+		return p, err
+	})
+}
+
+// Enrich returns a resolver that resolves with r, then passes the principal through enrich,
+// e.g. to add roles from a directory.
+func Enrich(r Resolver, enrich func(ctx context.Context, p Principal) (Principal, error)) Resolver {
+	return ResolverFunc(func(ctx context.Context, a cazi.Assertion) (Principal, error) {
+		p, err := r.Resolve(ctx, a)
+		if err != nil {
+			return Principal{}, err
+		}
+		return enrich(ctx, p)
+	})
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying p.
+func NewContext(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext returns the principal carried by ctx, if any.
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(Principal)
+	return p, ok
+}
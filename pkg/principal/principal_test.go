@@ -0,0 +1,157 @@
+package principal_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"github.com/alechenninger/cazi/pkg/claims"
+	"github.com/alechenninger/cazi/pkg/principal"
+	"github.com/alechenninger/cazi/pkg/tokens"
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+var secret = []byte("0123456789abcdef0123456789abcdef")
+
+// token signs a JWT with claims using secret.
+func token(t *testing.T, c map[string]any) cazi.OpaqueToken {
+	t.Helper()
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: secret}, nil)
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+	raw, err := jwt.Signed(signer).Claims(c).Serialize()
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return tokens.JWT(raw)
+}
+
+func resolver(t *testing.T) principal.Resolver {
+	t.Helper()
+	v, err := tokens.NewVerifier(tokens.Options{Keys: tokens.StaticKeys{{Key: secret}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	users := principal.Mapping{Type: "user"}
+	return principal.Chain(
+		principal.References("user"),
+		principal.FromClaims(users),
+		principal.FromTokens(v, users),
+	)
+}
+
+func TestChain(t *testing.T) {
+	r := resolver(t)
+	alice := cazi.ResourceReference{Type: "user", ID: "alice"}
+
+	t.Run("Resource reference", func(t *testing.T) {
+		p, err := r.Resolve(context.Background(), alice)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if p.Reference != alice {
+			t.Errorf("expected %v, got %v", alice, p.Reference)
+		}
+		if sub, _ := claims.Sub.Get(p.Claims); sub != "alice" {
+			t.Errorf("expected sub 'alice', got '%s'", sub)
+		}
+		if !p.Verified {
+			t.Error("expected verified claims")
+		}
+	})
+
+	t.Run("Claims", func(t *testing.T) {
+		assertion := cazi.Claims{"sub": "alice", "email": "alice@example.com"}
+		p, err := r.Resolve(context.Background(), assertion)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if p.Reference != alice {
+			t.Errorf("expected %v, got %v", alice, p.Reference)
+		}
+		if email, _ := claims.Email.Get(p.Claims); email != "alice@example.com" {
+			t.Errorf("expected email claim, got %v", p.Claims)
+		}
+		if p.Verified {
+			t.Error("expected unverified claims")
+		}
+		p.Claims["email"] = "changed"
+		if assertion["email"] != "alice@example.com" {
+			t.Error("expected the assertion to be unchanged")
+		}
+	})
+
+	t.Run("JWT", func(t *testing.T) {
+		p, err := r.Resolve(context.Background(), token(t, map[string]any{
+			"sub":   "alice",
+			"roles": []string{"admin"},
+			"iss":   "https://issuer.example.com",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+		}))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if p.Reference != alice {
+			t.Errorf("expected %v, got %v", alice, p.Reference)
+		}
+		if roles, _ := claims.Roles.Get(p.Claims); len(roles) != 1 || roles[0] != "admin" {
+			t.Errorf("expected roles [admin], got %v", p.Claims)
+		}
+		if !p.Verified {
+			t.Error("expected verified claims")
+		}
+		if _, ok := p.Claims["exp"]; ok {
+			t.Errorf("expected token claims to be removed, got %v", p.Claims)
+		}
+	})
+
+	tests := []struct {
+		name      string
+		assertion cazi.Assertion
+		err       error
+	}{
+		{"Unsupported reference type", cazi.ResourceReference{Type: "group", ID: "eng"}, cazi.ErrUnsupportedResourceType},
+		{"Claims without a subject", cazi.Claims{"email": "alice@example.com"}, cazi.ErrInvalidArgument},
+		{"Invalid token", tokens.JWT("not.a.jwt"), tokens.ErrInvalidToken},
+		{"Invalid tokens are invalid arguments", tokens.JWT("not.a.jwt"), cazi.ErrInvalidArgument},
+		{"Unsupported token type", cazi.OpaqueToken{Type: "saml"}, cazi.ErrUnsupportedAssertion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := r.Resolve(context.Background(), tt.assertion); !errors.Is(err, tt.err) {
+				t.Errorf("expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestMappingID(t *testing.T) {
+	r := principal.FromClaims(principal.Mapping{Type: "user", ID: claims.PreferredUsername})
+
+	p, err := r.Resolve(context.Background(), cazi.Claims{"sub": "f81d4fae", "preferred_username": "alice"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Reference != (cazi.ResourceReference{Type: "user", ID: "alice"}) {
+		t.Errorf("expected user:alice, got %v", p.Reference)
+	}
+}
+
+func TestEnrich(t *testing.T) {
+	r := principal.Enrich(principal.References(), func(ctx context.Context, p principal.Principal) (principal.Principal, error) {
+		claims.Roles.Set(p.Claims, []string{"viewer"})
+		return p, nil
+	})
+
+	p, err := r.Resolve(context.Background(), cazi.ResourceReference{Type: "user", ID: "alice"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if roles, _ := claims.Roles.Get(p.Claims); len(roles) != 1 || roles[0] != "viewer" {
+		t.Errorf("expected roles [viewer], got %v", p.Claims)
+	}
+}
@@ -0,0 +1,140 @@
+package tokens
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+)
+
+// maxJWKSBytes bounds the size of a JWKS document.
+const maxJWKSBytes = 1 << 20
+
+// JWKSOptions configures a JWKS key source.
+type JWKSOptions struct {
+	// URL of the JWKS document, e.g. an issuer's jwks_uri.
+	URL string
+
+	// Path of a file containing the JWKS document, used instead of URL.
+	Path string
+
+	// HTTPClient fetches URL. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+
+	// RefreshInterval is how long fetched keys are used before fetching them again.
+	// Defaults to one hour.
+	RefreshInterval time.Duration
+
+	// MinRefreshInterval is the minimum time between fetches when a token names a key
+	// that wasn't in the last fetch, as happens after the issuer rotates its keys.
+	// Defaults to one minute.
+	MinRefreshInterval time.Duration
+
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// JWKS is a KeySource that fetches keys from a JSON Web Key Set document and caches them.
+//
+// Keys are fetched again after RefreshInterval, or sooner when a token names an unknown key,
+// so that rotated keys are picked up. If fetching fails, the previously fetched keys continue to be used.
+type JWKS struct {
+	opts JWKSOptions
+
+	mu        sync.Mutex // held while fetching, so concurrent callers share one fetch
+	keys      []Key
+	fetched   bool
+	checkedAt time.Time // time of the last fetch attempt
+}
+
+var _ KeySource = (*JWKS)(nil)
+
+// NewJWKS creates a JWKS key source. Keys are fetched on first use.
+func NewJWKS(opts JWKSOptions) (*JWKS, error) {
+	if (opts.URL == "") == (opts.Path == "") {
+		return nil, errors.New("JWKS requires exactly one of a URL or path")
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+	if opts.RefreshInterval <= 0 {
+		opts.RefreshInterval = time.Hour
+	}
+	if opts.MinRefreshInterval <= 0 {
+		opts.MinRefreshInterval = time.Minute
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &JWKS{opts: opts}, nil
+}
+
+// Keys implements KeySource.
+// If keys have never been fetched successfully, the error wraps cazi.ErrUnavailable.
+func (j *JWKS) Keys(ctx context.Context, id string) ([]Key, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := j.opts.Now()
+	if !j.fetched || now.Sub(j.checkedAt) >= j.opts.RefreshInterval {
+		if err := j.refresh(ctx, now); err != nil && !j.fetched {
+			return nil, err
+		}
+	}
+
+	keys := matchKeys(j.keys, id)
+	if len(keys) == 0 && id != "" && now.Sub(j.checkedAt) >= j.opts.MinRefreshInterval {
+		// The key may have been added since the last fetch.
+		if err := j.refresh(ctx, now); err != nil {
+			return nil, err
+		}
+		keys = matchKeys(j.keys, id)
+	}
+	return keys, nil
+}
+
+// refresh fetches the keys. Callers must hold j.mu.
+func (j *JWKS) refresh(ctx context.Context, now time.Time) error {
+	j.checkedAt = now
+
+	data, err := j.fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: failed to fetch JWKS: %w", cazi.ErrUnavailable, err)
+	}
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return fmt.Errorf("%w: %w", cazi.ErrUnavailable, err)
+	}
+
+	j.keys, j.fetched = keys, true
+	return nil
+}
+
+func (j *JWKS) fetch(ctx context.Context) ([]byte, error) {
+	if j.opts.Path != "" {
+		return os.ReadFile(j.opts.Path)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.opts.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := j.opts.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxJWKSBytes))
+}
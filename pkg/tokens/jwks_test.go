@@ -0,0 +1,186 @@
+package tokens_test
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"github.com/alechenninger/cazi/pkg/tokens"
+	"github.com/go-jose/go-jose/v4"
+)
+
+// jwks encodes a key set containing the public halves of keys.
+func jwks(t *testing.T, keys ...jose.JSONWebKey) []byte {
+	t.Helper()
+	for i, k := range keys {
+		keys[i] = k.Public()
+	}
+	data, err := json.Marshal(jose.JSONWebKeySet{Keys: keys})
+	if err != nil {
+		t.Fatalf("failed to encode JWKS: %v", err)
+	}
+	return data
+}
+
+// jwksServer serves a JWKS document that can be replaced, counting requests.
+type jwksServer struct {
+	mu       sync.Mutex
+	document []byte
+	status   int
+	requests int
+}
+
+func (s *jwksServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+	if s.status != 0 {
+		w.WriteHeader(s.status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(s.document)
+}
+
+func (s *jwksServer) serve(document []byte, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.document, s.status = document, status
+}
+
+func (s *jwksServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func TestJWKSFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, jwks(t, jose.JSONWebKey{Key: p256Key, KeyID: "k1", Algorithm: "ES256"}), 0o600); err != nil {
+		t.Fatalf("failed to write JWKS: %v", err)
+	}
+	keys, err := tokens.NewJWKS(tokens.JWKSOptions{Path: path})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	v := verifier(t, tokens.Options{Keys: keys})
+	if _, err := v.Verify(context.Background(), sign(t, jose.ES256, p256Key, "k1", standard("alice"))); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestJWKSRefresh(t *testing.T) {
+	server := &jwksServer{}
+	server.serve(jwks(t, jose.JSONWebKey{Key: rsaKey, KeyID: "k1"}), 0)
+	srv := httptest.NewServer(server)
+	t.Cleanup(srv.Close)
+
+	clock := now
+	keys, err := tokens.NewJWKS(tokens.JWKSOptions{
+		URL:                srv.URL,
+		RefreshInterval:    time.Hour,
+		MinRefreshInterval: time.Minute,
+		Now:                func() time.Time { return clock },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	v := verifier(t, tokens.Options{Keys: keys})
+	verify := func(alg jose.SignatureAlgorithm, key any, kid string) error {
+		_, err := v.Verify(context.Background(), sign(t, alg, key, kid, standard("alice")))
+		return err
+	}
+
+	t.Run("Keys are cached", func(t *testing.T) {
+		for range 3 {
+			if err := verify(jose.RS256, rsaKey, "k1"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if n := server.count(); n != 1 {
+			t.Errorf("expected 1 request, got %d", n)
+		}
+	})
+
+	// The issuer rotates to a new key.
+	server.serve(jwks(t, jose.JSONWebKey{Key: rsaKey, KeyID: "k1"}, jose.JSONWebKey{Key: p256Key, KeyID: "k2"}), 0)
+
+	t.Run("Unknown keys are not fetched more often than the minimum interval", func(t *testing.T) {
+		if err := verify(jose.ES256, p256Key, "k2"); !errors.Is(err, tokens.ErrUnknownKey) {
+			t.Errorf("expected ErrUnknownKey, got %v", err)
+		}
+		if n := server.count(); n != 1 {
+			t.Errorf("expected 1 request, got %d", n)
+		}
+	})
+
+	t.Run("Rotated keys are fetched", func(t *testing.T) {
+		clock = clock.Add(2 * time.Minute)
+		if err := verify(jose.ES256, p256Key, "k2"); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if n := server.count(); n != 2 {
+			t.Errorf("expected 2 requests, got %d", n)
+		}
+	})
+
+	t.Run("Stale keys are used if fetching fails", func(t *testing.T) {
+		server.serve(nil, http.StatusInternalServerError)
+		clock = clock.Add(2 * time.Hour)
+		if err := verify(jose.RS256, rsaKey, "k1"); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if n := server.count(); n != 3 {
+			t.Errorf("expected 3 requests, got %d", n)
+		}
+	})
+}
+
+func TestJWKSUnavailable(t *testing.T) {
+	server := &jwksServer{}
+	server.serve(nil, http.StatusServiceUnavailable)
+	srv := httptest.NewServer(server)
+	t.Cleanup(srv.Close)
+
+	keys, err := tokens.NewJWKS(tokens.JWKSOptions{URL: srv.URL})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	v := verifier(t, tokens.Options{Keys: keys})
+
+	_, err = v.Verify(context.Background(), sign(t, jose.RS256, rsaKey, "k1", standard("alice")))
+	if !errors.Is(err, cazi.ErrUnavailable) {
+		t.Errorf("expected cazi.ErrUnavailable, got %v", err)
+	}
+}
+
+func TestParseJWKS(t *testing.T) {
+	data, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: rsaKey, KeyID: "private", Algorithm: "RS256", Use: "sig"},
+		{Key: &p256Key.PublicKey, KeyID: "encryption", Use: "enc"},
+	}})
+	if err != nil {
+		t.Fatalf("failed to encode JWKS: %v", err)
+	}
+
+	keys, err := tokens.ParseJWKS(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(keys) != 1 || keys[0].ID != "private" || keys[0].Algorithm != "RS256" {
+		t.Fatalf("expected only the signing key, got %+v", keys)
+	}
+	if _, ok := keys[0].Key.(*rsa.PublicKey); !ok {
+		t.Errorf("expected the public half of the key, got %T", keys[0].Key)
+	}
+}
@@ -0,0 +1,76 @@
+package tokens
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-jose/go-jose/v4"
+)
+
+// Key is a key that may have signed tokens.
+type Key struct {
+	// ID is the key ID, matched against the kid header of tokens.
+	// Tokens without a kid are tried against every key.
+	ID string
+
+	// Algorithm is the only signature algorithm accepted for this key, e.g. "RS256".
+	// If empty, any algorithm suitable for the key's type is accepted.
+	Algorithm string
+
+	// Key is the verification key:
+	// *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey, or []byte for HMAC.
+	Key any
+}
+
+// KeySource provides the keys used to verify token signatures.
+type KeySource interface {
+	// Keys returns the keys with the given ID, or all keys if id is empty.
+	Keys(ctx context.Context, id string) ([]Key, error)
+}
+
+// StaticKeys is a KeySource with a fixed set of keys.
+type StaticKeys []Key
+
+// Keys implements KeySource.
+func (s StaticKeys) Keys(ctx context.Context, id string) ([]Key, error) {
+	return matchKeys(s, id), nil
+}
+
+// ParseJWKS parses a JSON Web Key Set (RFC 7517), e.g. as served from an issuer's jwks_uri.
+// Keys intended for encryption ("use": "enc") are skipped.
+func ParseJWKS(data []byte) ([]Key, error) {
+	var set jose.JSONWebKeySet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	keys := make([]Key, 0, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use == "enc" {
+			continue
+		}
+		if _, symmetric := k.Key.([]byte); !symmetric && !k.IsPublic() {
+			k = k.Public() // only the public half is needed to verify
+		}
+		if !k.Valid() {
+			return nil, fmt.Errorf("invalid JWKS: invalid key %q", k.KeyID)
+		}
+		keys = append(keys, Key{ID: k.KeyID, Algorithm: k.Algorithm, Key: k.Key})
+	}
+	return keys, nil
+}
+
+// matchKeys returns the keys with the given ID, or all keys if id is empty.
+func matchKeys(keys []Key, id string) []Key {
+	if id == "" {
+		return keys
+	}
+	var matched []Key
+	for _, k := range keys {
+		if k.ID == id {
+			matched = append(matched, k)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,245 @@
+// Package tokens verifies JSON Web Tokens carried in cazi.OpaqueToken assertions
+// and converts them to cazi.Claims.
+//
+// Signatures are verified against keys from a [KeySource]: fixed keys with [StaticKeys],
+// or a JSON Web Key Set fetched from an issuer with [JWKS].
+// The standard claims exp, nbf, iss and aud are validated before the claims are returned.
+package tokens
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+// TypeJWT is the OpaqueToken type of JSON Web Tokens.
+const TypeJWT = "jwt"
+
+// JWT returns an assertion carrying a JSON Web Token in compact serialization.
+func JWT(raw string) cazi.OpaqueToken {
+	return cazi.OpaqueToken{Type: TypeJWT, Raw: []byte(raw)}
+}
+
+var (
+	// ErrInvalidToken is returned when a token is malformed or fails verification.
+	// The errors below wrap it with the reason.
+	ErrInvalidToken = errors.New("invalid token")
+
+	// ErrInvalidSignature is returned when no key verifies a token's signature.
+	ErrInvalidSignature = fmt.Errorf("%w: invalid signature", ErrInvalidToken)
+
+	// ErrUnknownKey is returned when no key matches a token's key ID.
+	ErrUnknownKey = fmt.Errorf("%w: unknown signing key", ErrInvalidToken)
+
+	// ErrExpired is returned when a token's expiry (exp) has passed.
+	ErrExpired = fmt.Errorf("%w: expired", ErrInvalidToken)
+
+	// ErrNotYetValid is returned when a token's not before time (nbf) has not been reached.
+	ErrNotYetValid = fmt.Errorf("%w: not yet valid", ErrInvalidToken)
+
+	// ErrInvalidIssuer is returned when a token's issuer (iss) is not the expected issuer.
+	ErrInvalidIssuer = fmt.Errorf("%w: unexpected issuer", ErrInvalidToken)
+
+	// ErrInvalidAudience is returned when a token's audience (aud) includes none of the expected audiences.
+	ErrInvalidAudience = fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+
+	// ErrInvalidType is returned when a token's type header (typ) is not the expected type.
+	ErrInvalidType = fmt.Errorf("%w: unexpected type", ErrInvalidToken)
+)
+
+// Algorithms are the signature algorithms supported by Verifier.
+var Algorithms = []string{
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+	"HS256", "HS384", "HS512",
+}
+
+// Options configures a Verifier.
+type Options struct {
+	// Keys provides the keys that may have signed tokens. Required.
+	Keys KeySource
+
+	// Algorithms are the accepted signature algorithms. Defaults to all of [Algorithms].
+	// A key is only ever used with algorithms suitable for its type,
+	// so a public key can't be used as an HMAC secret.
+	Algorithms []string
+
+	// Issuer, if set, is the required value of the iss claim.
+	Issuer string
+
+	// Audience, if set, are the accepted values of the aud claim; a token must be issued for at least one.
+	Audience []string
+
+	// Type, if set, is the required value of the typ header, e.g. "at+jwt".
+	// Explicit types keep tokens issued for one purpose from being accepted for another.
+	Type string
+
+	// Leeway is the allowed clock skew when checking exp and nbf.
+	Leeway time.Duration
+
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// Verifier verifies signed JWTs and returns their claims.
+type Verifier struct {
+	opts       Options
+	algorithms []jose.SignatureAlgorithm
+}
+
+// NewVerifier creates a verifier.
+func NewVerifier(opts Options) (*Verifier, error) {
+	if opts.Keys == nil {
+		return nil, errors.New("verifier requires keys")
+	}
+	if len(opts.Algorithms) == 0 {
+		opts.Algorithms = Algorithms
+	}
+	algorithms := make([]jose.SignatureAlgorithm, len(opts.Algorithms))
+	for i, alg := range opts.Algorithms {
+		if !slices.Contains(Algorithms, alg) {
+			return nil, fmt.Errorf("unsupported algorithm %q", alg)
+		}
+		algorithms[i] = jose.SignatureAlgorithm(alg)
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Verifier{opts: opts, algorithms: algorithms}, nil
+}
+
+// Verify verifies a token in compact serialization and returns its claims.
+//
+// Errors from verification wrap [ErrInvalidToken].
+// Errors from the key source are returned as is; for [JWKS], they wrap cazi.ErrUnavailable.
+func (v *Verifier) Verify(ctx context.Context, raw string) (cazi.Claims, error) {
+	jws, err := jose.ParseSignedCompact(raw, v.algorithms)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	header := jws.Signatures[0].Header
+	if typ, _ := header.ExtraHeaders[jose.HeaderType].(string); v.opts.Type != "" && !strings.EqualFold(typ, v.opts.Type) {
+		return nil, fmt.Errorf("%w %q", ErrInvalidType, typ)
+	}
+
+	keys, err := v.opts.Keys.Keys(ctx, header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, header.KeyID)
+	}
+
+	for _, key := range keys {
+		if key.Algorithm != "" && key.Algorithm != header.Algorithm {
+			continue
+		}
+		payload, err := jws.Verify(key.Key)
+		if err != nil {
+			continue
+		}
+		return v.claims(payload)
+	}
+	return nil, ErrInvalidSignature
+}
+
+// claims decodes and validates the payload of a verified token.
+func (v *Verifier) claims(payload []byte) (cazi.Claims, error) {
+	var std jwt.Claims
+	if err := json.Unmarshal(payload, &std); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	var claims map[string]any
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	if err := v.validate(std); err != nil {
+		return nil, err
+	}
+	return normalize(claims), nil
+}
+
+// VerifyAssertion verifies an assertion carrying a JWT and returns its claims.
+// Assertions other than an OpaqueToken of type [TypeJWT] are rejected with an error wrapping cazi.ErrUnsupportedAssertion.
+func (v *Verifier) VerifyAssertion(ctx context.Context, a cazi.Assertion) (cazi.Claims, error) {
+	token, ok := a.(cazi.OpaqueToken)
+	if !ok || token.Type != TypeJWT {
+		return nil, fmt.Errorf("%w: expected a %s token, got %s", cazi.ErrUnsupportedAssertion, TypeJWT, describe(a))
+	}
+	return v.Verify(ctx, string(token.Raw))
+}
+
+// validate checks the standard claims.
+func (v *Verifier) validate(std jwt.Claims) error {
+	now := v.opts.Now()
+	if std.Expiry != nil && now.Add(-v.opts.Leeway).After(std.Expiry.Time()) {
+		return fmt.Errorf("%w at %s", ErrExpired, std.Expiry.Time().UTC().Format(time.RFC3339))
+	}
+	if std.NotBefore != nil && now.Add(v.opts.Leeway).Before(std.NotBefore.Time()) {
+		return fmt.Errorf("%w until %s", ErrNotYetValid, std.NotBefore.Time().UTC().Format(time.RFC3339))
+	}
+	if v.opts.Issuer != "" && std.Issuer != v.opts.Issuer {
+		return fmt.Errorf("%w %q", ErrInvalidIssuer, std.Issuer)
+	}
+	if len(v.opts.Audience) > 0 && !slices.ContainsFunc(v.opts.Audience, std.Audience.Contains) {
+		return fmt.Errorf("%w %q", ErrInvalidAudience, []string(std.Audience))
+	}
+	return nil
+}
+
+// normalize converts arrays of strings in decoded JSON claims to []string,
+// so they can be read with accessors such as claims.Roles.
+func normalize(claims map[string]any) cazi.Claims {
+	for k, v := range claims {
+		claims[k] = normalizeValue(v)
+	}
+	return claims
+}
+
+func normalizeValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		return map[string]any(normalize(v))
+	case []any:
+		if strs, ok := asStrings(v); ok {
+			return strs
+		}
+		for i, e := range v {
+			v[i] = normalizeValue(e)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// asStrings returns values as []string if they are all strings.
+func asStrings(values []any) ([]string, bool) {
+	strs := make([]string, len(values))
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			return nil, false
+		}
+		strs[i] = s
+	}
+	return strs, true
+}
+
+// describe names the type of an assertion for error messages.
+func describe(a cazi.Assertion) string {
+	if token, ok := a.(cazi.OpaqueToken); ok {
+		return fmt.Sprintf("%q token", token.Type)
+	}
+	return fmt.Sprintf("%T", a)
+}
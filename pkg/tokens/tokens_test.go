@@ -0,0 +1,241 @@
+package tokens_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"github.com/alechenninger/cazi/pkg/claims"
+	"github.com/alechenninger/cazi/pkg/tokens"
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+var (
+	rsaKey, _       = rsa.GenerateKey(rand.Reader, 2048)
+	p256Key, _      = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	p384Key, _      = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	edPub, edKey, _ = ed25519.GenerateKey(rand.Reader)
+	secret          = []byte("0123456789abcdef0123456789abcdef")
+)
+
+var now = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// sign serializes a JWT with the given claims, signed with key.
+func sign(t *testing.T, alg jose.SignatureAlgorithm, key any, kid string, claims any) string {
+	t.Helper()
+	opts := (&jose.SignerOptions{}).WithType("JWT")
+	if kid != "" {
+		opts = opts.WithHeader(jose.HeaderKey("kid"), kid)
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: key}, opts)
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+	raw, err := jwt.Signed(signer).Claims(claims).Serialize()
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return raw
+}
+
+func verifier(t *testing.T, opts tokens.Options) *tokens.Verifier {
+	t.Helper()
+	if opts.Now == nil {
+		opts.Now = func() time.Time { return now }
+	}
+	v, err := tokens.NewVerifier(opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return v
+}
+
+func standard(sub string) jwt.Claims {
+	return jwt.Claims{
+		Subject:  sub,
+		Issuer:   "https://issuer.example.com",
+		Audience: jwt.Audience{"widgets"},
+		Expiry:   jwt.NewNumericDate(now.Add(time.Hour)),
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		alg     jose.SignatureAlgorithm
+		signKey any
+		key     any
+	}{
+		{jose.RS256, rsaKey, &rsaKey.PublicKey},
+		{jose.PS384, rsaKey, &rsaKey.PublicKey},
+		{jose.ES256, p256Key, &p256Key.PublicKey},
+		{jose.ES384, p384Key, &p384Key.PublicKey},
+		{jose.EdDSA, edKey, edPub},
+		{jose.HS256, secret, secret},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.alg), func(t *testing.T) {
+			v := verifier(t, tokens.Options{Keys: tokens.StaticKeys{{ID: "k1", Key: tt.key}}})
+			raw := sign(t, tt.alg, tt.signKey, "k1", map[string]any{
+				"sub":          "alice",
+				"roles":        []string{"admin", "editor"},
+				"realm_access": map[string]any{"roles": []string{"viewer"}},
+			})
+
+			got, err := v.Verify(context.Background(), raw)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if sub, _ := claims.Sub.Get(got); sub != "alice" {
+				t.Errorf("expected sub 'alice', got '%s'", sub)
+			}
+			if roles, _ := claims.Roles.Get(got); !reflect.DeepEqual(roles, []string{"admin", "editor"}) {
+				t.Errorf("expected roles [admin editor], got %v", roles)
+			}
+			realmRoles := claims.Nested[[]string]("realm_access", "roles")
+			if roles, _ := realmRoles.Get(got); !reflect.DeepEqual(roles, []string{"viewer"}) {
+				t.Errorf("expected realm roles [viewer], got %v", roles)
+			}
+		})
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	keys := tokens.StaticKeys{{ID: "k1", Key: &rsaKey.PublicKey}}
+	v := verifier(t, tokens.Options{
+		Keys:     keys,
+		Issuer:   "https://issuer.example.com",
+		Audience: []string{"widgets", "gadgets"},
+	})
+
+	tests := []struct {
+		name string
+		raw  string
+		err  error
+	}{
+		{"Malformed", "not.a.jwt", tokens.ErrInvalidToken},
+		{"Unknown key", sign(t, jose.RS256, rsaKey, "k2", standard("alice")), tokens.ErrUnknownKey},
+		{"Wrong key", sign(t, jose.ES256, p256Key, "k1", standard("alice")), tokens.ErrInvalidSignature},
+		{"Expired", sign(t, jose.RS256, rsaKey, "k1", func() jwt.Claims {
+			c := standard("alice")
+			c.Expiry = jwt.NewNumericDate(now.Add(-time.Second))
+			return c
+		}()), tokens.ErrExpired},
+		{"Not yet valid", sign(t, jose.RS256, rsaKey, "k1", func() jwt.Claims {
+			c := standard("alice")
+			c.NotBefore = jwt.NewNumericDate(now.Add(time.Minute))
+			return c
+		}()), tokens.ErrNotYetValid},
+		{"Issuer", sign(t, jose.RS256, rsaKey, "k1", func() jwt.Claims {
+			c := standard("alice")
+			c.Issuer = "https://evil.example.com"
+			return c
+		}()), tokens.ErrInvalidIssuer},
+		{"Audience", sign(t, jose.RS256, rsaKey, "k1", func() jwt.Claims {
+			c := standard("alice")
+			c.Audience = jwt.Audience{"sprockets"}
+			return c
+		}()), tokens.ErrInvalidAudience},
+		// A verifier that accepted HMAC with a public key as the secret would accept this forgery.
+		{"Public key as HMAC secret", sign(t, jose.HS256, x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey), "k1", standard("alice")), tokens.ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.Verify(context.Background(), tt.raw)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("expected %v, got %v", tt.err, err)
+			}
+			if !errors.Is(err, tokens.ErrInvalidToken) {
+				t.Errorf("expected error to wrap ErrInvalidToken, got %v", err)
+			}
+		})
+	}
+
+	t.Run("Leeway", func(t *testing.T) {
+		c := standard("alice")
+		c.Expiry = jwt.NewNumericDate(now.Add(-time.Second))
+		v := verifier(t, tokens.Options{Keys: keys, Leeway: time.Minute})
+		if _, err := v.Verify(context.Background(), sign(t, jose.RS256, rsaKey, "k1", c)); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Key algorithm", func(t *testing.T) {
+		v := verifier(t, tokens.Options{Keys: tokens.StaticKeys{{ID: "k1", Algorithm: "RS256", Key: &rsaKey.PublicKey}}})
+		_, err := v.Verify(context.Background(), sign(t, jose.PS256, rsaKey, "k1", standard("alice")))
+		if !errors.Is(err, tokens.ErrInvalidSignature) {
+			t.Errorf("expected ErrInvalidSignature, got %v", err)
+		}
+	})
+
+	t.Run("Disallowed algorithm", func(t *testing.T) {
+		v := verifier(t, tokens.Options{Keys: keys, Algorithms: []string{"ES256"}})
+		_, err := v.Verify(context.Background(), sign(t, jose.RS256, rsaKey, "k1", standard("alice")))
+		if !errors.Is(err, tokens.ErrInvalidToken) {
+			t.Errorf("expected ErrInvalidToken, got %v", err)
+		}
+	})
+
+	t.Run("Type", func(t *testing.T) {
+		v := verifier(t, tokens.Options{Keys: keys, Type: "at+jwt"})
+		_, err := v.Verify(context.Background(), sign(t, jose.RS256, rsaKey, "k1", standard("alice")))
+		if !errors.Is(err, tokens.ErrInvalidType) {
+			t.Errorf("expected ErrInvalidType, got %v", err)
+		}
+	})
+
+	t.Run("Tokens without a key ID try every key", func(t *testing.T) {
+		v := verifier(t, tokens.Options{Keys: tokens.StaticKeys{
+			{ID: "k1", Key: &p256Key.PublicKey},
+			{ID: "k2", Key: &rsaKey.PublicKey},
+		}})
+		if _, err := v.Verify(context.Background(), sign(t, jose.RS256, rsaKey, "", standard("alice"))); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestVerifyAssertion(t *testing.T) {
+	v := verifier(t, tokens.Options{Keys: tokens.StaticKeys{{Key: secret}}})
+
+	t.Run("JWT", func(t *testing.T) {
+		got, err := v.VerifyAssertion(context.Background(), tokens.JWT(sign(t, jose.HS256, secret, "", standard("alice"))))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if sub, _ := claims.Sub.Get(got); sub != "alice" {
+			t.Errorf("expected sub 'alice', got '%s'", sub)
+		}
+	})
+
+	t.Run("Other assertions", func(t *testing.T) {
+		for _, a := range []cazi.Assertion{
+			cazi.Claims{"sub": "alice"},
+			cazi.OpaqueToken{Type: "saml", Raw: []byte("<assertion/>")},
+		} {
+			if _, err := v.VerifyAssertion(context.Background(), a); !errors.Is(err, cazi.ErrUnsupportedAssertion) {
+				t.Errorf("expected cazi.ErrUnsupportedAssertion, got %v", err)
+			}
+		}
+	})
+}
+
+func TestNewVerifier(t *testing.T) {
+	if _, err := tokens.NewVerifier(tokens.Options{}); err == nil {
+		t.Error("expected error without keys")
+	}
+	if _, err := tokens.NewVerifier(tokens.Options{Keys: tokens.StaticKeys{}, Algorithms: []string{"none"}}); err == nil {
+		t.Error("expected error for unsupported algorithm")
+	}
+}
@@ -0,0 +1,289 @@
+// Package txntoken mints and verifies Transaction Tokens (draft-ietf-oauth-transaction-tokens)
+// carrying the AuthorizationContext of an allowed decision.
+//
+// A service that calls the PDP mints a token from the CheckRequest and CheckResponse with a [Minter]
+// and passes it to the services it calls in the [Header] header.
+// Those services verify it with a [Verifier] and get back the AuthorizationContext,
+// without calling the PDP again.
+//
+// See: https://www.ietf.org/archive/id/draft-ietf-oauth-transaction-tokens-06.html
+package txntoken
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"github.com/alechenninger/cazi/pkg/claims"
+	"github.com/alechenninger/cazi/pkg/tokens"
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+// Type is the typ header of Transaction Tokens.
+const Type = "txntoken+jwt"
+
+// Header is the HTTP header that carries a Transaction Token between services.
+const Header = "Txn-Token"
+
+// DefaultLifetime is how long minted tokens are valid if MinterOptions.Lifetime is not set.
+// Transaction Tokens are meant to live only as long as the transaction.
+const DefaultLifetime = time.Minute
+
+// ErrNotAllowed is returned when minting a token for a decision other than cazi.DecisionAllow.
+var ErrNotAllowed = errors.New("transaction tokens are only minted for allowed decisions")
+
+// Claims of Transaction Tokens.
+var (
+	txn   = claims.TopLevel[string]("txn")
+	scope = claims.TopLevel[string]("scope")
+	rctx  = claims.TopLevel[map[string]any]("rctx")
+	tctx  = claims.TopLevel[map[string]any]("tctx")
+	reqWL = claims.TopLevel[string]("req_wl")
+	iat   = claims.TopLevel[float64]("iat")
+	exp   = claims.TopLevel[float64]("exp")
+	iss   = claims.TopLevel[string]("iss")
+)
+
+// MinterOptions configures a Minter.
+type MinterOptions struct {
+	// Audience identifies the trust domain the tokens are valid in (aud). Required.
+	Audience string
+
+	// Issuer identifies the token service (iss). Optional.
+	Issuer string
+
+	// Workload identifies the workload requesting tokens (req_wl). Optional.
+	Workload string
+
+	// Algorithm is the signature algorithm, one of tokens.Algorithms. Required.
+	Algorithm string
+
+	// Key is the signing key:
+	// *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey, or []byte for HMAC.
+	Key any
+
+	// KeyID is the kid header, identifying Key to verifiers. Optional.
+	KeyID string
+
+	// Lifetime is how long tokens are valid. Defaults to [DefaultLifetime].
+	Lifetime time.Duration
+
+	// Scope returns the scope of the transaction. Defaults to the request's verb.
+	Scope func(req cazi.CheckRequest) string
+
+	// TransactionID returns the unique identifier of the transaction (txn),
+	// e.g. from a request ID or trace carried by ctx. Defaults to a random identifier.
+	TransactionID func(ctx context.Context) string
+
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// Minter mints signed Transaction Tokens from authorization decisions.
+type Minter struct {
+	opts   MinterOptions
+	signer jose.Signer
+}
+
+// NewMinter creates a minter.
+func NewMinter(opts MinterOptions) (*Minter, error) {
+	if opts.Audience == "" {
+		return nil, errors.New("minter requires an audience")
+	}
+	if opts.Key == nil {
+		return nil, errors.New("minter requires a key")
+	}
+	signerOpts := (&jose.SignerOptions{}).WithType(Type)
+	if opts.KeyID != "" {
+		signerOpts = signerOpts.WithHeader(jose.HeaderKey("kid"), opts.KeyID)
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.SignatureAlgorithm(opts.Algorithm), Key: opts.Key}, signerOpts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing key: %w", err)
+	}
+	if opts.Lifetime == 0 {
+		opts.Lifetime = DefaultLifetime
+	}
+	if opts.Scope == nil {
+		opts.Scope = func(req cazi.CheckRequest) string { return req.Verb }
+	}
+	if opts.TransactionID == nil {
+		opts.TransactionID = randomID
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Minter{opts: opts, signer: signer}, nil
+}
+
+// Mint returns a signed token, in compact serialization, for an allowed decision.
+//
+// The token's rctx and tctx are the RequesterContext and TransactionContext of resp.
+// Its sub is the sub of the RequesterContext, if present, otherwise the ID of the subject of req:
+// the ID of a ResourceReference or the sub of Claims.
+// Decisions other than cazi.DecisionAllow are rejected with [ErrNotAllowed].
+func (m *Minter) Mint(ctx context.Context, req cazi.CheckRequest, resp cazi.CheckResponse) (string, error) {
+	if resp.Decision != cazi.DecisionAllow {
+		return "", fmt.Errorf("%w: decision was %v", ErrNotAllowed, resp.Decision)
+	}
+	sub, err := subject(req, resp)
+	if err != nil {
+		return "", err
+	}
+
+	now := m.opts.Now()
+	c := cazi.Claims{
+		"aud": m.opts.Audience,
+		"iat": now.Unix(),
+		"exp": now.Add(m.opts.Lifetime).Unix(),
+		"sub": sub,
+	}
+	txn.Set(c, m.opts.TransactionID(ctx))
+	if s := m.opts.Scope(req); s != "" {
+		scope.Set(c, s)
+	}
+	if m.opts.Issuer != "" {
+		iss.Set(c, m.opts.Issuer)
+	}
+	if m.opts.Workload != "" {
+		reqWL.Set(c, m.opts.Workload)
+	}
+	if len(resp.Context.RequesterContext) > 0 {
+		rctx.Set(c, resp.Context.RequesterContext)
+	}
+	if len(resp.Context.TransactionContext) > 0 {
+		tctx.Set(c, resp.Context.TransactionContext)
+	}
+
+	raw, err := jwt.Signed(m.signer).Claims(map[string]any(c)).Serialize()
+	if err != nil {
+		return "", fmt.Errorf("failed to sign transaction token: %w", err)
+	}
+	return raw, nil
+}
+
+// subject returns the sub of a token for req.
+func subject(req cazi.CheckRequest, resp cazi.CheckResponse) (string, error) {
+	if sub, ok := claims.Sub.Get(resp.Context.RequesterContext); ok && sub != "" {
+		return sub, nil
+	}
+	switch a := req.Subject.Assertion.(type) {
+	case cazi.ResourceReference:
+		return a.ID, nil
+	case cazi.Claims:
+		if sub, ok := claims.Sub.Get(a); ok && sub != "" {
+			return sub, nil
+		}
+	}
+	return "", fmt.Errorf("%w: can't identify the subject of a %T assertion", cazi.ErrInvalidArgument, req.Subject.Assertion)
+}
+
+// randomID returns a random transaction identifier.
+func randomID(context.Context) string {
+	return rand.Text()
+}
+
+// Token is a verified Transaction Token.
+type Token struct {
+	Transaction string // txn: unique identifier of the transaction
+	Subject     string // sub
+	Scope       string // scope, if any
+	Audience    string // aud: the trust domain the token was verified for
+	Issuer      string // iss, if any
+	Workload    string // req_wl, if any
+	IssuedAt    time.Time
+	Expiry      time.Time
+
+	// Context is the AuthorizationContext the token was minted from.
+	Context cazi.AuthorizationContext
+}
+
+// VerifierOptions configures a Verifier.
+type VerifierOptions struct {
+	// Keys provides the keys that may have signed tokens. Required.
+	Keys tokens.KeySource
+
+	// Audience is the trust domain tokens must be valid in. Required.
+	Audience string
+
+	// Issuer, if set, is the required issuer.
+	Issuer string
+
+	// Algorithms are the accepted signature algorithms. Defaults to all of tokens.Algorithms.
+	Algorithms []string
+
+	// Leeway is the allowed clock skew when checking expiry.
+	Leeway time.Duration
+
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// Verifier verifies Transaction Tokens.
+type Verifier struct {
+	verifier *tokens.Verifier
+	audience string
+}
+
+// NewVerifier creates a verifier.
+func NewVerifier(opts VerifierOptions) (*Verifier, error) {
+	if opts.Audience == "" {
+		return nil, errors.New("verifier requires an audience")
+	}
+	v, err := tokens.NewVerifier(tokens.Options{
+		Keys:       opts.Keys,
+		Algorithms: opts.Algorithms,
+		Issuer:     opts.Issuer,
+		Audience:   []string{opts.Audience},
+		Type:       Type,
+		Leeway:     opts.Leeway,
+		Now:        opts.Now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Verifier{verifier: v, audience: opts.Audience}, nil
+}
+
+// Verify verifies a token in compact serialization.
+//
+// Errors from verification wrap tokens.ErrInvalidToken;
+// tokens with a typ other than [Type], or without txn, sub or exp, are invalid.
+func (v *Verifier) Verify(ctx context.Context, raw string) (Token, error) {
+	c, err := v.verifier.Verify(ctx, raw)
+	if err != nil {
+		return Token{}, err
+	}
+
+	var t Token
+	var ok bool
+	if t.Transaction, ok = txn.Get(c); !ok || t.Transaction == "" {
+		return Token{}, fmt.Errorf("%w: missing txn", tokens.ErrInvalidToken)
+	}
+	if t.Subject, ok = claims.Sub.Get(c); !ok || t.Subject == "" {
+		return Token{}, fmt.Errorf("%w: missing sub", tokens.ErrInvalidToken)
+	}
+	expiry, ok := exp.Get(c)
+	if !ok {
+		return Token{}, fmt.Errorf("%w: missing exp", tokens.ErrInvalidToken)
+	}
+	t.Expiry = time.Unix(int64(expiry), 0)
+	if issued, ok := iat.Get(c); ok {
+		t.IssuedAt = time.Unix(int64(issued), 0)
+	}
+	t.Scope, _ = scope.Get(c)
+	t.Issuer, _ = iss.Get(c)
+	t.Workload, _ = reqWL.Get(c)
+	t.Audience = v.audience
+	if r, ok := rctx.Get(c); ok {
+		t.Context.RequesterContext = cazi.Claims(r)
+	}
+	if tc, ok := tctx.Get(c); ok {
+		t.Context.TransactionContext = cazi.Claims(tc)
+	}
+	return t, nil
+}
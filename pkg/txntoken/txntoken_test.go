@@ -0,0 +1,219 @@
+package txntoken_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alechenninger/cazi/pkg/cazi"
+	"github.com/alechenninger/cazi/pkg/tokens"
+	"github.com/alechenninger/cazi/pkg/txntoken"
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+var (
+	key, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	now    = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock  = func() time.Time { return now }
+)
+
+func minter(t *testing.T, opts txntoken.MinterOptions) *txntoken.Minter {
+	t.Helper()
+	opts.Audience = "example.com"
+	opts.Algorithm = "ES256"
+	opts.Key = key
+	opts.KeyID = "k1"
+	opts.Now = clock
+	m, err := txntoken.NewMinter(opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return m
+}
+
+func verifier(t *testing.T, audience string) *txntoken.Verifier {
+	t.Helper()
+	v, err := txntoken.NewVerifier(txntoken.VerifierOptions{
+		Keys:     tokens.StaticKeys{{ID: "k1", Key: &key.PublicKey}},
+		Audience: audience,
+		Now:      clock,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return v
+}
+
+var (
+	req = cazi.CheckRequest{
+		Subject: cazi.Subject{Assertion: cazi.ResourceReference{Type: "user", ID: "alice"}},
+		Verb:    "read",
+		Object:  cazi.Object{Assertion: cazi.ResourceReference{Type: "widget", ID: "widget-1"}},
+	}
+	allowed = cazi.CheckResponse{
+		Decision: cazi.DecisionAllow,
+		Context: cazi.AuthorizationContext{
+			RequesterContext:   cazi.Claims{"sub": "alice", "roles": []string{"admin"}},
+			TransactionContext: cazi.Claims{"widget_id": "widget-1", "operation": "read"},
+		},
+	}
+)
+
+func TestMintAndVerify(t *testing.T) {
+	m := minter(t, txntoken.MinterOptions{
+		Issuer:        "https://txn.example.com",
+		Workload:      "widgets",
+		TransactionID: func(ctx context.Context) string { return "txn-1" },
+	})
+	raw, err := m.Mint(context.Background(), req, allowed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	token, err := verifier(t, "example.com").Verify(context.Background(), raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := txntoken.Token{
+		Transaction: "txn-1",
+		Subject:     "alice",
+		Scope:       "read",
+		Audience:    "example.com",
+		Issuer:      "https://txn.example.com",
+		Workload:    "widgets",
+		IssuedAt:    now,
+		Expiry:      now.Add(txntoken.DefaultLifetime),
+		Context:     allowed.Context,
+	}
+	token.IssuedAt, token.Expiry = token.IssuedAt.UTC(), token.Expiry.UTC()
+	if !reflect.DeepEqual(token, want) {
+		t.Errorf("expected %+v, got %+v", want, token)
+	}
+}
+
+func TestMint(t *testing.T) {
+	m := minter(t, txntoken.MinterOptions{})
+	v := verifier(t, "example.com")
+
+	t.Run("Random transaction IDs", func(t *testing.T) {
+		seen := map[string]bool{}
+		for range 3 {
+			raw, err := m.Mint(context.Background(), req, allowed)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			token, err := v.Verify(context.Background(), raw)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if seen[token.Transaction] {
+				t.Errorf("expected unique transaction IDs, got %s twice", token.Transaction)
+			}
+			seen[token.Transaction] = true
+		}
+	})
+
+	t.Run("Subject from claims", func(t *testing.T) {
+		req := req
+		req.Subject.Assertion = cazi.Claims{"sub": "bob"}
+		raw, err := m.Mint(context.Background(), req, cazi.CheckResponse{Decision: cazi.DecisionAllow})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		token, err := v.Verify(context.Background(), raw)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if token.Subject != "bob" {
+			t.Errorf("expected subject 'bob', got '%s'", token.Subject)
+		}
+		if token.Context.RequesterContext != nil || token.Context.TransactionContext != nil {
+			t.Errorf("expected empty context, got %+v", token.Context)
+		}
+	})
+
+	t.Run("Unidentified subject", func(t *testing.T) {
+		req := req
+		req.Subject.Assertion = tokens.JWT("opaque")
+		_, err := m.Mint(context.Background(), req, cazi.CheckResponse{Decision: cazi.DecisionAllow})
+		if !errors.Is(err, cazi.ErrInvalidArgument) {
+			t.Errorf("expected cazi.ErrInvalidArgument, got %v", err)
+		}
+	})
+
+	for _, decision := range []cazi.DecisionKind{cazi.DecisionDeny, cazi.DecisionConditional} {
+		t.Run(decision.String(), func(t *testing.T) {
+			_, err := m.Mint(context.Background(), req, cazi.CheckResponse{Decision: decision})
+			if !errors.Is(err, txntoken.ErrNotAllowed) {
+				t.Errorf("expected ErrNotAllowed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	raw, err := minter(t, txntoken.MinterOptions{}).Mint(context.Background(), req, allowed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// sign signs claims with the minter's key and the given type.
+	sign := func(typ string, c map[string]any) string {
+		signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: key}, (&jose.SignerOptions{}).WithType(jose.ContentType(typ)))
+		if err != nil {
+			t.Fatalf("failed to create signer: %v", err)
+		}
+		raw, err := jwt.Signed(signer).Claims(c).Serialize()
+		if err != nil {
+			t.Fatalf("failed to sign token: %v", err)
+		}
+		return raw
+	}
+	valid := map[string]any{"aud": "example.com", "exp": now.Add(time.Minute).Unix(), "sub": "alice", "txn": "txn-1"}
+	without := func(claim string) map[string]any {
+		c := map[string]any{}
+		for k, v := range valid {
+			if k != claim {
+				c[k] = v
+			}
+		}
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		raw      string
+		audience string
+		err      error
+	}{
+		{"Other trust domain", raw, "other.example.com", tokens.ErrInvalidAudience},
+		{"Access token", sign("at+jwt", valid), "example.com", tokens.ErrInvalidType},
+		{"Missing txn", sign(txntoken.Type, without("txn")), "example.com", tokens.ErrInvalidToken},
+		{"Missing sub", sign(txntoken.Type, without("sub")), "example.com", tokens.ErrInvalidToken},
+		{"Missing exp", sign(txntoken.Type, without("exp")), "example.com", tokens.ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := verifier(t, tt.audience).Verify(context.Background(), tt.raw); !errors.Is(err, tt.err) {
+				t.Errorf("expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		token, err := verifier(t, "example.com").Verify(context.Background(), sign(txntoken.Type, valid))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if token.Transaction != "txn-1" {
+			t.Errorf("expected transaction 'txn-1', got '%s'", token.Transaction)
+		}
+	})
+}